@@ -0,0 +1,115 @@
+package tarantool
+
+import (
+	"strings"
+	"sync"
+)
+
+// nameAliases maps client-side names of spaces and indexes to their names
+// in schema. It is shared by all schemas loaded by a connection, so
+// aliases survive schema reloads.
+type nameAliases struct {
+	mutex    sync.RWMutex
+	spaces   map[string]string
+	indexes  map[string]map[string]string
+	foldCase bool
+}
+
+func newNameAliases(opts Opts) *nameAliases {
+	a := &nameAliases{
+		spaces:   make(map[string]string),
+		indexes:  make(map[string]map[string]string),
+		foldCase: opts.CaseInsensitiveNames,
+	}
+	for alias, name := range opts.SpaceAliases {
+		a.spaces[alias] = name
+	}
+	return a
+}
+
+func (a *nameAliases) space(name string) string {
+	if a == nil {
+		return name
+	}
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	if real, ok := a.spaces[name]; ok {
+		return real
+	}
+	return name
+}
+
+func (a *nameAliases) index(space, name string) string {
+	if a == nil {
+		return name
+	}
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	if real, ok := a.indexes[space][name]; ok {
+		return real
+	}
+	return name
+}
+
+// lookupSpace finds space by name, alias or, if enabled, by name in
+// another case.
+func (schema *Schema) lookupSpace(name string) (*Space, bool) {
+	name = schema.aliases.space(name)
+	if space, ok := schema.Spaces[name]; ok {
+		return space, true
+	}
+	if schema.aliases != nil && schema.aliases.foldCase {
+		for spaceName, space := range schema.Spaces {
+			if strings.EqualFold(spaceName, name) {
+				return space, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// lookupIndex finds index of space by name, alias or, if enabled, by
+// name in another case.
+func (schema *Schema) lookupIndex(space *Space, name string) (*Index, bool) {
+	name = schema.aliases.index(space.Name, name)
+	if index, ok := space.Indexes[name]; ok {
+		return index, true
+	}
+	if schema.aliases != nil && schema.aliases.foldCase {
+		for indexName, index := range space.Indexes {
+			if strings.EqualFold(indexName, name) {
+				return index, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// SetSpaceAlias makes requests to space alias go to space name, e.g. to
+// switch from "users" to "users_v2" during blue/green migration without
+// changes at call sites. Empty name removes alias.
+func (conn *Connection) SetSpaceAlias(alias, name string) {
+	conn.aliases.mutex.Lock()
+	defer conn.aliases.mutex.Unlock()
+	if name == "" {
+		delete(conn.aliases.spaces, alias)
+		return
+	}
+	conn.aliases.spaces[alias] = name
+}
+
+// SetIndexAlias makes requests to index alias of space go to index name.
+// Space is a name of space in schema, not an alias. Empty name removes
+// alias.
+func (conn *Connection) SetIndexAlias(space, alias, name string) {
+	conn.aliases.mutex.Lock()
+	defer conn.aliases.mutex.Unlock()
+	if name == "" {
+		delete(conn.aliases.indexes[space], alias)
+		return
+	}
+	if conn.aliases.indexes[space] == nil {
+		conn.aliases.indexes[space] = make(map[string]string)
+	}
+	conn.aliases.indexes[space][alias] = name
+}
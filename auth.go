@@ -3,8 +3,57 @@ package tarantool
 import (
 	"crypto/sha1"
 	"encoding/base64"
+
+	"gopkg.in/vmihailenco/msgpack.v2"
 )
 
+// authAttempts is a number of attempts of Connection.Authenticate when
+// connection is reestablished during authentication.
+const authAttempts = 3
+
+// Auth is an IPROTO_AUTH request with chap-sha1 credentials of user, it
+// is created with NewAuthRequest and sent with Connection.Authenticate.
+type Auth struct {
+	user string
+	pass string
+}
+
+// NewAuthRequest creates request to authenticate as user with password.
+func NewAuthRequest(user, pass string) *Auth {
+	return &Auth{user: user, pass: pass}
+}
+
+// User returns name of user to authenticate as.
+func (req *Auth) User() string {
+	return req.user
+}
+
+// WithAuth returns copy of opts with credentials of req, e.g. to connect
+// as user authenticated with Connection.Authenticate.
+func (opts Opts) WithAuth(req *Auth) Opts {
+	opts.User, opts.Pass = req.user, req.pass
+	return opts
+}
+
+// body returns body of request with password scrambled with salt from
+// greeting of connection.
+func (req *Auth) body(salt string) (func(*msgpack.Encoder) error, error) {
+	scr, err := scramble(salt, req.pass)
+	if err != nil {
+		return nil, err
+	}
+	return authBody(req.user, scr), nil
+}
+
+func authBody(user string, scramble []byte) func(*msgpack.Encoder) error {
+	return func(enc *msgpack.Encoder) error {
+		return enc.Encode(map[uint32]interface{}{
+			KeyUserName: user,
+			KeyTuple:    []interface{}{string("chap-sha1"), string(scramble)},
+		})
+	}
+}
+
 func scramble(encodedSalt, pass string) (scramble []byte, err error) {
 	/* ==================================================================
 		According to: http://tarantool.org/doc/dev_guide/box-protocol.html
@@ -0,0 +1,155 @@
+package bench_test
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+var server = "127.0.0.1:3013"
+var opts = Opts{
+	Timeout: 5 * time.Second,
+	User:    "test",
+	Pass:    "test",
+}
+
+const spaceName = "bench"
+
+type tuple struct {
+	Id   uint
+	Data string
+}
+
+func (t *tuple) EncodeMsgpack(e *msgpack.Encoder) error {
+	e.EncodeSliceLen(2)
+	e.EncodeUint(t.Id)
+	return e.EncodeString(t.Data)
+}
+
+func (t *tuple) DecodeMsgpack(d *msgpack.Decoder) error {
+	var err error
+	if _, err = d.DecodeSliceLen(); err != nil {
+		return err
+	}
+	if t.Id, err = d.DecodeUint(); err != nil {
+		return err
+	}
+	t.Data, err = d.DecodeString()
+	return err
+}
+
+// latencies collects request latencies to report percentiles.
+type latencies struct {
+	mutex sync.Mutex
+	d     []time.Duration
+}
+
+func (l *latencies) add(d time.Duration) {
+	l.mutex.Lock()
+	l.d = append(l.d, d)
+	l.mutex.Unlock()
+}
+
+func (l *latencies) report(b *testing.B) {
+	if len(l.d) == 0 {
+		return
+	}
+	sort.Slice(l.d, func(i, j int) bool { return l.d[i] < l.d[j] })
+	b.ReportMetric(float64(l.d[len(l.d)/2]), "p50-ns")
+	b.ReportMetric(float64(l.d[len(l.d)*99/100]), "p99-ns")
+}
+
+func connect(b *testing.B) *Connection {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		b.Fatalf("Failed to connect: %s", err.Error())
+	}
+	return conn
+}
+
+// run calls request b.N times from parallel goroutines and reports
+// allocations and latencies.
+func run(b *testing.B, request func() error) {
+	var l latencies
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			start := time.Now()
+			if err := request(); err != nil {
+				b.Error(err)
+				return
+			}
+			l.add(time.Since(start))
+		}
+	})
+	b.StopTimer()
+	l.report(b)
+}
+
+func BenchmarkPing(b *testing.B) {
+	conn := connect(b)
+	defer conn.Close()
+
+	run(b, func() error {
+		_, err := conn.Ping()
+		return err
+	})
+}
+
+func BenchmarkSelectHotKey(b *testing.B) {
+	conn := connect(b)
+	defer conn.Close()
+	if _, err := conn.Replace(spaceName, &tuple{Id: 1, Data: "hot"}); err != nil {
+		b.Fatalf("Failed to prepare data: %s", err.Error())
+	}
+
+	run(b, func() error {
+		_, err := conn.Select(spaceName, "primary", 0, 1, IterEq, []interface{}{uint(1)})
+		return err
+	})
+}
+
+func BenchmarkSelectHotKeyTyped(b *testing.B) {
+	conn := connect(b)
+	defer conn.Close()
+	if _, err := conn.Replace(spaceName, &tuple{Id: 1, Data: "hot"}); err != nil {
+		b.Fatalf("Failed to prepare data: %s", err.Error())
+	}
+
+	run(b, func() error {
+		var res []tuple
+		return conn.SelectTyped(spaceName, "primary", 0, 1, IterEq, []interface{}{uint(1)}, &res)
+	})
+}
+
+// BenchmarkInsertBatch sends batches of 100 replaces without waiting for
+// each response.
+func BenchmarkInsertBatch(b *testing.B) {
+	const batch = 100
+	conn := connect(b)
+	defer conn.Close()
+
+	var mutex sync.Mutex
+	id := uint(1000)
+	run(b, func() error {
+		mutex.Lock()
+		start := id
+		id += batch
+		mutex.Unlock()
+		futures := make([]*Future, batch)
+		for i := range futures {
+			futures[i] = conn.ReplaceAsync(spaceName, &tuple{Id: start + uint(i), Data: "batch"})
+		}
+		for _, fut := range futures {
+			if err := fut.Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
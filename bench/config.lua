@@ -0,0 +1,14 @@
+box.cfg{
+    listen = 3013,
+    wal_dir = 'xlog',
+    snap_dir = 'snap',
+    wal_mode = 'none',
+}
+
+box.once("init", function()
+    local s = box.schema.space.create('bench', {if_not_exists = true})
+    s:create_index('primary', {type = 'tree', parts = {1, 'unsigned'}, if_not_exists = true})
+
+    box.schema.user.create('test', {password = 'test', if_not_exists = true})
+    box.schema.user.grant('test', 'read,write,execute', 'universe', nil, {if_not_exists = true})
+end)
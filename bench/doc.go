@@ -0,0 +1,12 @@
+// Package bench contains reproducible benchmarks of the client: ping,
+// select of a hot key, batch insert and typed decode. Benchmarks report
+// allocations and latency percentiles (p50-ns, p99-ns), so results of two
+// revisions could be compared with benchstat:
+//
+//	go test -run=^$ -bench=. -count=10 ./bench > old.txt
+//	# apply changes
+//	go test -run=^$ -bench=. -count=10 ./bench > new.txt
+//	benchstat old.txt new.txt
+//
+// Benchmarks expect Tarantool started with bench/config.lua on port 3013.
+package bench
@@ -0,0 +1,120 @@
+// Package bitset provides flags for BITSET indexes and helpers for
+// selects with BITS_ALL_SET, BITS_ANY_SET and BITS_ALL_NOT_SET iterators.
+//
+// Flags of application are declared as constants of type Flags:
+//
+//	const (
+//		Active bitset.Flags = 1 << iota
+//		Admin
+//		Banned
+//	)
+//
+//	idx := bitset.New(conn, "users", "flags")
+//	var admins []User
+//	err := idx.AllSet(Active|Admin, 0, 100, &admins)
+package bitset
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// Flags is a set of bits stored in unsigned field indexed by BITSET
+// index. It is encoded as unsigned integer.
+type Flags uint64
+
+// Bit returns flags with only bit n set.
+func Bit(n uint) Flags {
+	return 1 << n
+}
+
+// Has reports whether all bits of flags are set.
+func (f Flags) Has(flags Flags) bool {
+	return f&flags == flags
+}
+
+// Any reports whether some bit of flags is set.
+func (f Flags) Any(flags Flags) bool {
+	return f&flags != 0
+}
+
+// Set returns f with bits of flags set.
+func (f Flags) Set(flags Flags) Flags {
+	return f | flags
+}
+
+// Clear returns f with bits of flags cleared.
+func (f Flags) Clear(flags Flags) Flags {
+	return f &^ flags
+}
+
+// Key returns key of BITSET index.
+func (f Flags) Key() []interface{} {
+	return []interface{}{uint64(f)}
+}
+
+// Format returns names of set bits joined by "|", names are indexed by
+// bit numbers. Bits without names are formatted as numbers.
+func (f Flags) Format(names []string) string {
+	var parts []string
+	for n := uint(0); n < 64; n++ {
+		if !f.Has(Bit(n)) {
+			continue
+		}
+		if int(n) < len(names) && names[n] != "" {
+			parts = append(parts, names[n])
+		} else {
+			parts = append(parts, fmt.Sprintf("bit%d", n))
+		}
+	}
+	if len(parts) == 0 {
+		return "0"
+	}
+	return strings.Join(parts, "|")
+}
+
+// Index is a handle of BITSET index.
+type Index struct {
+	conn  tarantool.Connector
+	space interface{}
+	index interface{}
+}
+
+// New creates handle of BITSET index of space. Space and index are
+// numbers or names as in tarantool.Connector.Select.
+func New(conn tarantool.Connector, space, index interface{}) *Index {
+	return &Index{conn: conn, space: space, index: index}
+}
+
+// Select selects tuples with iterator and flags into result.
+func (idx *Index) Select(iterator uint32, flags Flags, offset, limit uint32, result interface{}) error {
+	switch iterator {
+	case tarantool.IterEq, tarantool.IterAll, tarantool.IterBitsAllSet,
+		tarantool.IterBitsAnySet, tarantool.IterBitsAllNotSet:
+	default:
+		return fmt.Errorf("iterator %s is not supported by BITSET index", tarantool.IteratorName(iterator))
+	}
+	return idx.conn.SelectTyped(idx.space, idx.index, offset, limit, iterator, flags.Key(), result)
+}
+
+// Equal selects tuples with exactly flags set.
+func (idx *Index) Equal(flags Flags, offset, limit uint32, result interface{}) error {
+	return idx.Select(tarantool.IterEq, flags, offset, limit, result)
+}
+
+// AllSet selects tuples with all bits of flags set.
+func (idx *Index) AllSet(flags Flags, offset, limit uint32, result interface{}) error {
+	return idx.Select(tarantool.IterBitsAllSet, flags, offset, limit, result)
+}
+
+// AnySet selects tuples with some bit of flags set.
+func (idx *Index) AnySet(flags Flags, offset, limit uint32, result interface{}) error {
+	return idx.Select(tarantool.IterBitsAnySet, flags, offset, limit, result)
+}
+
+// AllNotSet selects tuples with no bit of flags set.
+func (idx *Index) AllNotSet(flags Flags, offset, limit uint32, result interface{}) error {
+	return idx.Select(tarantool.IterBitsAllNotSet, flags, offset, limit, result)
+}
@@ -0,0 +1,119 @@
+package bitset_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/bitset"
+)
+
+var server = "127.0.0.1:3013"
+var opts = Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+const spaceName = "test_bitset"
+
+const initLua = `
+local space = ...
+local s = box.schema.space.create(space, {if_not_exists = true})
+s:create_index('primary', {parts = {1, 'unsigned'}, if_not_exists = true})
+s:create_index('flags', {type = 'bitset', unique = false, parts = {2, 'unsigned'}, if_not_exists = true})
+s:truncate()
+`
+
+const (
+	Active bitset.Flags = 1 << iota
+	Admin
+	Banned
+)
+
+var flagNames = []string{"active", "admin", "banned"}
+
+type user struct {
+	Id    uint
+	Flags bitset.Flags
+}
+
+func TestFlags(t *testing.T) {
+	f := Active.Set(Banned)
+	if !f.Has(Active) || f.Has(Active|Admin) || !f.Any(Active|Admin) {
+		t.Errorf("Unexpected flags %d", f)
+	}
+	if f = f.Clear(Banned); f != Active {
+		t.Errorf("Unexpected flags %d", f)
+	}
+	if s := (Admin | bitset.Bit(5)).Format(flagNames); s != "admin|bit5" {
+		t.Errorf("Unexpected formatted flags %s", s)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	if _, err = conn.Eval(initLua, []interface{}{spaceName}); err != nil {
+		t.Errorf("Failed to create space: %s", err.Error())
+		return
+	}
+	users := []user{{1, Active}, {2, Active | Admin}, {3, Banned}, {4, Admin | Banned}}
+	for _, u := range users {
+		if _, err = conn.Insert(spaceName, []interface{}{u.Id, u.Flags}); err != nil {
+			t.Errorf("Failed to insert: %s", err.Error())
+			return
+		}
+	}
+	idx := bitset.New(conn, spaceName, "flags")
+
+	checks := []struct {
+		iterator uint32
+		flags    bitset.Flags
+		count    int
+	}{
+		{IterEq, Active | Admin, 1},
+		{IterBitsAllSet, Admin, 2},
+		{IterBitsAnySet, Active | Banned, 4},
+		{IterBitsAllNotSet, Banned, 2},
+	}
+	for _, check := range checks {
+		var result []user
+		if err = idx.Select(check.iterator, check.flags, 0, 10, &result); err != nil {
+			t.Errorf("Failed to select: %s", err.Error())
+			return
+		}
+		if len(result) != check.count {
+			t.Errorf("Unexpected result of %s %s: %v", IteratorName(check.iterator),
+				check.flags.Format(flagNames), result)
+		}
+	}
+	if err = idx.Select(IterGt, Admin, 0, 10, &[]user{}); err == nil {
+		t.Errorf("GT iterator is valid for BITSET index")
+	}
+}
+
+func ExampleIndex_AllSet() {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		fmt.Printf("error in connect is %v", err)
+		return
+	}
+	defer conn.Close()
+
+	var admins []user
+	idx := bitset.New(conn, spaceName, "flags")
+	if err = idx.AllSet(Active|Admin, 0, 100, &admins); err != nil {
+		fmt.Printf("error in select is %v", err)
+		return
+	}
+	for _, u := range admins {
+		fmt.Println(u.Id, u.Flags.Format(flagNames))
+	}
+}
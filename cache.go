@@ -0,0 +1,321 @@
+package tarantool
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// CacheOpts is a way to configure Cache.
+type CacheOpts struct {
+	// TTL is a time cached response is valid for.
+	// By default, responses are valid until invalidated.
+	TTL time.Duration
+	// MaxEntries is a maximum number of cached responses. When it is
+	// reached, the least recently used response is evicted.
+	// By default, number of responses is not limited.
+	MaxEntries int
+}
+
+// Cache is a client-side cache of Select and Call responses over
+// Connection. Responses are keyed by encoded request.
+//
+// Cached selects of a space are invalidated by writes to the same space
+// performed through Cache. Use Invalidate* methods to invalidate responses
+// on external events (e.g. writes by other clients or server-side
+// notifications).
+//
+// Only successful responses are cached. Each caller gets its own Future
+// with a copy of the cached response.
+type Cache struct {
+	conn    *Connection
+	opts    CacheOpts
+	mutex   sync.Mutex
+	gen     uint64
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type cacheEntry struct {
+	key         string
+	requestCode int32
+	spaceNo     uint32
+	space       string
+	function    string
+	expire      time.Time
+	body        []byte
+}
+
+// NewCache creates cache of responses over conn.
+func NewCache(conn *Connection, opts CacheOpts) *Cache {
+	return &Cache{
+		conn:    conn,
+		opts:    opts,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Len returns number of cached responses.
+func (c *Cache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.lru.Len()
+}
+
+// InvalidateAll removes all cached responses.
+func (c *Cache) InvalidateAll() {
+	c.invalidate(func(*cacheEntry) bool { return true })
+}
+
+// InvalidateSpace removes cached selects of space.
+func (c *Cache) InvalidateSpace(space interface{}) error {
+	ref, err := c.conn.resolveRef(space, nil)
+	if err != nil {
+		return err
+	}
+	c.invalidateSpace(ref)
+	return nil
+}
+
+// InvalidateCall removes cached results of function calls.
+func (c *Cache) InvalidateCall(functionName string) {
+	c.invalidate(func(e *cacheEntry) bool { return e.function == functionName })
+}
+
+func (c *Cache) invalidateSpace(ref spaceRef) {
+	c.invalidate(func(e *cacheEntry) bool {
+		return e.requestCode == SelectRequest && e.spaceNo == ref.spaceNo && e.space == ref.space
+	})
+}
+
+func (c *Cache) invalidate(match func(*cacheEntry) bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	// responses for requests in flight could be stale
+	c.gen++
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		if e := el.Value.(*cacheEntry); match(e) {
+			c.lru.Remove(el)
+			delete(c.entries, e.key)
+		}
+		el = next
+	}
+}
+
+// Select performs select to box space or returns cached response.
+//
+// It is equal to c.SelectAsync(...).Get()
+func (c *Cache) Select(space, index interface{}, offset, limit, iterator uint32, key interface{}) (resp *Response, err error) {
+	return c.SelectAsync(space, index, offset, limit, iterator, key).Get()
+}
+
+// SelectTyped performs select to box space or uses cached response and
+// fills typed result.
+//
+// It is equal to c.SelectAsync(space, index, offset, limit, iterator, key).GetTyped(&result)
+func (c *Cache) SelectTyped(space, index interface{}, offset, limit, iterator uint32, key interface{}, result interface{}) (err error) {
+	return c.SelectAsync(space, index, offset, limit, iterator, key).GetTyped(result)
+}
+
+// GetTyped performs select (with limit = 1 and offset = 0) to box space
+// or uses cached response and fills typed result.
+func (c *Cache) GetTyped(space, index interface{}, key interface{}, result interface{}) (err error) {
+	s := single{res: result}
+	return c.SelectAsync(space, index, 0, 1, IterEq, key).GetTyped(&s)
+}
+
+// Call calls registered tarantool function or returns cached response.
+//
+// It is equal to c.CallAsync(functionName, args).Get().
+func (c *Cache) Call(functionName string, args interface{}) (resp *Response, err error) {
+	return c.CallAsync(functionName, args).Get()
+}
+
+// CallTyped calls registered function or uses cached response.
+//
+// It is equal to c.CallAsync(functionName, args).GetTyped(&result).
+func (c *Cache) CallTyped(functionName string, args interface{}, result interface{}) (err error) {
+	return c.CallAsync(functionName, args).GetTyped(result)
+}
+
+// Call17 calls registered tarantool function or returns cached response.
+//
+// It is equal to c.Call17Async(functionName, args).Get().
+func (c *Cache) Call17(functionName string, args interface{}) (resp *Response, err error) {
+	return c.Call17Async(functionName, args).Get()
+}
+
+// Call17Typed calls registered function or uses cached response.
+//
+// It is equal to c.Call17Async(functionName, args).GetTyped(&result).
+func (c *Cache) Call17Typed(functionName string, args interface{}, result interface{}) (err error) {
+	return c.Call17Async(functionName, args).GetTyped(result)
+}
+
+// SelectAsync returns Future with cached response or sends select request
+// to tarantool.
+func (c *Cache) SelectAsync(space, index interface{}, offset, limit, iterator uint32, key interface{}) *Future {
+	ref, err := c.conn.selectRef(space, index, iterator)
+	if err != nil {
+		return c.conn.newFuture(SelectRequest, ref.spaceNo).fail(c.conn, err)
+	}
+	return c.do(&cacheEntry{requestCode: SelectRequest, spaceNo: ref.spaceNo, space: ref.space},
+		selectRefBody(ref, offset, limit, iterator, key))
+}
+
+// CallAsync returns Future with cached response or sends a call to
+// registered tarantool function.
+func (c *Cache) CallAsync(functionName string, args interface{}) *Future {
+	return c.do(&cacheEntry{requestCode: CallRequest, function: functionName},
+		callBody(functionName, args))
+}
+
+// Call17Async returns Future with cached response or sends a call to
+// registered tarantool function.
+func (c *Cache) Call17Async(functionName string, args interface{}) *Future {
+	requestCode, body := c.conn.call17Body(functionName, args)
+	return c.do(&cacheEntry{requestCode: requestCode, function: functionName}, body)
+}
+
+// Insert performs insertion to box space and invalidates cached selects
+// of the space.
+func (c *Cache) Insert(space interface{}, tuple interface{}) (resp *Response, err error) {
+	return c.InsertAsync(space, tuple).Get()
+}
+
+// Replace performs "insert or replace" action to box space and invalidates
+// cached selects of the space.
+func (c *Cache) Replace(space interface{}, tuple interface{}) (resp *Response, err error) {
+	return c.ReplaceAsync(space, tuple).Get()
+}
+
+// Delete performs deletion of a tuple by key and invalidates cached
+// selects of the space.
+func (c *Cache) Delete(space, index interface{}, key interface{}) (resp *Response, err error) {
+	return c.DeleteAsync(space, index, key).Get()
+}
+
+// Update performs update of a tuple by key and invalidates cached selects
+// of the space.
+func (c *Cache) Update(space, index interface{}, key, ops interface{}) (resp *Response, err error) {
+	return c.UpdateAsync(space, index, key, ops).Get()
+}
+
+// Upsert performs "update or insert" action of a tuple by key and
+// invalidates cached selects of the space.
+func (c *Cache) Upsert(space interface{}, tuple, ops interface{}) (resp *Response, err error) {
+	return c.UpsertAsync(space, tuple, ops).Get()
+}
+
+// InsertAsync sends insert action to tarantool and returns Future.
+func (c *Cache) InsertAsync(space interface{}, tuple interface{}) *Future {
+	c.invalidateWrite(space)
+	return c.conn.InsertAsync(space, tuple)
+}
+
+// ReplaceAsync sends "insert or replace" action to tarantool and returns Future.
+func (c *Cache) ReplaceAsync(space interface{}, tuple interface{}) *Future {
+	c.invalidateWrite(space)
+	return c.conn.ReplaceAsync(space, tuple)
+}
+
+// DeleteAsync sends deletion action to tarantool and returns Future.
+func (c *Cache) DeleteAsync(space, index interface{}, key interface{}) *Future {
+	c.invalidateWrite(space)
+	return c.conn.DeleteAsync(space, index, key)
+}
+
+// UpdateAsync sends update of a tuple by key and returns Future.
+func (c *Cache) UpdateAsync(space, index interface{}, key, ops interface{}) *Future {
+	c.invalidateWrite(space)
+	return c.conn.UpdateAsync(space, index, key, ops)
+}
+
+// UpsertAsync sends "update or insert" action to tarantool and returns Future.
+func (c *Cache) UpsertAsync(space interface{}, tuple interface{}, ops interface{}) *Future {
+	c.invalidateWrite(space)
+	return c.conn.UpsertAsync(space, tuple, ops)
+}
+
+func (c *Cache) invalidateWrite(space interface{}) {
+	// resolve error is reported by the request itself
+	if ref, err := c.conn.resolveRef(space, nil); err == nil {
+		c.invalidateSpace(ref)
+	}
+}
+
+func (c *Cache) do(entry *cacheEntry, body func(*msgpack.Encoder) error) *Future {
+	packet, err := encodeBody(body)
+	if err != nil {
+		return c.conn.newFuture(entry.requestCode, entry.spaceNo).fail(c.conn, err)
+	}
+	entry.key = string(append([]byte{byte(entry.requestCode)}, packet...))
+
+	c.mutex.Lock()
+	if el, ok := c.entries[entry.key]; ok {
+		cached := el.Value.(*cacheEntry)
+		if c.opts.TTL <= 0 || c.conn.opts.Clock.Now().Before(cached.expire) {
+			c.lru.MoveToFront(el)
+			c.mutex.Unlock()
+			return &Future{
+				requestCode: cached.requestCode,
+				spaceNo:     cached.spaceNo,
+				resp:        &Response{Code: OkCode, buf: smallBuf{b: cached.body}},
+				ready:       closedChan,
+				conn:        c.conn,
+			}
+		}
+		c.lru.Remove(el)
+		delete(c.entries, entry.key)
+	}
+	gen := c.gen
+	c.mutex.Unlock()
+
+	shared := c.conn.newFutureFor(entry.requestCode, entry.spaceNo, entry.function)
+	shared.send(c.conn, rawBody(packet))
+	fut := &Future{
+		requestCode: entry.requestCode,
+		spaceNo:     entry.spaceNo,
+		ready:       make(chan struct{}),
+		conn:        c.conn,
+	}
+	go c.resolve(entry, gen, shared, fut)
+	return fut
+}
+
+func (c *Cache) resolve(entry *cacheEntry, gen uint64, shared, fut *Future) {
+	shared.wait()
+	if shared.err == nil && shared.resp.Code == OkCode {
+		entry.body = shared.resp.buf.Bytes()
+		c.store(entry, gen)
+	}
+	fut.requestId = shared.requestId
+	fut.err = shared.err
+	fut.resp = shared.resp
+	close(fut.ready)
+}
+
+func (c *Cache) store(entry *cacheEntry, gen uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if gen != c.gen {
+		// cache was invalidated while request was in flight
+		return
+	}
+	if c.opts.TTL > 0 {
+		entry.expire = c.conn.opts.Clock.Now().Add(c.opts.TTL)
+	}
+	if el, ok := c.entries[entry.key]; ok {
+		c.lru.Remove(el)
+	}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	for c.opts.MaxEntries > 0 && c.lru.Len() > c.opts.MaxEntries {
+		el := c.lru.Back()
+		c.lru.Remove(el)
+		delete(c.entries, el.Value.(*cacheEntry).key)
+	}
+}
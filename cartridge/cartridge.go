@@ -0,0 +1,246 @@
+// Package cartridge wraps admin functions of Tarantool Cartridge: cluster
+// topology, roles of replicasets and failover settings. It is intended
+// for automation against existing Cartridge clusters:
+//
+//	admin := cartridge.New(conn)
+//	topology, err := admin.Topology()
+//	err = admin.SetRoles(topology.Replicasets[0].UUID, []string{"vshard-storage"})
+//
+// Functions are called with Eval on any instance of the cluster, so user
+// needs 'execute universe' privilege. Changes of topology are applied by
+// Cartridge to the whole cluster.
+package cartridge
+
+import (
+	"fmt"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// Lua code returns plain tables, since Cartridge objects reference each
+// other and can't be encoded.
+const topologyLua = `
+local cartridge = require('cartridge')
+local servers, err = cartridge.admin_get_servers()
+if servers == nil then
+    error(tostring(err), 0)
+end
+local replicasets, err = cartridge.admin_get_replicasets()
+if replicasets == nil then
+    error(tostring(err), 0)
+end
+local res = {servers = {}, replicasets = {}}
+for _, s in ipairs(servers) do
+    table.insert(res.servers, {
+        uuid = s.uuid or '',
+        uri = s.uri,
+        alias = s.alias or '',
+        status = s.status or '',
+        message = s.message or '',
+        disabled = s.disabled or false,
+        replicaset_uuid = s.replicaset and s.replicaset.uuid or '',
+    })
+end
+for _, r in ipairs(replicasets) do
+    local uuids = {}
+    for _, s in ipairs(r.servers) do
+        table.insert(uuids, s.uuid)
+    end
+    table.insert(res.replicasets, {
+        uuid = r.uuid,
+        alias = r.alias or '',
+        roles = r.roles,
+        status = r.status or '',
+        all_rw = r.all_rw or false,
+        weight = r.weight or 0,
+        vshard_group = r.vshard_group or '',
+        master = r.master and r.master.uuid or '',
+        active_master = r.active_master and r.active_master.uuid or '',
+        servers = uuids,
+    })
+end
+return res
+`
+
+const getFailoverLua = `
+local params = require('cartridge').failover_get_params()
+return {
+    mode = params.mode,
+    state_provider = params.state_provider or '',
+    failover_timeout = params.failover_timeout or 0,
+    fencing_enabled = params.fencing_enabled or false,
+    fencing_timeout = params.fencing_timeout or 0,
+    fencing_pause = params.fencing_pause or 0,
+}
+`
+
+const setFailoverLua = `
+local ok, err = require('cartridge').failover_set_params(...)
+if not ok then
+    error(tostring(err), 0)
+end
+`
+
+const editTopologyLua = `
+local topology, err = require('cartridge').admin_edit_topology(...)
+if topology == nil then
+    error(tostring(err), 0)
+end
+`
+
+// Server is an instance of cluster.
+type Server struct {
+	UUID  string `msgpack:"uuid"`
+	URI   string `msgpack:"uri"`
+	Alias string `msgpack:"alias"`
+	// Status is "healthy", "unconfigured" or an error state.
+	Status string `msgpack:"status"`
+	// Message describes status of unhealthy server.
+	Message  string `msgpack:"message"`
+	Disabled bool   `msgpack:"disabled"`
+	// ReplicasetUUID is empty for unconfigured server.
+	ReplicasetUUID string `msgpack:"replicaset_uuid"`
+}
+
+// Replicaset is a replicaset of cluster.
+type Replicaset struct {
+	UUID   string   `msgpack:"uuid"`
+	Alias  string   `msgpack:"alias"`
+	Roles  []string `msgpack:"roles"`
+	Status string   `msgpack:"status"`
+	AllRW  bool     `msgpack:"all_rw"`
+	// Weight is a vshard weight of storage replicaset.
+	Weight      float64 `msgpack:"weight"`
+	VshardGroup string  `msgpack:"vshard_group"`
+	// Master is a UUID of configured leader.
+	Master string `msgpack:"master"`
+	// ActiveMaster is a UUID of leader elected by failover.
+	ActiveMaster string `msgpack:"active_master"`
+	// Servers are UUIDs of servers in order of failover priority.
+	Servers []string `msgpack:"servers"`
+}
+
+// HasRole reports whether role is enabled on replicaset.
+func (r *Replicaset) HasRole(role string) bool {
+	for _, name := range r.Roles {
+		if name == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Topology is a topology of cluster.
+type Topology struct {
+	Servers     []Server     `msgpack:"servers"`
+	Replicasets []Replicaset `msgpack:"replicasets"`
+}
+
+// Replicaset returns replicaset with uuid or nil.
+func (t *Topology) Replicaset(uuid string) *Replicaset {
+	for i := range t.Replicasets {
+		if t.Replicasets[i].UUID == uuid {
+			return &t.Replicasets[i]
+		}
+	}
+	return nil
+}
+
+// ReplicasetsWithRole returns replicasets with role enabled.
+func (t *Topology) ReplicasetsWithRole(role string) []Replicaset {
+	var res []Replicaset
+	for _, r := range t.Replicasets {
+		if r.HasRole(role) {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// FailoverParams are failover settings of cluster.
+type FailoverParams struct {
+	// Mode is "disabled", "eventual", "stateful" or "raft".
+	Mode string `msgpack:"mode"`
+	// StateProvider is "tarantool" or "etcd2" for stateful mode.
+	StateProvider   string  `msgpack:"state_provider"`
+	FailoverTimeout float64 `msgpack:"failover_timeout"`
+	FencingEnabled  bool    `msgpack:"fencing_enabled"`
+	FencingTimeout  float64 `msgpack:"fencing_timeout"`
+	FencingPause    float64 `msgpack:"fencing_pause"`
+}
+
+// Client calls Cartridge admin functions.
+type Client struct {
+	conn tarantool.Connector
+}
+
+// New creates Client over connection to any instance of cluster.
+func New(conn tarantool.Connector) *Client {
+	return &Client{conn: conn}
+}
+
+// Topology returns servers and replicasets of cluster.
+func (c *Client) Topology() (*Topology, error) {
+	var res []Topology
+	if err := c.conn.EvalTyped(topologyLua, []interface{}{}, &res); err != nil {
+		return nil, err
+	}
+	if len(res) != 1 {
+		return nil, fmt.Errorf("unexpected number of results %d", len(res))
+	}
+	return &res[0], nil
+}
+
+// SetRoles sets roles of replicaset, roles not in the list are disabled.
+func (c *Client) SetRoles(replicasetUUID string, roles []string) error {
+	if roles == nil {
+		roles = []string{}
+	}
+	return c.EditTopology(map[string]interface{}{
+		"replicasets": []interface{}{
+			map[string]interface{}{"uuid": replicasetUUID, "roles": roles},
+		},
+	})
+}
+
+// EditTopology calls admin_edit_topology with arguments as in Lua API,
+// e.g. {"servers": [{"uuid": ..., "disabled": true}]}.
+func (c *Client) EditTopology(args map[string]interface{}) error {
+	_, err := c.conn.Eval(editTopologyLua, []interface{}{args})
+	return err
+}
+
+// FailoverParams returns failover settings.
+func (c *Client) FailoverParams() (*FailoverParams, error) {
+	var res []FailoverParams
+	if err := c.conn.EvalTyped(getFailoverLua, []interface{}{}, &res); err != nil {
+		return nil, err
+	}
+	if len(res) != 1 {
+		return nil, fmt.Errorf("unexpected number of results %d", len(res))
+	}
+	return &res[0], nil
+}
+
+// SetFailoverParams changes failover mode and timeouts. Zero timeouts and
+// empty state provider are left unchanged.
+func (c *Client) SetFailoverParams(params FailoverParams) error {
+	opts := map[string]interface{}{
+		"mode":            params.Mode,
+		"fencing_enabled": params.FencingEnabled,
+	}
+	if params.StateProvider != "" {
+		opts["state_provider"] = params.StateProvider
+	}
+	if params.FailoverTimeout > 0 {
+		opts["failover_timeout"] = params.FailoverTimeout
+	}
+	if params.FencingTimeout > 0 {
+		opts["fencing_timeout"] = params.FencingTimeout
+	}
+	if params.FencingPause > 0 {
+		opts["fencing_pause"] = params.FencingPause
+	}
+	_, err := c.conn.Eval(setFailoverLua, []interface{}{opts})
+	return err
+}
@@ -0,0 +1,67 @@
+package cartridge_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/cartridge"
+)
+
+var server = "127.0.0.1:3013"
+var opts = Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+func TestTopologyHelpers(t *testing.T) {
+	topology := cartridge.Topology{
+		Replicasets: []cartridge.Replicaset{
+			{UUID: "r1", Roles: []string{"vshard-router"}},
+			{UUID: "r2", Roles: []string{"vshard-storage", "metrics"}},
+		},
+	}
+	if r := topology.Replicaset("r2"); r == nil || !r.HasRole("metrics") {
+		t.Errorf("Unexpected replicaset: %v", r)
+	}
+	if r := topology.Replicaset("r3"); r != nil {
+		t.Errorf("Unexpected replicaset: %v", r)
+	}
+	if rs := topology.ReplicasetsWithRole("vshard-storage"); len(rs) != 1 || rs[0].UUID != "r2" {
+		t.Errorf("Unexpected replicasets with role: %v", rs)
+	}
+}
+
+func TestClient(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	admin := cartridge.New(conn)
+	topology, err := admin.Topology()
+	if err != nil {
+		if strings.Contains(err.Error(), "module 'cartridge' not found") {
+			t.Skip("instance is not a Cartridge cluster member")
+		}
+		t.Errorf("Failed to get topology: %s", err.Error())
+		return
+	}
+	for _, s := range topology.Servers {
+		if s.URI == "" {
+			t.Errorf("Server without URI: %v", s)
+		}
+	}
+	params, err := admin.FailoverParams()
+	if err != nil {
+		t.Errorf("Failed to get failover params: %s", err.Error())
+		return
+	}
+	if params.Mode == "" {
+		t.Errorf("Unexpected failover params: %v", params)
+	}
+}
@@ -0,0 +1,58 @@
+package tarantool
+
+import (
+	"time"
+)
+
+// Clock is a source of time for Connection: request timeouts, pings,
+// reconnect pauses and other timers. It could be replaced with a fake
+// clock in tests to check timeouts deterministically without real sleeps.
+//
+// Note: socket deadlines (see Opts.Timeout) always use real time.
+type Clock interface {
+	// Now returns current time.
+	Now() time.Time
+	// NewTimer creates timer which fires once after duration d.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer created by Clock, it behaves like time.Timer.
+type Timer interface {
+	// C returns channel the time is delivered on.
+	C() <-chan time.Time
+	// Stop prevents timer from firing.
+	Stop() bool
+	// Reset changes timer to expire after duration d.
+	Reset(d time.Duration) bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.t.C
+}
+
+func (t realTimer) Stop() bool {
+	return t.t.Stop()
+}
+
+func (t realTimer) Reset(d time.Duration) bool {
+	return t.t.Reset(d)
+}
+
+// sinceEpoch returns current time of connection clock as offset from epoch.
+func (conn *Connection) sinceEpoch() time.Duration {
+	return conn.opts.Clock.Now().Sub(epoch)
+}
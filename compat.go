@@ -0,0 +1,101 @@
+package tarantool
+
+import (
+	"fmt"
+	"regexp"
+	"sync/atomic"
+
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// version is a server version packed as major<<16 | minor<<8 | patch,
+// so versions could be compared as integers.
+type version uint32
+
+func makeVersion(major, minor, patch uint32) version {
+	return version(major<<16 | minor<<8 | patch)
+}
+
+// parseGreetingVersion extracts version from greeting line like
+// "Tarantool 1.6.9 (Binary) 7a4b...". Zero is returned if version
+// could not be parsed.
+func parseGreetingVersion(greeting string) version {
+	var major, minor, patch uint32
+	if n, _ := fmt.Sscanf(greeting, "Tarantool %d.%d.%d", &major, &minor, &patch); n < 2 {
+		return 0
+	}
+	return makeVersion(major, minor, patch)
+}
+
+// call17Version is the first version which supports IPROTO_CALL.
+var call17Version = makeVersion(1, 7, 0)
+
+var luaFunctionName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*([.:][A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// call17Body returns request code and body for Call17 compatible with
+// server version. Tarantool before 1.7 has no IPROTO_CALL, so it is
+// emulated with Eval which does not convert result either.
+func (conn *Connection) call17Body(functionName string, args interface{}) (int32, func(*msgpack.Encoder) error) {
+	v := conn.serverVersion()
+	if v == 0 || v >= call17Version || !luaFunctionName.MatchString(functionName) {
+		return Call17Request, callBody(functionName, args)
+	}
+	return EvalRequest, evalBody("return "+functionName+"(...)", args)
+}
+
+func (conn *Connection) serverVersion() version {
+	return version(atomic.LoadUint32(&conn.version))
+}
+
+// spaceRef is a space and index of request given by numbers, or by names
+// if they are resolved by server.
+type spaceRef struct {
+	spaceNo, indexNo uint32
+	space, index     string
+}
+
+// resolveRef resolves space and index like resolveSpaceIndex. Names which
+// can't be resolved without loaded schema are passed as is to servers
+// with SpaceAndIndexNamesFeature (Tarantool 3.0+), so requests by names
+// work with SkipSchema there, while older servers require loaded schema.
+func (conn *Connection) resolveRef(space, index interface{}) (ref spaceRef, err error) {
+	ref.spaceNo, ref.indexNo, err = conn.resolveSpaceIndex(space, index)
+	if nerr, ok := err.(NameResolutionError); !ok || !nerr.SchemaNotLoaded ||
+		!conn.ProtocolInfo().Has(SpaceAndIndexNamesFeature) {
+		return ref, err
+	}
+	ref = spaceRef{}
+	if name, ok := space.(string); ok {
+		ref.space = conn.aliases.space(name)
+	} else if ref.spaceNo, _, err = conn.resolveSpaceIndex(space, nil); err != nil {
+		return ref, err
+	}
+	if name, ok := index.(string); ok {
+		ref.index = conn.aliases.index(ref.space, name)
+	} else if index != nil {
+		if _, ref.indexNo, err = conn.resolveSpaceIndex(ref.spaceNo, index); err != nil {
+			return ref, err
+		}
+	}
+	return ref, nil
+}
+
+func (ref spaceRef) fillSpace(enc *msgpack.Encoder) {
+	if ref.space != "" {
+		enc.EncodeUint64(KeySpaceName)
+		enc.EncodeString(ref.space)
+		return
+	}
+	enc.EncodeUint64(KeySpaceNo)
+	enc.EncodeUint64(uint64(ref.spaceNo))
+}
+
+func (ref spaceRef) fillIndex(enc *msgpack.Encoder) {
+	if ref.index != "" {
+		enc.EncodeUint64(KeyIndexName)
+		enc.EncodeString(ref.index)
+		return
+	}
+	enc.EncodeUint64(KeyIndexNo)
+	enc.EncodeUint64(uint64(ref.indexNo))
+}
@@ -0,0 +1,263 @@
+// Package conditions builds filter conditions once and serializes them
+// either to conditions of crud module or to SQL WHERE clause:
+//
+//	cond := conditions.And(
+//		conditions.Eq("status", "active"),
+//		conditions.Between("age", 18, 65),
+//	)
+//	if err := cond.Validate(space); err != nil {
+//		return err
+//	}
+//	crudConds, err := cond.Crud()
+//	where, args := cond.SQL()
+//
+// Crud supports only conjunction of comparisons, so Or, Ne and In with
+// several values can't be serialized to crud conditions.
+package conditions
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// Cond is a condition or a combination of conditions.
+type Cond struct {
+	op       string
+	field    string
+	values   []interface{}
+	children []Cond
+}
+
+const (
+	opAnd     = "AND"
+	opOr      = "OR"
+	opIn      = "IN"
+	opBetween = "BETWEEN"
+)
+
+// Eq is a condition field == value.
+func Eq(field string, value interface{}) Cond {
+	return Cond{op: "==", field: field, values: []interface{}{value}}
+}
+
+// Ne is a condition field != value.
+func Ne(field string, value interface{}) Cond {
+	return Cond{op: "!=", field: field, values: []interface{}{value}}
+}
+
+// Lt is a condition field < value.
+func Lt(field string, value interface{}) Cond {
+	return Cond{op: "<", field: field, values: []interface{}{value}}
+}
+
+// Le is a condition field <= value.
+func Le(field string, value interface{}) Cond {
+	return Cond{op: "<=", field: field, values: []interface{}{value}}
+}
+
+// Gt is a condition field > value.
+func Gt(field string, value interface{}) Cond {
+	return Cond{op: ">", field: field, values: []interface{}{value}}
+}
+
+// Ge is a condition field >= value.
+func Ge(field string, value interface{}) Cond {
+	return Cond{op: ">=", field: field, values: []interface{}{value}}
+}
+
+// In is a condition that field is equal to one of values.
+func In(field string, values ...interface{}) Cond {
+	return Cond{op: opIn, field: field, values: values}
+}
+
+// Between is a condition low <= field <= high.
+func Between(field string, low, high interface{}) Cond {
+	return Cond{op: opBetween, field: field, values: []interface{}{low, high}}
+}
+
+// And is a conjunction of conditions, it is true if conds are empty.
+func And(conds ...Cond) Cond {
+	return Cond{op: opAnd, children: conds}
+}
+
+// Or is a disjunction of conditions, it is false if conds are empty.
+func Or(conds ...Cond) Cond {
+	return Cond{op: opOr, children: conds}
+}
+
+func (c Cond) isLogical() bool {
+	return c.op == opAnd || c.op == opOr
+}
+
+// String returns condition in SQL syntax with values inlined.
+func (c Cond) String() string {
+	where, args := c.SQL()
+	for _, arg := range args {
+		where = strings.Replace(where, "?", fmt.Sprintf("%#v", arg), 1)
+	}
+	return where
+}
+
+// Validate checks that fields of conditions are present in format of
+// space and values match field types.
+func (c Cond) Validate(space *tarantool.Space) error {
+	if c.isLogical() {
+		for _, child := range c.children {
+			if err := child.Validate(space); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	field, ok := space.Fields[c.field]
+	if !ok {
+		return fmt.Errorf("there is no field %s in space %s", c.field, space.Name)
+	}
+	if c.op == opIn && len(c.values) == 0 {
+		return fmt.Errorf("condition IN on field %s has no values", c.field)
+	}
+	for _, v := range c.values {
+		if !matchesType(v, field.Type) {
+			return fmt.Errorf("value %#v of condition on field %s doesn't match field type %s", v, c.field, field.Type)
+		}
+	}
+	return nil
+}
+
+// matchesType reports whether value can be compared with field of type.
+// Values of unknown types are not checked.
+func matchesType(v interface{}, typ string) bool {
+	rv := reflect.ValueOf(v)
+	switch strings.ToLower(typ) {
+	case "unsigned", "uint":
+		switch rv.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return rv.Int() >= 0
+		}
+		return false
+	case "integer", "int":
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		}
+		return false
+	case "number", "double":
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			return true
+		}
+		return false
+	case "string", "str":
+		return rv.Kind() == reflect.String
+	case "boolean":
+		return rv.Kind() == reflect.Bool
+	}
+	return true
+}
+
+// Crud returns conditions of crud module, e.g. {{"==", "status",
+// "active"}, {">=", "age", 18}}. It returns error if condition is not
+// a conjunction of comparisons.
+func (c Cond) Crud() ([]interface{}, error) {
+	conds := []interface{}{}
+	if err := c.crud(&conds); err != nil {
+		return nil, err
+	}
+	return conds, nil
+}
+
+func (c Cond) crud(conds *[]interface{}) error {
+	switch c.op {
+	case opAnd:
+		for _, child := range c.children {
+			if err := child.crud(conds); err != nil {
+				return err
+			}
+		}
+	case opOr:
+		return fmt.Errorf("OR is not supported by crud")
+	case "!=":
+		return fmt.Errorf("condition != on field %s is not supported by crud", c.field)
+	case opIn:
+		if len(c.values) != 1 {
+			return fmt.Errorf("condition IN on field %s with %d values is not supported by crud", c.field, len(c.values))
+		}
+		*conds = append(*conds, []interface{}{"==", c.field, c.values[0]})
+	case opBetween:
+		*conds = append(*conds,
+			[]interface{}{">=", c.field, c.values[0]},
+			[]interface{}{"<=", c.field, c.values[1]})
+	default:
+		*conds = append(*conds, []interface{}{c.op, c.field, c.values[0]})
+	}
+	return nil
+}
+
+// SQL returns WHERE clause with ? placeholders and their arguments.
+// Field names are quoted.
+func (c Cond) SQL() (string, []interface{}) {
+	var sb strings.Builder
+	var args []interface{}
+	c.sql(&sb, &args)
+	return sb.String(), args
+}
+
+func (c Cond) sql(sb *strings.Builder, args *[]interface{}) {
+	switch c.op {
+	case opAnd, opOr:
+		if len(c.children) == 0 {
+			if c.op == opAnd {
+				sb.WriteString("TRUE")
+			} else {
+				sb.WriteString("FALSE")
+			}
+			return
+		}
+		for i, child := range c.children {
+			if i > 0 {
+				fmt.Fprintf(sb, " %s ", c.op)
+			}
+			if child.isLogical() && len(child.children) > 1 {
+				sb.WriteByte('(')
+				child.sql(sb, args)
+				sb.WriteByte(')')
+			} else {
+				child.sql(sb, args)
+			}
+		}
+		return
+	}
+	sb.WriteString(quote(c.field))
+	switch c.op {
+	case opIn:
+		sb.WriteString(" IN (")
+		for i := range c.values {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteByte('?')
+		}
+		sb.WriteByte(')')
+	case opBetween:
+		sb.WriteString(" BETWEEN ? AND ?")
+	case "==":
+		sb.WriteString(" = ?")
+	case "!=":
+		sb.WriteString(" <> ?")
+	default:
+		fmt.Fprintf(sb, " %s ?", c.op)
+	}
+	*args = append(*args, c.values...)
+}
+
+func quote(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
@@ -0,0 +1,88 @@
+package conditions_test
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/conditions"
+)
+
+var space = &Space{
+	Name: "users",
+	Fields: map[string]*Field{
+		"id":     {Id: 0, Name: "id", Type: "unsigned"},
+		"status": {Id: 1, Name: "status", Type: "string"},
+		"age":    {Id: 2, Name: "age", Type: "integer"},
+	},
+}
+
+func TestSQL(t *testing.T) {
+	cond := conditions.And(
+		conditions.Eq("status", "active"),
+		conditions.Or(conditions.Between("age", 18, 65), conditions.In("id", 1, 2)),
+		conditions.Ne("status", `x"y`),
+	)
+	where, args := cond.SQL()
+	expected := `"status" = ? AND ("age" BETWEEN ? AND ? OR "id" IN (?, ?)) AND "status" <> ?`
+	if where != expected {
+		t.Errorf("Unexpected WHERE clause: %s", where)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"active", 18, 65, 1, 2, `x"y`}) {
+		t.Errorf("Unexpected arguments: %v", args)
+	}
+	if where, _ = conditions.And().SQL(); where != "TRUE" {
+		t.Errorf("Unexpected WHERE clause of empty AND: %s", where)
+	}
+	if s := conditions.Gt("age", 18).String(); s != `"age" > 18` {
+		t.Errorf("Unexpected string: %s", s)
+	}
+}
+
+func TestCrud(t *testing.T) {
+	conds, err := conditions.And(
+		conditions.Eq("status", "active"),
+		conditions.Between("age", 18, 65),
+		conditions.In("id", 1),
+	).Crud()
+	if err != nil {
+		t.Errorf("Failed to serialize crud conditions: %s", err.Error())
+		return
+	}
+	expected := []interface{}{
+		[]interface{}{"==", "status", "active"},
+		[]interface{}{">=", "age", 18},
+		[]interface{}{"<=", "age", 65},
+		[]interface{}{"==", "id", 1},
+	}
+	if !reflect.DeepEqual(conds, expected) {
+		t.Errorf("Unexpected crud conditions: %v", conds)
+	}
+	unsupported := []conditions.Cond{
+		conditions.Or(conditions.Eq("id", 1)),
+		conditions.Ne("id", 1),
+		conditions.In("id", 1, 2),
+	}
+	for _, cond := range unsupported {
+		if _, err = cond.Crud(); err == nil {
+			t.Errorf("Condition %s is serialized to crud", cond)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := conditions.And(conditions.Eq("id", uint(1)), conditions.Gt("age", -1)).Validate(space); err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+	invalid := []conditions.Cond{
+		conditions.Eq("name", "bob"),
+		conditions.Eq("id", -1),
+		conditions.Or(conditions.Eq("status", 1)),
+		conditions.In("id"),
+	}
+	for _, cond := range invalid {
+		if err := cond.Validate(space); err == nil {
+			t.Errorf("Condition %s is valid", cond)
+		}
+	}
+}
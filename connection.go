@@ -3,6 +3,7 @@ package tarantool
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -17,12 +18,55 @@ import (
 )
 
 const requestsMap = 128
+
+// shutdownPollInterval is a period of checks for requests in flight by
+// CloseGraceful.
+const shutdownPollInterval = 10 * time.Millisecond
 const (
 	connDisconnected = 0
 	connConnected    = 1
 	connClosed       = 2
+	connShutdown     = 3
+	connInitializing = 4
+)
+
+// ConnState is a state of Connection reported by Connection.State().
+type ConnState uint32
+
+const (
+	// StateReconnecting means that connection is not established at the
+	// moment, requests are rejected with ErrConnectionNotReady.
+	StateReconnecting ConnState = connDisconnected
+	// StateConnected means that connection is established and requests are
+	// sent to Tarantool.
+	StateConnected ConnState = connConnected
+	// StateClosed means that connection is closed forever.
+	StateClosed ConnState = connClosed
+	// StateShutdownPending means that connection is being closed and waits
+	// for in-flight requests, new requests are rejected.
+	StateShutdownPending ConnState = connShutdown
+	// StateInitializing means that connection is established and
+	// Opts.OnConnect hook is running.
+	StateInitializing ConnState = connInitializing
 )
 
+// String implements Stringer interface
+func (s ConnState) String() string {
+	switch s {
+	case StateReconnecting:
+		return "reconnecting"
+	case StateConnected:
+		return "connected"
+	case StateClosed:
+		return "closed"
+	case StateShutdownPending:
+		return "shutdown pending"
+	case StateInitializing:
+		return "initializing"
+	}
+	return fmt.Sprintf("unknown state %d", uint32(s))
+}
+
 type ConnEventKind int
 type ConnLogKind int
 
@@ -44,13 +88,18 @@ const (
 	// LogUnexpectedResultId is logged when response with unknown id were received.
 	// Most probably it is due to request timeout.
 	LogUnexpectedResultId
+	// LogFutureLeak is logged when request is not answered for longer than
+	// Opts.LeakThreshold. It is logged once per request.
+	LogFutureLeak
 )
 
 // ConnEvent is sent throw Notify channel specified in Opts
 type ConnEvent struct {
 	Conn *Connection
 	Kind ConnEventKind
-	When time.Time
+	// State is a state of connection right after the event.
+	State ConnState
+	When  time.Time
 }
 
 var epoch = time.Now()
@@ -74,6 +123,9 @@ func (d defaultLogger) Report(event ConnLogKind, conn *Connection, v ...interfac
 	case LogUnexpectedResultId:
 		resp := v[0].(*Response)
 		log.Printf("tarantool: connection %s got unexpected resultId (%d) in response", conn.addr, resp.RequestId)
+	case LogFutureLeak:
+		req := v[0].(PendingRequest)
+		log.Printf("tarantool: connection %s request %d (code %d, space %d) is not answered for %s", conn.addr, req.RequestId, req.RequestCode, req.SpaceNo, req.Age)
 	default:
 		args := append([]interface{}{"tarantool: unexpected event ", event, conn}, v...)
 		log.Print(args...)
@@ -85,7 +137,7 @@ func (d defaultLogger) Report(event ConnLogKind, conn *Connection, v ...interfac
 // It is created and configured with Connect function, and could not be
 // reconfigured later.
 //
-// It is could be "Connected", "Disconnected", and "Closed".
+// It is could be "Connected", "Disconnected", and "Closed" (see State() method).
 //
 // When "Connected" it sends queries to Tarantool.
 //
@@ -97,6 +149,8 @@ func (d defaultLogger) Report(event ConnLogKind, conn *Connection, v ...interfac
 // or when Tarantool disconnected and Reconnect pause is not specified or
 // MaxReconnects is specified and MaxReconnect reconnect attempts already performed.
 //
+// Connection could be forced to reestablish socket with Connection.Reconnect().
+//
 // You may perform data manipulation operation by calling its methods:
 // Call*, Insert*, Replace*, Update*, Upsert*, Call*, Eval*.
 //
@@ -118,16 +172,41 @@ type Connection struct {
 	c     net.Conn
 	mutex sync.Mutex
 	// Schema contains schema loaded on connection.
-	Schema    *Schema
+	Schema *Schema
+	// aliases of spaces and indexes shared by loaded schemas
+	aliases   *nameAliases
 	requestId uint32
 	// Greeting contains first message sent by tarantool
 	Greeting *Greeting
+	// protocolInfo contains features reported by tarantool
+	protocolInfo ProtocolInfo
+	// version is a server version parsed from greeting
+	version uint32
+	// session describes server session, it is fetched on first
+	// SessionInfo call after connect
+	session *SessionInfo
+	// traffic dumps packets if Opts.TrafficDump is set
+	traffic *trafficDumper
+	// sizes tracks packet sizes if Opts.TrackSizes is set
+	sizes *sizeTracker
+	// requests tracks completed requests if Opts.TrackRequests is set
+	requests *requestTracker
+	// memory accounts buffered requests and responses if Opts.MaxMemory
+	// is set
+	memory *memoryLimiter
+	// tupleFormats caches formats of MP_TUPLE tuples of current session
+	tupleFormats tupleFormats
 
 	shard      []connShard
 	dirtyShard chan uint32
+	// initialized is closed when Opts.OnConnect of current socket is done,
+	// requests not sent by the hook wait for it
+	initialized chan struct{}
 
 	control chan struct{}
+	flush   chan struct{}
 	rlimit  chan struct{}
+	limiter *rateLimiter
 	opts    Opts
 	state   uint32
 	dec     *msgpack.Decoder
@@ -137,15 +216,22 @@ type Connection struct {
 var _ = Connector(&Connection{}) // check compatibility with connector interface
 
 type connShard struct {
-	rmut     sync.Mutex
-	requests [requestsMap]struct {
-		first *Future
-		last  **Future
-	}
-	bufmut sync.Mutex
-	buf    smallWBuf
-	enc    *msgpack.Encoder
-	_pad   [16]uint64
+	requests [requestsMap]futureBucket
+	bufmut   sync.Mutex
+	buf      smallWBuf
+	enc      *msgpack.Encoder
+	// reserved is a size of buf accounted by Opts.MaxMemory
+	reserved int
+	_pad     [16]uint64
+}
+
+// futureBucket is a list of futures waiting for response ordered by request
+// id. Every bucket has its own mutex, so goroutines sending requests and
+// reader fetching futures rarely wait for each other.
+type futureBucket struct {
+	mutex sync.Mutex
+	first *Future
+	last  **Future
 }
 
 // Greeting is a message sent by tarantool on connect.
@@ -168,6 +254,10 @@ type Opts struct {
 	// MaxReconnects is a maximum reconnect attempts.
 	// After MaxReconnects attempts Connection becomes closed.
 	MaxReconnects uint
+	// ReconnectPolicy decides pauses between reconnect attempts and when
+	// to give them up. It overrides Reconnect and MaxReconnects, which are
+	// equal to FixedReconnect{Reconnect, MaxReconnects} policy.
+	ReconnectPolicy ReconnectPolicy
 	// User name for authorization
 	User string
 	// Pass is password for authorization
@@ -185,21 +275,140 @@ type Opts struct {
 	//                If no timeout period is set, it will wait forever.
 	// It is required if RateLimit is specified.
 	RLimitAction uint
+	// RateLimits limits rate of requests per second globally, per space
+	// and per function. It is disabled by default.
+	RateLimits *RateLimits
 	// Concurrency is amount of separate mutexes for request
-	// queues and buffers inside of connection.
+	// buffers inside of connection. Every buffer has its own queue of
+	// requests waiting for response split into 128 buckets with separate
+	// mutexes.
 	// It is rounded upto nearest power of 2.
 	// By default it is runtime.GOMAXPROCS(-1) * 4
 	Concurrency uint32
 	// SkipSchema disables schema loading. Without disabling schema loading,
 	// there is no way to create Connection for currently not accessible tarantool.
+	// Requests with names of spaces or indexes fail then with
+	// NameResolutionError, unless SchemaResolver is set.
 	SkipSchema bool
+	// SchemaResolver resolves names of spaces and indexes instead of
+	// schema loaded from _vspace and _vindex, schema is not loaded if it is
+	// set. If it is a *Schema, e.g. one loaded with ImportSchema, it is
+	// also set as Connection.Schema.
+	SchemaResolver SchemaResolver
 	// Notify is a channel which receives notifications about Connection status
 	// changes.
 	Notify chan<- ConnEvent
 	// Handle is user specified value, that could be retrivied with Handle() method
 	Handle interface{}
+	// OnConnect is called on every connect and reconnect before connection
+	// is reported as connected, e.g. to set session settings or to warm
+	// up caches. Requests of the hook are sent as usual, while
+	// ConnectedNow reports false and Connected event is not sent yet.
+	// If the hook fails, connection is closed and the error is handled as
+	// a failed connect attempt. Schema is not loaded yet on the first
+	// connect, so spaces should be referred by numbers. Opts.Timeout
+	// limits requests of the hook, so it should be set.
+	OnConnect func(ctx context.Context, conn *Connection) error
 	// Logger is user specified logger used for error messages
 	Logger Logger
+	// FlushInterval is a maximum time requests are kept in write buffer
+	// waiting for other requests to be sent with the same write.
+	// By default, buffer is flushed as soon as there are no more requests
+	// to be written. Use Connection.Flush() to send buffered requests
+	// without waiting.
+	FlushInterval time.Duration
+	// MaxBatchBytes is a size of buffered requests after which buffer is
+	// flushed regardless of FlushInterval. Buffer is also flushed when its
+	// capacity (128KB) is exhausted.
+	MaxBatchBytes int
+	// ContextValues lists values of context.Context which are passed to
+	// server by CallContext, Call17Context and EvalContext, e.g. request or
+	// tenant id to correlate server logs with client traces. Values found in
+	// context are appended to arguments as a map from name to value.
+	ContextValues []ContextValue
+	// TrafficDump receives raw requests and responses for debugging, see
+	// TrafficRecord for format. Packets of handshake (greeting and auth)
+	// are not dumped. Errors of TrafficDump are ignored.
+	TrafficDump io.Writer
+	// SyncGenerator generates request ids (IPROTO_SYNC) instead of the
+	// internal counter. It may be used to get deterministic ids in tests
+	// or to correlate requests with server logs.
+	SyncGenerator SyncGenerator
+	// Clock is a source of time for request timeouts, pings and pauses
+	// between reconnects. By default, real time is used.
+	Clock Clock
+	// LeakThreshold enables detection of lost responses: requests that are
+	// not answered for longer than LeakThreshold are reported to Logger
+	// with LogFutureLeak. It is disabled by default.
+	LeakThreshold time.Duration
+	// SpaceAliases maps client-side space names to names in schema, see
+	// Connection.SetSpaceAlias.
+	SpaceAliases map[string]string
+	// CaseInsensitiveNames enables case insensitive lookup of space and
+	// index names, which is used when there is no exact match.
+	CaseInsensitiveNames bool
+	// OnDecodeError is called when a response can't be decoded. Responses
+	// are decoded by Future.Get and Future.GetTyped, so it is called from
+	// their goroutines, or from reader goroutine if response header is
+	// malformed.
+	OnDecodeError func(conn *Connection, err DecodeError)
+	// DecodeErrorPolicy defines whether a response that can't be decoded
+	// fails only its request (default) or the whole connection. Malformed
+	// response header always fails the connection.
+	DecodeErrorPolicy DecodeErrorPolicy
+	// RequiredProtocolInfo is a minimal protocol version and features
+	// Tarantool must support. Otherwise connect fails with
+	// ProtocolMismatchError.
+	RequiredProtocolInfo ProtocolInfo
+	// TrackSizes enables tracking of request and response sizes by
+	// request code and space, see Connection.Stats.
+	TrackSizes bool
+	// HeavyRequests is a number of the largest requests and responses
+	// remembered when TrackSizes is set, 10 by default.
+	HeavyRequests int
+	// TrackRequests enables counting of completed requests and their
+	// latencies by request code and space, see Connection.Stats.
+	TrackRequests bool
+	// ProfilerLabels enables pprof labels of request type, space and
+	// stage ("encode" or "decode") on goroutines while they encode
+	// requests and decode responses, so CPU profiles attribute time to
+	// spaces. Labels are added to labels of ctx of requests sent with it,
+	// e.g. with EvalContext, and goroutine labels are restored to them
+	// afterwards. Labels of other goroutines could not be restored, so
+	// requests without ctx are not labeled.
+	ProfilerLabels bool
+	// TraceRegions enables runtime/trace regions named
+	// "tarantool.<request>.<stage>" around encoding and decoding.
+	TraceRegions bool
+	// StreamThreshold enables streaming of responses larger than
+	// StreamThreshold bytes to requests made with SelectStream and
+	// Call17Stream: their tuples are decoded directly from the socket
+	// instead of buffering the whole response. Streaming is disabled by
+	// default.
+	StreamThreshold int
+	// MaxMemory limits size of buffered requests and responses in bytes.
+	// Requests are accounted until they are written to socket, responses
+	// are accounted until they are retrieved from Future or the Future is
+	// garbage collected, so unread responses hold the memory. Requests and
+	// responses exceeding the limit fail with ClientError{Code:
+	// ErrMemoryLimit}, such responses are skipped without buffering. It is
+	// unlimited by default.
+	MaxMemory int64
+	// ValidateIterators enables client-side check that iterator of select
+	// is supported by type of the index, see Index.ValidateIterator. It
+	// requires loaded schema.
+	ValidateIterators bool
+	// TupleExtension asks Tarantool to send tuples of responses as MP_TUPLE
+	// extension with format ids (IPROTO_FEATURE_DML_TUPLE_EXTENSION and
+	// IPROTO_FEATURE_CALL_RET_TUPLE_EXTENSION, Tarantool 2.11+). Such
+	// tuples are decoded as ExtTuple with access to fields by names, so
+	// results should be decoded into ExtTuple instead of arrays.
+	TupleExtension bool
+	// ValidateTuples enables client-side check of tuples of insert,
+	// replace and upsert and of update operations against space format,
+	// see Space.ValidateTuple and Space.ValidateOps. Invalid requests fail
+	// with TupleValidationError without sending. It requires loaded schema.
+	ValidateTuples bool
 }
 
 // Connect creates and configures new Connection
@@ -222,10 +431,10 @@ type Opts struct {
 //
 // Note:
 //
-// - If opts.Reconnect is zero (default), then connection either already connected
-// or error is returned.
+// - If opts.Reconnect is zero and opts.ReconnectPolicy is nil (default), then
+// connection either already connected or error is returned.
 //
-// - If opts.Reconnect is non-zero, then error will be returned only if authorization// fails. But if Tarantool is not reachable, then it will attempt to reconnect later
+// - If opts.Reconnect is non-zero or opts.ReconnectPolicy is set, then error will be returned only if authorization// fails. But if Tarantool is not reachable, then it will attempt to reconnect later
 // and will not end attempts on authorization failures.
 func Connect(addr string, opts Opts) (conn *Connection, err error) {
 	conn = &Connection{
@@ -233,8 +442,10 @@ func Connect(addr string, opts Opts) (conn *Connection, err error) {
 		requestId: 0,
 		Greeting:  &Greeting{},
 		control:   make(chan struct{}),
+		flush:     make(chan struct{}, 1),
 		opts:      opts,
 		dec:       msgpack.NewDecoder(&smallBuf{}),
+		aliases:   newNameAliases(opts),
 	}
 	maxprocs := uint32(runtime.GOMAXPROCS(-1))
 	if conn.opts.Concurrency == 0 || conn.opts.Concurrency > maxprocs*128 {
@@ -262,38 +473,81 @@ func Connect(addr string, opts Opts) (conn *Connection, err error) {
 		}
 	}
 
+	if conn.opts.Clock == nil {
+		conn.opts.Clock = realClock{}
+	}
+
+	if opts.TrafficDump != nil {
+		conn.traffic = &trafficDumper{w: opts.TrafficDump}
+	}
+
+	if opts.TrackSizes {
+		conn.sizes = newSizeTracker(opts.HeavyRequests)
+	}
+
+	if opts.TrackRequests {
+		conn.requests = &requestTracker{}
+	}
+
+	if opts.MaxMemory > 0 {
+		conn.memory = &memoryLimiter{max: opts.MaxMemory}
+	}
+
+	if opts.RateLimits != nil {
+		conn.limiter = newRateLimiter(opts.RateLimits, conn.opts.Clock)
+	}
+
 	if conn.opts.Logger == nil {
 		conn.opts.Logger = defaultLogger{}
 	}
 
-	if err = conn.createConnection(false); err != nil {
+	if conn.opts.ReconnectPolicy == nil && conn.opts.Reconnect > 0 {
+		conn.opts.ReconnectPolicy = FixedReconnect{
+			Delay:       conn.opts.Reconnect,
+			MaxAttempts: conn.opts.MaxReconnects,
+		}
+	}
+
+	// requests of Opts.OnConnect are timed out and socket is pinged on the
+	// first connect too
+	go conn.pinger()
+	if conn.opts.Timeout > 0 {
+		go conn.timeouts()
+	}
+
+	conn.mutex.Lock()
+	err = conn.createConnection(false)
+	conn.mutex.Unlock()
+	if err != nil {
 		ter, ok := err.(Error)
-		if conn.opts.Reconnect <= 0 {
-			return nil, err
-		} else if ok && (ter.Code == ErrNoSuchUser ||
+		_, mismatch := err.(ProtocolMismatchError)
+		if conn.opts.ReconnectPolicy == nil || mismatch || ok && (ter.Code == ErrNoSuchUser ||
 			ter.Code == ErrPasswordMismatch) {
 			/* reported auth errors immediatly */
+			conn.mutex.Lock()
+			conn.closeConnection(err, true)
+			conn.mutex.Unlock()
 			return nil, err
-		} else {
-			// without SkipSchema it is useless
-			go func(conn *Connection) {
-				conn.mutex.Lock()
-				defer conn.mutex.Unlock()
-				if err := conn.createConnection(true); err != nil {
-					conn.closeConnection(err, true)
-				}
-			}(conn)
-			err = nil
 		}
+		// without SkipSchema it is useless
+		go func(conn *Connection) {
+			conn.mutex.Lock()
+			defer conn.mutex.Unlock()
+			if err := conn.createConnection(true); err != nil {
+				conn.closeConnection(err, true)
+			}
+		}(conn)
+		err = nil
 	}
 
-	go conn.pinger()
-	if conn.opts.Timeout > 0 {
-		go conn.timeouts()
+	if conn.opts.LeakThreshold > 0 {
+		go conn.leakDetector()
 	}
 
 	// TODO: reload schema after reconnect
-	if !conn.opts.SkipSchema {
+	if schema, ok := conn.opts.SchemaResolver.(*Schema); ok {
+		conn.setSchema(schema)
+	} else if !conn.opts.SkipSchema && conn.opts.SchemaResolver == nil {
 		if err = conn.loadSchema(); err != nil {
 			conn.mutex.Lock()
 			defer conn.mutex.Unlock()
@@ -315,6 +569,74 @@ func (conn *Connection) ClosedNow() bool {
 	return atomic.LoadUint32(&conn.state) == connClosed
 }
 
+// State reports current state of connection.
+func (conn *Connection) State() ConnState {
+	return ConnState(atomic.LoadUint32(&conn.state))
+}
+
+// Reconnect closes current socket and establishes new one, e.g. after DNS
+// failover. Requests in flight are aborted with ErrConnectionNotReady.
+//
+// If Opts.Reconnect is specified, connect attempts are repeated with that
+// pause until ctx is done, after that connection is left to usual automatic
+// reconnection and ctx error is returned. Otherwise only one attempt is
+// performed, and connection becomes "Closed" if it fails.
+func (conn *Connection) Reconnect(ctx context.Context) (err error) {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	if conn.state == connClosed {
+		return ClientError{ErrConnectionClosed, "using closed connection"}
+	}
+	conn.closeConnection(ClientError{ErrConnectionNotReady, "reconnect requested by client"}, false)
+	for reconnects := uint(0); ; reconnects++ {
+		if err = conn.dial(); err == nil {
+			err = conn.initConnection()
+		}
+		if err == nil {
+			conn.notify(Connected)
+			return nil
+		}
+		if conn.opts.ReconnectPolicy == nil {
+			conn.closeConnection(err, true)
+			return err
+		}
+		delay, ok := conn.opts.ReconnectPolicy.NextDelay(reconnects, err)
+		if !ok {
+			conn.opts.Logger.Report(LogLastReconnectFailed, conn, err)
+			conn.closeConnection(err, true)
+			return err
+		}
+		conn.opts.Logger.Report(LogReconnectFailed, conn, reconnects, err)
+		conn.notify(ReconnectFailed)
+		t := conn.opts.Clock.NewTimer(delay)
+		conn.mutex.Unlock()
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-t.C():
+		}
+		t.Stop()
+		conn.mutex.Lock()
+		if conn.state == connClosed {
+			return ClientError{ErrConnectionClosed, "using closed connection"}
+		}
+		if conn.c != nil {
+			// connection is reestablished by another goroutine
+			return nil
+		}
+		if err != nil {
+			go func() {
+				conn.mutex.Lock()
+				defer conn.mutex.Unlock()
+				if err := conn.createConnection(true); err != nil {
+					conn.closeConnection(err, true)
+				}
+			}()
+			return err
+		}
+	}
+}
+
 // Close closes Connection.
 // After this method called, there is no way to reopen this Connection.
 func (conn *Connection) Close() error {
@@ -324,6 +646,40 @@ func (conn *Connection) Close() error {
 	return conn.closeConnection(err, true)
 }
 
+// CloseGraceful closes connection after requests in flight are done.
+// Connection state becomes StateShutdownPending and new requests are
+// rejected with ErrConnectionClosed meanwhile. If ctx is done earlier,
+// connection is closed, remaining requests are aborted and ctx error
+// is returned.
+func (conn *Connection) CloseGraceful(ctx context.Context) (err error) {
+	conn.mutex.Lock()
+	if conn.state == connClosed {
+		conn.mutex.Unlock()
+		return nil
+	}
+	conn.lockShards()
+	atomic.StoreUint32(&conn.state, connShutdown)
+	conn.unlockShards()
+	conn.mutex.Unlock()
+
+	t := conn.opts.Clock.NewTimer(shutdownPollInterval)
+	defer t.Stop()
+wait:
+	for conn.pendingCount() > 0 {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break wait
+		case <-t.C():
+			t.Reset(shutdownPollInterval)
+		}
+	}
+	if cerr := conn.Close(); err == nil {
+		err = cerr
+	}
+	return
+}
+
 // Addr is configured address of Tarantool socket
 func (conn *Connection) Addr() string {
 	return conn.addr
@@ -397,6 +753,26 @@ func (conn *Connection) dial() (err error) {
 	}
 	conn.Greeting.Version = bytes.NewBuffer(greeting[:64]).String()
 	conn.Greeting.auth = bytes.NewBuffer(greeting[64:108]).String()
+	atomic.StoreUint32(&conn.version, uint32(parseGreetingVersion(conn.Greeting.Version)))
+
+	// Identify protocol features
+	if err = conn.writeIdRequest(w); err == nil {
+		if err = w.Flush(); err != nil {
+			err = errors.New("id: flush error " + err.Error())
+		}
+	}
+	if err != nil {
+		connection.Close()
+		return
+	}
+	protocolInfo, err := conn.readIdResponse(r)
+	if err == nil {
+		err = conn.checkProtocolInfo(protocolInfo)
+	}
+	if err != nil {
+		connection.Close()
+		return
+	}
 
 	// Auth
 	if conn.opts.User != "" {
@@ -419,11 +795,52 @@ func (conn *Connection) dial() (err error) {
 	// Only if connected and authenticated
 	conn.lockShards()
 	conn.c = connection
-	atomic.StoreUint32(&conn.state, connConnected)
+	conn.protocolInfo = protocolInfo
+	conn.tupleFormats.reset()
+	conn.session = nil
+	if conn.opts.OnConnect != nil {
+		conn.initialized = make(chan struct{})
+		atomic.StoreUint32(&conn.state, connInitializing)
+	} else {
+		atomic.StoreUint32(&conn.state, connConnected)
+	}
 	conn.unlockShards()
 	go conn.writer(w, connection)
 	go conn.reader(r, connection)
+	return
+}
+
+// onConnectKey marks context of Opts.OnConnect.
+type onConnectKey struct{}
 
+// initConnection runs Opts.OnConnect for just dialed socket. Mutex is
+// released meanwhile, so the hook may use any method of connection and
+// reader may reconnect if the socket fails.
+func (conn *Connection) initConnection() (err error) {
+	if conn.opts.OnConnect == nil {
+		return nil
+	}
+	c, initialized := conn.c, conn.initialized
+	conn.mutex.Unlock()
+	ctx := context.WithValue(context.Background(), onConnectKey{}, conn)
+	err = conn.opts.OnConnect(ctx, conn)
+	conn.mutex.Lock()
+	if conn.c != c {
+		// connection is closed by user or broken during the hook
+		return ClientError{ErrConnectionNotReady, "connection is lost during on connect hook"}
+	}
+	if err != nil {
+		err = fmt.Errorf("on connect: %s", err)
+		conn.closeConnection(err, false)
+		return
+	}
+	conn.lockShards()
+	// connection may be shut down gracefully meanwhile
+	if conn.state == connInitializing {
+		atomic.StoreUint32(&conn.state, connConnected)
+	}
+	close(initialized)
+	conn.unlockShards()
 	return
 }
 
@@ -433,12 +850,7 @@ func (conn *Connection) writeAuthRequest(w *bufio.Writer, scramble []byte) (err
 		requestCode: AuthRequest,
 	}
 	var packet smallWBuf
-	err = request.pack(&packet, msgpack.NewEncoder(&packet), func(enc *msgpack.Encoder) error {
-		return enc.Encode(map[uint32]interface{}{
-			KeyUserName: conn.opts.User,
-			KeyTuple:    []interface{}{string("chap-sha1"), string(scramble)},
-		})
-	})
+	err = request.pack(&packet, msgpack.NewEncoder(&packet), authBody(conn.opts.User, scramble))
 	if err != nil {
 		return errors.New("auth: pack error " + err.Error())
 	}
@@ -451,6 +863,46 @@ func (conn *Connection) writeAuthRequest(w *bufio.Writer, scramble []byte) (err
 	return
 }
 
+// Authenticate re-authenticates session of connection as user of req,
+// e.g. to switch users without reconnect. On success the credentials are
+// used for reconnects, user is reported by ConnectionInfo and
+// SessionInfo. Requests sent concurrently may be performed on behalf of
+// any of users.
+//
+// Scramble depends on salt of the socket, so if connection is
+// reestablished meanwhile, the request is repeated on the new socket a few
+// times.
+func (conn *Connection) Authenticate(req *Auth) error {
+	for attempt := 1; ; attempt++ {
+		conn.mutex.Lock()
+		salt, c := conn.Greeting.auth, conn.c
+		conn.mutex.Unlock()
+		if c == nil {
+			return ClientError{ErrConnectionNotReady, "client connection is not ready"}
+		}
+		body, err := req.body(salt)
+		if err != nil {
+			return errors.New("auth: scrambling failure " + err.Error())
+		}
+		_, err = conn.newFuture(AuthRequest, 0).send(conn, body).Get()
+
+		conn.mutex.Lock()
+		if conn.c != c {
+			conn.mutex.Unlock()
+			if attempt < authAttempts {
+				continue
+			}
+			return ClientError{ErrConnectionNotReady, "connection is reestablished during auth"}
+		}
+		if err == nil {
+			conn.opts.User, conn.opts.Pass = req.user, req.pass
+			conn.session = nil
+		}
+		conn.mutex.Unlock()
+		return err
+	}
+}
+
 func (conn *Connection) readAuthResponse(r io.Reader) (err error) {
 	respBytes, err := conn.read(r)
 	if err != nil {
@@ -476,15 +928,19 @@ func (conn *Connection) readAuthResponse(r io.Reader) (err error) {
 func (conn *Connection) createConnection(reconnect bool) (err error) {
 	var reconnects uint
 	for conn.c == nil && conn.state == connDisconnected {
-		now := time.Now()
+		now := conn.opts.Clock.Now()
 		err = conn.dial()
+		if err == nil {
+			err = conn.initConnection()
+		}
 		if err == nil || !reconnect {
 			if err == nil {
 				conn.notify(Connected)
 			}
 			return
 		}
-		if conn.opts.MaxReconnects > 0 && reconnects > conn.opts.MaxReconnects {
+		delay, ok := conn.opts.ReconnectPolicy.NextDelay(reconnects, err)
+		if !ok {
 			conn.opts.Logger.Report(LogLastReconnectFailed, conn, err)
 			err = ClientError{ErrConnectionClosed, "last reconnect failed"}
 			// mark connection as closed to avoid reopening by another goroutine
@@ -494,11 +950,15 @@ func (conn *Connection) createConnection(reconnect bool) (err error) {
 		conn.notify(ReconnectFailed)
 		reconnects++
 		conn.mutex.Unlock()
-		time.Sleep(now.Add(conn.opts.Reconnect).Sub(time.Now()))
+		t := conn.opts.Clock.NewTimer(now.Add(delay).Sub(conn.opts.Clock.Now()))
+		<-t.C()
 		conn.mutex.Lock()
 	}
 	if conn.state == connClosed {
 		err = ClientError{ErrConnectionClosed, "using closed connection"}
+	} else if conn.c != nil {
+		// connection is reestablished by another goroutine
+		err = nil
 	}
 	return
 }
@@ -513,7 +973,10 @@ func (conn *Connection) closeConnection(neterr error, forever bool) (err error)
 			conn.notify(Closed)
 		}
 	} else {
-		atomic.StoreUint32(&conn.state, connDisconnected)
+		if conn.state != connShutdown {
+			// connection being shut down is not reestablished
+			atomic.StoreUint32(&conn.state, connDisconnected)
+		}
 		conn.notify(Disconnected)
 	}
 	if conn.c != nil {
@@ -522,6 +985,8 @@ func (conn *Connection) closeConnection(neterr error, forever bool) (err error)
 	}
 	for i := range conn.shard {
 		conn.shard[i].buf.Reset()
+		conn.memory.release(conn.shard[i].reserved)
+		conn.shard[i].reserved = 0
 		requests := &conn.shard[i].requests
 		for pos := range requests {
 			fut := requests[pos].first
@@ -540,12 +1005,14 @@ func (conn *Connection) closeConnection(neterr error, forever bool) (err error)
 func (conn *Connection) reconnect(neterr error, c net.Conn) {
 	conn.mutex.Lock()
 	defer conn.mutex.Unlock()
-	if conn.opts.Reconnect > 0 {
-		if c == conn.c {
-			conn.closeConnection(neterr, false)
-			if err := conn.createConnection(true); err != nil {
-				conn.closeConnection(err, true)
-			}
+	if c != conn.c {
+		// socket is already replaced or closed
+		return
+	}
+	if conn.opts.ReconnectPolicy != nil {
+		conn.closeConnection(neterr, false)
+		if err := conn.createConnection(true); err != nil {
+			conn.closeConnection(err, true)
 		}
 	} else {
 		conn.closeConnection(neterr, true)
@@ -554,15 +1021,21 @@ func (conn *Connection) reconnect(neterr error, c net.Conn) {
 
 func (conn *Connection) lockShards() {
 	for i := range conn.shard {
-		conn.shard[i].rmut.Lock()
-		conn.shard[i].bufmut.Lock()
+		shard := &conn.shard[i]
+		for pos := range shard.requests {
+			shard.requests[pos].mutex.Lock()
+		}
+		shard.bufmut.Lock()
 	}
 }
 
 func (conn *Connection) unlockShards() {
 	for i := range conn.shard {
-		conn.shard[i].rmut.Unlock()
-		conn.shard[i].bufmut.Unlock()
+		shard := &conn.shard[i]
+		for pos := range shard.requests {
+			shard.requests[pos].mutex.Unlock()
+		}
+		shard.bufmut.Unlock()
 	}
 }
 
@@ -571,22 +1044,28 @@ func (conn *Connection) pinger() {
 	if to == 0 {
 		to = 3 * time.Second
 	}
-	t := time.NewTicker(to / 3)
+	t := conn.opts.Clock.NewTimer(to / 3)
 	defer t.Stop()
 	for {
 		select {
 		case <-conn.control:
 			return
-		case <-t.C:
+		case <-t.C():
 		}
-		conn.Ping()
+		t.Reset(to / 3)
+		// pings are not held back by Opts.OnConnect, so socket is alive
+		// during the hook
+		conn.newFutureOf(PingRequest, 0, "", true).send(conn, func(enc *msgpack.Encoder) error {
+			enc.EncodeMapLen(0)
+			return nil
+		}).Get()
 	}
 }
 
 func (conn *Connection) notify(kind ConnEventKind) {
 	if conn.opts.Notify != nil {
 		select {
-		case conn.opts.Notify <- ConnEvent{Kind: kind, Conn: conn, When: time.Now()}:
+		case conn.opts.Notify <- ConnEvent{Kind: kind, Conn: conn, State: conn.State(), When: conn.opts.Clock.Now()}:
 		default:
 		}
 	}
@@ -595,19 +1074,42 @@ func (conn *Connection) notify(kind ConnEventKind) {
 func (conn *Connection) writer(w *bufio.Writer, c net.Conn) {
 	var shardn uint32
 	var packet smallWBuf
+	var flushTimer Timer
+	var flushC <-chan time.Time
+	forced := false
+	flush := func() error {
+		forced = false
+		if flushTimer != nil {
+			flushTimer.Stop()
+			flushTimer, flushC = nil, nil
+		}
+		return w.Flush()
+	}
 	for atomic.LoadUint32(&conn.state) != connClosed {
 		select {
 		case shardn = <-conn.dirtyShard:
 		default:
 			runtime.Gosched()
 			if len(conn.dirtyShard) == 0 {
-				if err := w.Flush(); err != nil {
-					conn.reconnect(err, c)
-					return
+				if conn.opts.FlushInterval <= 0 || forced || w.Buffered() == 0 {
+					if err := flush(); err != nil {
+						conn.reconnect(err, c)
+						return
+					}
+				} else if flushTimer == nil {
+					flushTimer = conn.opts.Clock.NewTimer(conn.opts.FlushInterval)
+					flushC = flushTimer.C()
 				}
 			}
 			select {
 			case shardn = <-conn.dirtyShard:
+			case <-flushC:
+				flushTimer, flushC = nil, nil
+				forced = true
+				continue
+			case <-conn.flush:
+				forced = true
+				continue
 			case <-conn.control:
 				return
 			}
@@ -620,76 +1122,176 @@ func (conn *Connection) writer(w *bufio.Writer, c net.Conn) {
 			return
 		}
 		packet, shard.buf = shard.buf, packet
+		reserved := shard.reserved
+		shard.reserved = 0
 		shard.bufmut.Unlock()
 		if packet.Len() == 0 {
 			continue
 		}
-		if err := write(w, packet.b); err != nil {
+		err := hitFailpoint(FailpointWrite)
+		if err == nil {
+			err = write(w, packet.b)
+		}
+		conn.memory.release(reserved)
+		if err != nil {
 			conn.reconnect(err, c)
 			return
 		}
+		conn.traffic.dump(TrafficOut, conn.opts.Clock.Now(), nil, packet.b)
 		packet.Reset()
+		select {
+		case <-flushC:
+			// interval is elapsed while other requests are coming
+			flushTimer, flushC = nil, nil
+			forced = true
+		default:
+		}
+		if forced || conn.opts.MaxBatchBytes > 0 && w.Buffered() >= conn.opts.MaxBatchBytes {
+			if err := flush(); err != nil {
+				conn.reconnect(err, c)
+				return
+			}
+		}
+	}
+}
+
+// Flush asks connection to send all buffered requests to Tarantool
+// without waiting for Opts.FlushInterval. It does not wait for the write
+// to complete.
+func (conn *Connection) Flush() {
+	select {
+	case conn.flush <- struct{}{}:
+	default:
 	}
 }
 
 func (conn *Connection) reader(r *bufio.Reader, c net.Conn) {
 	for atomic.LoadUint32(&conn.state) != connClosed {
-		respBytes, err := conn.read(r)
+		length, err := conn.readLength(r)
+		if err == nil && conn.opts.StreamThreshold > 0 && length > conn.opts.StreamThreshold {
+			var streamed bool
+			if streamed, err = conn.readStream(r, length); err == nil && streamed {
+				continue
+			}
+		}
+		var respBytes []byte
+		if err == nil {
+			if !conn.memory.reserve(length) {
+				if err = conn.skipResponse(r, length); err == nil {
+					continue
+				}
+			} else if respBytes, err = conn.readBody(r, length); err != nil {
+				conn.memory.release(length)
+			}
+		}
 		if err != nil {
 			conn.reconnect(err, c)
 			return
 		}
+		conn.traffic.dump(TrafficIn, conn.opts.Clock.Now(), conn.lenbuf[:], respBytes)
 		resp := &Response{buf: smallBuf{b: respBytes}}
 		err = resp.decodeHeader(conn.dec)
 		if err != nil {
+			// request is unknown, so connection is failed regardless of policy
+			if conn.opts.OnDecodeError != nil {
+				conn.opts.OnDecodeError(conn, DecodeError{Err: err, Body: respBytes})
+			}
+			conn.memory.release(length)
 			conn.reconnect(err, c)
 			return
 		}
 		if fut := conn.fetchFuture(resp.RequestId); fut != nil {
+			conn.sizes.add(TrafficIn, fut, len(respBytes), conn.opts.Clock.Now())
+			if conn.memory != nil {
+				fut.holdMemory(length)
+			}
 			fut.resp = resp
 			fut.markReady(conn)
 		} else {
+			conn.memory.release(length)
 			conn.opts.Logger.Report(LogUnexpectedResultId, conn, resp)
 		}
 	}
 }
 
-func (conn *Connection) newFuture(requestCode int32) (fut *Future) {
+func (conn *Connection) newFuture(requestCode int32, spaceNo uint32) (fut *Future) {
+	return conn.newFutureFor(requestCode, spaceNo, "")
+}
+
+// newFutureFor creates future for request to space or function.
+func (conn *Connection) newFutureFor(requestCode int32, spaceNo uint32, function string) (fut *Future) {
+	return conn.newFutureOf(requestCode, spaceNo, function, false)
+}
+
+// newFutureCtx creates future for request sent with ctx, which is sent
+// during Opts.OnConnect if ctx is the one of the hook.
+func (conn *Connection) newFutureCtx(ctx context.Context, requestCode int32, function string) *Future {
+	fut := conn.newFutureOf(requestCode, 0, function, ctx.Value(onConnectKey{}) == conn)
+	fut.ctx = ctx
+	return fut
+}
+
+// newFutureOf creates future for request, which waits for Opts.OnConnect
+// unless it is sent by the hook.
+func (conn *Connection) newFutureOf(requestCode int32, spaceNo uint32, function string, hook bool) (fut *Future) {
 	fut = &Future{}
-	if conn.rlimit != nil && conn.opts.RLimitAction == RLimitDrop {
-		select {
-		case conn.rlimit <- struct{}{}:
-		default:
-			fut.err = ClientError{ErrRateLimited, "Request is rate limited on client"}
-			return
-		}
-	}
 	fut.ready = make(chan struct{})
+	fut.conn = conn
 	fut.requestId = conn.nextRequestId()
 	fut.requestCode = requestCode
-	shardn := fut.requestId & (conn.opts.Concurrency - 1)
-	shard := &conn.shard[shardn]
-	shard.rmut.Lock()
+	fut.spaceNo = spaceNo
+	fut.start = conn.sinceEpoch()
+	bucket := conn.futureBucket(fut.requestId)
+	bucket.mutex.Lock()
 	switch conn.state {
 	case connClosed:
 		fut.err = ClientError{ErrConnectionClosed, "using closed connection"}
 		fut.ready = nil
-		shard.rmut.Unlock()
+		bucket.mutex.Unlock()
 		return
 	case connDisconnected:
 		fut.err = ClientError{ErrConnectionNotReady, "client connection is not ready"}
 		fut.ready = nil
-		shard.rmut.Unlock()
+		bucket.mutex.Unlock()
 		return
+	case connShutdown:
+		fut.err = ClientError{ErrConnectionClosed, "connection is shutting down"}
+		fut.ready = nil
+		bucket.mutex.Unlock()
+		return
+	}
+	limited := requestCode != PingRequest
+	if limited {
+		if err := conn.checkRate(spaceNo, function); err != nil {
+			fut.err = err
+			fut.ready = nil
+			bucket.mutex.Unlock()
+			return
+		}
+	}
+	if conn.rlimit != nil && conn.opts.RLimitAction == RLimitDrop {
+		select {
+		case conn.rlimit <- struct{}{}:
+		default:
+			if limited {
+				conn.refundRate(spaceNo, function)
+			}
+			fut.err = ClientError{ErrRateLimited, "Request is rate limited on client"}
+			fut.ready = nil
+			bucket.mutex.Unlock()
+			return
+		}
+	}
+	var initialized chan struct{}
+	if conn.state == connInitializing && !hook {
+		initialized = conn.initialized
 	}
-	pos := (fut.requestId / conn.opts.Concurrency) & (requestsMap - 1)
-	pair := &shard.requests[pos]
-	*pair.last = fut
-	pair.last = &fut.next
+	*bucket.last = fut
+	bucket.last = &fut.next
 	if conn.opts.Timeout > 0 {
-		fut.timeout = time.Now().Sub(epoch) + conn.opts.Timeout
+		fut.timeout = conn.sinceEpoch() + conn.opts.Timeout
 	}
-	shard.rmut.Unlock()
+	bucket.mutex.Unlock()
 	if conn.rlimit != nil && conn.opts.RLimitAction == RLimitWait {
 		select {
 		case conn.rlimit <- struct{}{}:
@@ -704,6 +1306,14 @@ func (conn *Connection) newFuture(requestCode int32) (fut *Future) {
 			}
 		}
 	}
+	if initialized != nil {
+		// future is failed if connection is closed or request is timed
+		// out meanwhile
+		select {
+		case <-initialized:
+		case <-fut.ready:
+		}
+	}
 	return
 }
 
@@ -723,12 +1333,27 @@ func (conn *Connection) putFuture(fut *Future, body func(*msgpack.Encoder) error
 		shard.enc = msgpack.NewEncoder(&shard.buf)
 	}
 	blen := shard.buf.Len()
-	if err := fut.pack(&shard.buf, shard.enc, body); err != nil {
+	err := hitFailpoint(FailpointTimeout)
+	if err == nil {
+		fut.profile(conn, "encode", func() {
+			if err = hitFailpoint(FailpointEncode); err == nil {
+				err = fut.pack(&shard.buf, shard.enc, body)
+			}
+		})
+	}
+	if size := shard.buf.Len() - blen; err == nil {
+		if conn.memory.reserve(size) {
+			shard.reserved += size
+		} else {
+			err = memoryLimitError("request", size, conn.memory.max)
+		}
+	}
+	if err != nil {
 		shard.buf.Trunc(blen)
 		shard.bufmut.Unlock()
 		if f := conn.fetchFuture(fut.requestId); f == fut {
-			fut.markReady(conn)
 			fut.err = err
+			fut.markReady(conn)
 		} else if f != nil {
 			/* in theory, it is possible. In practice, you have
 			 * to have race condition that lasts hours */
@@ -747,25 +1372,28 @@ func (conn *Connection) putFuture(fut *Future, body func(*msgpack.Encoder) error
 		}
 		return
 	}
+	conn.sizes.add(TrafficOut, fut, shard.buf.Len()-blen, conn.opts.Clock.Now())
 	shard.bufmut.Unlock()
 	if firstWritten {
 		conn.dirtyShard <- shardn
 	}
 }
 
-func (conn *Connection) fetchFuture(reqid uint32) (fut *Future) {
+func (conn *Connection) futureBucket(reqid uint32) *futureBucket {
 	shard := &conn.shard[reqid&(conn.opts.Concurrency-1)]
-	shard.rmut.Lock()
-	fut = conn.fetchFutureImp(reqid)
-	shard.rmut.Unlock()
+	return &shard.requests[(reqid/conn.opts.Concurrency)&(requestsMap-1)]
+}
+
+func (conn *Connection) fetchFuture(reqid uint32) (fut *Future) {
+	bucket := conn.futureBucket(reqid)
+	bucket.mutex.Lock()
+	fut = bucket.fetch(reqid)
+	bucket.mutex.Unlock()
 	return fut
 }
 
-func (conn *Connection) fetchFutureImp(reqid uint32) *Future {
-	shard := &conn.shard[reqid&(conn.opts.Concurrency-1)]
-	pos := (reqid / conn.opts.Concurrency) & (requestsMap - 1)
-	pair := &shard.requests[pos]
-	root := &pair.first
+func (bucket *futureBucket) fetch(reqid uint32) *Future {
+	root := &bucket.first
 	for {
 		fut := *root
 		if fut == nil {
@@ -774,7 +1402,7 @@ func (conn *Connection) fetchFutureImp(reqid uint32) *Future {
 		if fut.requestId == reqid {
 			*root = fut.next
 			if fut.next == nil {
-				pair.last = root
+				bucket.last = root
 			} else {
 				fut.next = nil
 			}
@@ -786,28 +1414,28 @@ func (conn *Connection) fetchFutureImp(reqid uint32) *Future {
 
 func (conn *Connection) timeouts() {
 	timeout := conn.opts.Timeout
-	t := time.NewTimer(timeout)
+	t := conn.opts.Clock.NewTimer(timeout)
 	for {
 		var nowepoch time.Duration
 		select {
 		case <-conn.control:
 			t.Stop()
 			return
-		case <-t.C:
+		case <-t.C():
 		}
-		minNext := time.Now().Sub(epoch) + timeout
+		minNext := conn.sinceEpoch() + timeout
 		for i := range conn.shard {
-			nowepoch = time.Now().Sub(epoch)
+			nowepoch = conn.sinceEpoch()
 			shard := &conn.shard[i]
 			for pos := range shard.requests {
-				shard.rmut.Lock()
-				pair := &shard.requests[pos]
-				for pair.first != nil && pair.first.timeout < nowepoch {
+				bucket := &shard.requests[pos]
+				bucket.mutex.Lock()
+				for bucket.first != nil && bucket.first.timeout < nowepoch {
 					shard.bufmut.Lock()
-					fut := pair.first
-					pair.first = fut.next
+					fut := bucket.first
+					bucket.first = fut.next
 					if fut.next == nil {
-						pair.last = &pair.first
+						bucket.last = &bucket.first
 					} else {
 						fut.next = nil
 					}
@@ -818,13 +1446,13 @@ func (conn *Connection) timeouts() {
 					fut.markReady(conn)
 					shard.bufmut.Unlock()
 				}
-				if pair.first != nil && pair.first.timeout < minNext {
-					minNext = pair.first.timeout
+				if bucket.first != nil && bucket.first.timeout < minNext {
+					minNext = bucket.first.timeout
 				}
-				shard.rmut.Unlock()
+				bucket.mutex.Unlock()
 			}
 		}
-		nowepoch = time.Now().Sub(epoch)
+		nowepoch = conn.sinceEpoch()
 		if nowepoch+time.Microsecond < minNext {
 			t.Reset(minNext - nowepoch)
 		} else {
@@ -833,6 +1461,99 @@ func (conn *Connection) timeouts() {
 	}
 }
 
+// PendingRequest describes request waiting for response.
+type PendingRequest struct {
+	RequestId   uint32
+	RequestCode int32
+	// SpaceNo is a space of request, it is zero for requests without space.
+	SpaceNo uint32
+	// Age is a time passed since request was created.
+	Age time.Duration
+}
+
+// PendingStats is a summary of requests waiting for response.
+type PendingStats struct {
+	// Count is a total number of pending requests.
+	Count int
+	// ByCode is a number of pending requests by request code.
+	ByCode map[int32]int
+	// Oldest is the oldest pending request, it is nil if there are
+	// no pending requests.
+	Oldest *PendingRequest
+}
+
+// PendingStats reports requests which wait for response at the moment.
+// It walks through all pending requests, so it is not intended to be
+// called on hot path.
+func (conn *Connection) PendingStats() (stats PendingStats) {
+	stats.ByCode = make(map[int32]int)
+	now := conn.sinceEpoch()
+	for i := range conn.shard {
+		shard := &conn.shard[i]
+		for pos := range shard.requests {
+			bucket := &shard.requests[pos]
+			bucket.mutex.Lock()
+			for fut := bucket.first; fut != nil; fut = fut.next {
+				stats.Count++
+				stats.ByCode[fut.requestCode]++
+				if stats.Oldest == nil || now-fut.start > stats.Oldest.Age {
+					stats.Oldest = fut.pending(now)
+				}
+			}
+			bucket.mutex.Unlock()
+		}
+	}
+	return
+}
+
+func (conn *Connection) pendingCount() (count int) {
+	for i := range conn.shard {
+		shard := &conn.shard[i]
+		for pos := range shard.requests {
+			bucket := &shard.requests[pos]
+			bucket.mutex.Lock()
+			for fut := bucket.first; fut != nil; fut = fut.next {
+				count++
+			}
+			bucket.mutex.Unlock()
+		}
+	}
+	return
+}
+
+func (conn *Connection) leakDetector() {
+	threshold := conn.opts.LeakThreshold
+	t := conn.opts.Clock.NewTimer(threshold / 2)
+	defer t.Stop()
+	for {
+		select {
+		case <-conn.control:
+			return
+		case <-t.C():
+		}
+		t.Reset(threshold / 2)
+		var leaks []PendingRequest
+		for i := range conn.shard {
+			shard := &conn.shard[i]
+			now := conn.sinceEpoch()
+			for pos := range shard.requests {
+				bucket := &shard.requests[pos]
+				bucket.mutex.Lock()
+				for fut := bucket.first; fut != nil; fut = fut.next {
+					if !fut.leaked && now-fut.start > threshold {
+						fut.leaked = true
+						leaks = append(leaks, *fut.pending(now))
+					}
+				}
+				bucket.mutex.Unlock()
+			}
+		}
+		for _, req := range leaks {
+			conn.opts.Logger.Report(LogFutureLeak, conn, req)
+		}
+	}
+}
+
 func write(w io.Writer, data []byte) (err error) {
 	l, err := w.Write(data)
 	if err != nil {
@@ -846,7 +1567,13 @@ func write(w io.Writer, data []byte) (err error) {
 
 func (conn *Connection) read(r io.Reader) (response []byte, err error) {
 	var length int
+	if length, err = conn.readLength(r); err != nil {
+		return
+	}
+	return conn.readBody(r, length)
+}
 
+func (conn *Connection) readLength(r io.Reader) (length int, err error) {
 	if _, err = io.ReadFull(r, conn.lenbuf[:]); err != nil {
 		return
 	}
@@ -861,15 +1588,35 @@ func (conn *Connection) read(r io.Reader) (response []byte, err error) {
 
 	if length == 0 {
 		err = errors.New("Response should not be 0 length")
-		return
 	}
+	return
+}
+
+func (conn *Connection) readBody(r io.Reader, length int) (response []byte, err error) {
 	response = make([]byte, length)
 	_, err = io.ReadFull(r, response)
-
 	return
 }
 
+// SyncGenerator generates request ids, see Opts.SyncGenerator.
+// Ids of requests which are waiting for response must be unique, and id 0
+// is reserved for connection handshake. NextSync is called concurrently.
+type SyncGenerator interface {
+	NextSync() uint32
+}
+
+// SyncGeneratorFunc is an adapter to use function as SyncGenerator.
+type SyncGeneratorFunc func() uint32
+
+// NextSync calls f().
+func (f SyncGeneratorFunc) NextSync() uint32 {
+	return f()
+}
+
 func (conn *Connection) nextRequestId() (requestId uint32) {
+	if conn.opts.SyncGenerator != nil {
+		return conn.opts.SyncGenerator.NextSync()
+	}
 	return atomic.AddUint32(&conn.requestId, 1)
 }
 
@@ -883,6 +1630,16 @@ func (conn *Connection) OverrideSchema(s *Schema) {
 	if s != nil {
 		conn.mutex.Lock()
 		defer conn.mutex.Unlock()
-		conn.Schema = s
+		if s.aliases == nil {
+			s.aliases = conn.aliases
+		}
+		conn.setSchema(s)
 	}
 }
+
+// setSchema sets Schema of the connection and updates schema dependent
+// state.
+func (conn *Connection) setSchema(s *Schema) {
+	conn.Schema = s
+	conn.limiter.setSchema(s)
+}
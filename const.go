@@ -13,6 +13,7 @@ const (
 	Call17Request    = 10
 	PingRequest      = 64
 	SubscribeRequest = 66
+	IdRequest        = 73
 
 	KeyCode         = 0x00
 	KeySync         = 0x01
@@ -29,19 +30,26 @@ const (
 	KeyDefTuple     = 0x28
 	KeyData         = 0x30
 	KeyError        = 0x31
+	KeyVersion      = 0x54
+	KeyFeatures     = 0x55
+	KeySpaceName    = 0x5e
+	KeyIndexName    = 0x5f
+	KeyTupleFormats = 0x60
 
 	// https://github.com/fl00r/go-tarantool-1.6/issues/2
 
-	IterEq            = uint32(0) // key == x ASC order
-	IterReq           = uint32(1) // key == x DESC order
-	IterAll           = uint32(2) // all tuples
-	IterLt            = uint32(3) // key < x
-	IterLe            = uint32(4) // key <= x
-	IterGe            = uint32(5) // key >= x
-	IterGt            = uint32(6) // key > x
-	IterBitsAllSet    = uint32(7) // all bits from x are set in key
-	IterBitsAnySet    = uint32(8) // at least one x's bit is set
-	IterBitsAllNotSet = uint32(9) // all bits are not set
+	IterEq            = uint32(0)  // key == x ASC order
+	IterReq           = uint32(1)  // key == x DESC order
+	IterAll           = uint32(2)  // all tuples
+	IterLt            = uint32(3)  // key < x
+	IterLe            = uint32(4)  // key <= x
+	IterGe            = uint32(5)  // key >= x
+	IterGt            = uint32(6)  // key > x
+	IterBitsAllSet    = uint32(7)  // all bits from x are set in key
+	IterBitsAnySet    = uint32(8)  // at least one x's bit is set
+	IterBitsAllNotSet = uint32(9)  // all bits are not set
+	IterOverlaps      = uint32(10) // key overlaps x
+	IterNeighbor      = uint32(11) // tuples in distance ascending order from x
 
 	RLimitDrop = 1
 	RLimitWait = 2
@@ -0,0 +1,216 @@
+// Package counters implements atomic counters backed by Tarantool: counters
+// with TTL and sliding window counters for rate limiting shared by several
+// application instances.
+//
+// Counters are updated by Lua functions bundled with the package. Init
+// installs them on an instance and creates the counters space; since
+// functions are not persisted, Init should be called again after instance
+// restart. Functions are invoked with Call17 requests.
+package counters
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// DefaultSpace is a name of counters space used by default.
+const DefaultSpace = "counters"
+
+// Tuples of counters space:
+// - counter: {key, value, expires}
+// - window: {key, count, expires, window_start, previous_window_count}
+// Zero expires means that counter never expires. Expired tuples are
+// treated as absent and are removed by purge.
+const initLua = `
+local space = ...
+local s = box.schema.space.create(space, {if_not_exists = true})
+s:create_index('primary', {parts = {1, 'string'}, if_not_exists = true})
+
+local clock = require('clock')
+local counters = {}
+
+local function get(s, key, now)
+    local t = s:get{key}
+    if t ~= nil and t[3] ~= 0 and t[3] <= now then
+        return nil
+    end
+    return t
+end
+
+function counters.incr(space, key, delta, ttl)
+    local s = box.space[space]
+    local now = clock.time()
+    if get(s, key, now) == nil then
+        s:replace{key, delta, ttl > 0 and now + ttl or 0}
+        return delta
+    end
+    return s:update({key}, {{'+', 2, delta}})[2]
+end
+
+function counters.get(space, key)
+    local t = get(box.space[space], key, clock.time())
+    return t == nil and 0 or t[2]
+end
+
+function counters.reset(space, key)
+    box.space[space]:delete{key}
+end
+
+function counters.allow(space, key, window, limit, n)
+    local s = box.space[space]
+    local now = clock.time()
+    local t = get(s, key, now)
+    local start, cur, prev = now, 0, 0
+    if t ~= nil then
+        start, cur, prev = t[4], t[2], t[5]
+        local passed = math.floor((now - start) / window)
+        if passed == 1 then
+            start, cur, prev = start + window, 0, cur
+        elseif passed > 1 then
+            start, cur, prev = start + passed * window, 0, 0
+        end
+    end
+    local rate = prev * (1 - (now - start) / window) + cur
+    if limit > 0 and rate + n > limit then
+        return false, rate
+    end
+    s:replace{key, cur + n, start + 2 * window, start, prev}
+    return true, rate + n
+end
+
+function counters.purge(space)
+    local s = box.space[space]
+    local now = clock.time()
+    local keys = {}
+    for _, t in s:pairs() do
+        if t[3] ~= 0 and t[3] <= now then
+            table.insert(keys, t[1])
+        end
+    end
+    for _, key in ipairs(keys) do
+        s:delete{key}
+    end
+    return #keys
+end
+
+rawset(_G, 'go_tarantool_counters', counters)
+`
+
+const funcPrefix = "go_tarantool_counters."
+
+// Init creates counters space if it doesn't exist and installs functions
+// of the package.
+// Note: it uses Eval, so user needs 'execute universe' privilege.
+func Init(conn tarantool.Connector, space string) error {
+	if space == "" {
+		space = DefaultSpace
+	}
+	_, err := conn.Eval(initLua, []interface{}{space})
+	return err
+}
+
+// Counters is a handle of counters space.
+type Counters struct {
+	conn  tarantool.Connector
+	space string
+}
+
+// New creates handle of counters space, DefaultSpace is used if space is
+// empty. Space should be initialized with Init.
+func New(conn tarantool.Connector, space string) *Counters {
+	if space == "" {
+		space = DefaultSpace
+	}
+	return &Counters{conn: conn, space: space}
+}
+
+func (c *Counters) call(function string, args ...interface{}) ([]interface{}, error) {
+	resp, err := c.conn.Call17(funcPrefix+function, append([]interface{}{c.space}, args...))
+	if err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// Incr adds delta to the counter and returns its new value. If counter
+// does not exist or is expired, it is created with ttl (zero ttl means
+// that counter never expires). So it could be used as fixed window
+// counter.
+func (c *Counters) Incr(key string, delta int64, ttl time.Duration) (int64, error) {
+	data, err := c.call("incr", key, delta, ttl.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return toInt64(data)
+}
+
+// Get returns value of the counter, it is zero if counter does not exist.
+func (c *Counters) Get(key string) (int64, error) {
+	data, err := c.call("get", key)
+	if err != nil {
+		return 0, err
+	}
+	return toInt64(data)
+}
+
+// Reset deletes the counter.
+func (c *Counters) Reset(key string) error {
+	_, err := c.call("reset", key)
+	return err
+}
+
+// Allow accounts n events in sliding window counter if number of events
+// in the last window does not exceed limit with them. It returns whether
+// events are accounted and estimated number of events in the last window.
+// Zero limit means no limit.
+//
+// The estimation is weighted sum of events in current and previous fixed
+// windows, so it needs constant memory per key.
+func (c *Counters) Allow(key string, n int64, limit int64, window time.Duration) (bool, float64, error) {
+	if window <= 0 {
+		return false, 0, fmt.Errorf("window should be positive")
+	}
+	data, err := c.call("allow", key, window.Seconds(), limit, n)
+	if err != nil {
+		return false, 0, err
+	}
+	if len(data) != 2 {
+		return false, 0, fmt.Errorf("unexpected response: %v", data)
+	}
+	ok, _ := data[0].(bool)
+	rate, err := toFloat64(data[1])
+	return ok, rate, err
+}
+
+// Purge removes expired counters and returns their number.
+func (c *Counters) Purge() (int64, error) {
+	data, err := c.call("purge")
+	if err != nil {
+		return 0, err
+	}
+	return toInt64(data)
+}
+
+func toFloat64(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case uint64:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("unexpected number type %T", v)
+}
+
+func toInt64(data []interface{}) (int64, error) {
+	if len(data) != 1 {
+		return 0, fmt.Errorf("unexpected response: %v", data)
+	}
+	f, err := toFloat64(data[0])
+	return int64(f), err
+}
@@ -0,0 +1,61 @@
+package counters_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/counters"
+)
+
+var server = "127.0.0.1:3013"
+var opts = Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+func TestCounters(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	if err = counters.Init(conn, ""); err != nil {
+		t.Errorf("Failed to init counters: %s", err.Error())
+		return
+	}
+	c := counters.New(conn, "")
+	defer c.Reset("test_counter")
+	defer c.Reset("test_window")
+
+	for i := int64(1); i <= 3; i++ {
+		v, err := c.Incr("test_counter", 2, 200*time.Millisecond)
+		if err != nil {
+			t.Errorf("Failed to incr: %s", err.Error())
+			return
+		}
+		if v != 2*i {
+			t.Errorf("Unexpected counter value %d", v)
+		}
+	}
+	time.Sleep(300 * time.Millisecond)
+	if v, err := c.Get("test_counter"); err != nil || v != 0 {
+		t.Errorf("Counter is not expired: %d, %v", v, err)
+	}
+	if n, err := c.Purge(); err != nil || n < 1 {
+		t.Errorf("Expired counter is not purged: %d, %v", n, err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if ok, _, err := c.Allow("test_window", 1, 3, time.Minute); err != nil || !ok {
+			t.Errorf("Event is not allowed within limit: %v, %v", ok, err)
+		}
+	}
+	ok, rate, err := c.Allow("test_window", 1, 3, time.Minute)
+	if err != nil || ok || rate != 3 {
+		t.Errorf("Event is allowed over limit: %v, %v, %v", ok, rate, err)
+	}
+}
@@ -0,0 +1,216 @@
+// Package debug provides HTTP handler exposing internals of connections
+// and pools as JSON for introspection of running applications:
+//
+//	h := debug.NewHandler()
+//	h.AddConnection("storage", conn)
+//	h.AddPool("routers", pool)
+//	http.Handle(debug.Path, h)
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/dump"
+	"github.com/tarantool/go-tarantool/multi"
+)
+
+// Path is a conventional path to mount Handler at.
+const Path = "/debug/tarantool"
+
+// Handler serves state of registered connections and pools as JSON. Query
+// parameter "name" limits output to connections and pools with the name.
+type Handler struct {
+	mutex sync.RWMutex
+	conns map[string]*tarantool.Connection
+	pools map[string]*multi.ConnectionMulti
+}
+
+// NewHandler creates Handler without connections.
+func NewHandler() *Handler {
+	return &Handler{
+		conns: make(map[string]*tarantool.Connection),
+		pools: make(map[string]*multi.ConnectionMulti),
+	}
+}
+
+// AddConnection registers connection under name.
+func (h *Handler) AddConnection(name string, conn *tarantool.Connection) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.conns[name] = conn
+}
+
+// AddPool registers pool under name.
+func (h *Handler) AddPool(name string, pool *multi.ConnectionMulti) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.pools[name] = pool
+}
+
+// Remove unregisters connection or pool with name.
+func (h *Handler) Remove(name string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.conns, name)
+	delete(h.pools, name)
+}
+
+// State is a state of all registered connections and pools.
+type State struct {
+	Connections map[string]ConnectionState `json:"connections"`
+	Pools       map[string]PoolState       `json:"pools"`
+}
+
+// ConnectionState is a state of connection.
+type ConnectionState struct {
+	Addr          string       `json:"addr"`
+	State         string       `json:"state"`
+	RemoteAddr    string       `json:"remote_addr,omitempty"`
+	LocalAddr     string       `json:"local_addr,omitempty"`
+	ServerVersion string       `json:"server_version,omitempty"`
+	Protocol      uint64       `json:"protocol_version"`
+	Features      []string     `json:"features"`
+	SessionId     uint64       `json:"session_id"`
+	SchemaVersion *uint        `json:"schema_version"`
+	Pending       PendingState `json:"pending"`
+}
+
+// PendingState is a summary of requests waiting for response.
+type PendingState struct {
+	Count  int            `json:"count"`
+	ByType map[string]int `json:"by_type"`
+	Oldest *OldestRequest `json:"oldest,omitempty"`
+}
+
+// OldestRequest describes the oldest request waiting for response.
+type OldestRequest struct {
+	RequestId uint32 `json:"request_id"`
+	Type      string `json:"type"`
+	SpaceNo   uint32 `json:"space_no,omitempty"`
+	AgeMs     int64  `json:"age_ms"`
+}
+
+// PoolState is a state of pool and its instances.
+type PoolState struct {
+	Ready     bool                     `json:"ready"`
+	Instances map[string]InstanceState `json:"instances"`
+}
+
+// InstanceState is a state of pool instance.
+type InstanceState struct {
+	ConnectionState
+	ReadOnly *bool               `json:"read_only,omitempty"`
+	Zone     string              `json:"zone,omitempty"`
+	Election *multi.ElectionInfo `json:"election,omitempty"`
+}
+
+// ConnectionStateOf returns state of connection.
+func ConnectionStateOf(conn *tarantool.Connection) ConnectionState {
+	info := conn.ConnectionInfo()
+	state := ConnectionState{
+		Addr:          conn.Addr(),
+		State:         conn.State().String(),
+		RemoteAddr:    conn.RemoteAddr(),
+		LocalAddr:     conn.LocalAddr(),
+		ServerVersion: info.ServerVersion,
+		Protocol:      info.ProtocolVersion,
+		Features:      []string{},
+		SessionId:     conn.SessionInfo().Id,
+	}
+	for _, f := range info.ServerProtocolInfo.Features {
+		state.Features = append(state.Features, f.String())
+	}
+	if schema := conn.Schema; schema != nil {
+		version := schema.Version
+		state.SchemaVersion = &version
+	}
+
+	pending := conn.PendingStats()
+	state.Pending = PendingState{
+		Count:  pending.Count,
+		ByType: make(map[string]int, len(pending.ByCode)),
+	}
+	for code, n := range pending.ByCode {
+		state.Pending.ByType[dump.RequestName(uint64(code))] += n
+	}
+	if oldest := pending.Oldest; oldest != nil {
+		state.Pending.Oldest = &OldestRequest{
+			RequestId: oldest.RequestId,
+			Type:      dump.RequestName(uint64(oldest.RequestCode)),
+			SpaceNo:   oldest.SpaceNo,
+			AgeMs:     int64(oldest.Age / time.Millisecond),
+		}
+	}
+	return state
+}
+
+// PoolStateOf returns state of pool.
+func PoolStateOf(pool *multi.ConnectionMulti) PoolState {
+	state := PoolState{
+		Ready:     pool.Ready(),
+		Instances: make(map[string]InstanceState),
+	}
+	for addr, conn := range pool.Instances() {
+		instance := InstanceState{
+			ConnectionState: ConnectionStateOf(conn),
+			Zone:            pool.Zone(addr),
+		}
+		if ro, ok := pool.ReadOnly(addr); ok {
+			instance.ReadOnly = &ro
+		}
+		if election, ok := pool.Election(addr); ok {
+			instance.Election = &election
+		}
+		state.Instances[addr] = instance
+	}
+	return state
+}
+
+// State returns state of registered connections and pools.
+func (h *Handler) State() State {
+	return h.state("")
+}
+
+func (h *Handler) state(name string) State {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	state := State{
+		Connections: make(map[string]ConnectionState),
+		Pools:       make(map[string]PoolState),
+	}
+	for n, conn := range h.conns {
+		if name == "" || n == name {
+			state.Connections[n] = ConnectionStateOf(conn)
+		}
+	}
+	for n, pool := range h.pools {
+		if name == "" || n == name {
+			state.Pools[n] = PoolStateOf(pool)
+		}
+	}
+	return state
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := strings.TrimSpace(r.URL.Query().Get("name"))
+	state := h.state(name)
+	if name != "" && len(state.Connections) == 0 && len(state.Pools) == 0 {
+		http.Error(w, "unknown name "+name, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(state)
+}
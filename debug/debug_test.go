@@ -0,0 +1,74 @@
+package debug_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/debug"
+)
+
+var server = "127.0.0.1:3013"
+var opts = tarantool.Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+func TestHandlerUnknownName(t *testing.T) {
+	h := debug.NewHandler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, debug.Path+"?name=none", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Unexpected status: %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, debug.Path, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Unexpected status: %d", rec.Code)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	conn, err := tarantool.Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	h := debug.NewHandler()
+	h.AddConnection("storage", conn)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + debug.Path + "?name=storage")
+	if err != nil {
+		t.Errorf("Failed to get state: %s", err.Error())
+		return
+	}
+	defer resp.Body.Close()
+	var state debug.State
+	if err = json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		t.Errorf("Failed to decode state: %s", err.Error())
+		return
+	}
+	storage, ok := state.Connections["storage"]
+	if !ok {
+		t.Errorf("No connection in state: %+v", state)
+		return
+	}
+	if storage.Addr != server || storage.State != "connected" || storage.SchemaVersion == nil ||
+		storage.SessionId == 0 || storage.Pending.Count != 0 {
+		t.Errorf("Unexpected connection state: %+v", storage)
+	}
+
+	h.Remove("storage")
+	if state = h.State(); len(state.Connections) != 0 {
+		t.Errorf("Connection is not removed: %+v", state)
+	}
+}
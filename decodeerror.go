@@ -0,0 +1,74 @@
+package tarantool
+
+import (
+	"fmt"
+)
+
+// DecodeErrorPolicy defines what happens to Connection when a response
+// can't be decoded.
+type DecodeErrorPolicy int
+
+const (
+	// DecodeFailRequest fails only the request the response belongs to.
+	DecodeFailRequest DecodeErrorPolicy = iota
+	// DecodeFailConnection also reconnects connection (or closes it, if
+	// Opts.Reconnect is not set), so other requests in flight are aborted.
+	DecodeFailConnection
+)
+
+// DecodeError is returned when response can't be decoded, e.g. it is
+// malformed or does not match result passed to GetTyped.
+type DecodeError struct {
+	// RequestId is an id of request, it is zero if response header
+	// can't be decoded.
+	RequestId uint32
+	// RequestCode is a type of request.
+	RequestCode int32
+	// Err is an error of decoder.
+	Err error
+	// Body is a raw response, it is handy to diagnose serializer
+	// mismatches.
+	Body []byte
+}
+
+func (decerr DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode response %d: %s, body: %x", decerr.RequestId, decerr.Err, decerr.Body)
+}
+
+// decodeError wraps error of response decoding into DecodeError and
+// applies Opts.DecodeErrorPolicy. Errors returned by Tarantool are kept
+// as is.
+func (fut *Future) decodeError(body []byte, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(Error); ok {
+		return err
+	}
+	decerr := DecodeError{
+		RequestId:   fut.requestId,
+		RequestCode: fut.requestCode,
+		Err:         err,
+		Body:        body,
+	}
+	if fut.conn != nil {
+		fut.conn.onDecodeError(decerr)
+	}
+	return decerr
+}
+
+func (conn *Connection) onDecodeError(decerr DecodeError) {
+	if conn.opts.OnDecodeError != nil {
+		conn.opts.OnDecodeError(conn, decerr)
+	}
+	if conn.opts.DecodeErrorPolicy != DecodeFailConnection {
+		return
+	}
+	conn.mutex.Lock()
+	c := conn.c
+	conn.mutex.Unlock()
+	if c != nil {
+		// reconnect may wait for Opts.Reconnect, so caller is not blocked
+		go conn.reconnect(decerr, c)
+	}
+}
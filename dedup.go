@@ -0,0 +1,154 @@
+package tarantool
+
+import (
+	"sync"
+
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// Dedup is an opt-in layer over Connection that shares one request between
+// identical concurrent read requests, so hot keys do not produce a
+// thundering herd of equal requests.
+//
+// Requests are identical if they have same request code and same encoded
+// body. Each caller gets its own Future filled with a copy of the shared
+// response.
+//
+// Only requests that do not modify data should be sent through Dedup.
+// It is up to user to guarantee that called functions are read-only.
+type Dedup struct {
+	conn     *Connection
+	mutex    sync.Mutex
+	inflight map[string]*dedupGroup
+}
+
+type dedupGroup struct {
+	futures []*Future
+}
+
+// NewDedup creates deduplication layer over conn.
+func NewDedup(conn *Connection) *Dedup {
+	return &Dedup{
+		conn:     conn,
+		inflight: make(map[string]*dedupGroup),
+	}
+}
+
+// Select performs select to box space.
+//
+// It is equal to d.SelectAsync(...).Get()
+func (d *Dedup) Select(space, index interface{}, offset, limit, iterator uint32, key interface{}) (resp *Response, err error) {
+	return d.SelectAsync(space, index, offset, limit, iterator, key).Get()
+}
+
+// SelectTyped performs select to box space and fills typed result.
+//
+// It is equal to d.SelectAsync(space, index, offset, limit, iterator, key).GetTyped(&result)
+func (d *Dedup) SelectTyped(space, index interface{}, offset, limit, iterator uint32, key interface{}, result interface{}) (err error) {
+	return d.SelectAsync(space, index, offset, limit, iterator, key).GetTyped(result)
+}
+
+// GetTyped performs select (with limit = 1 and offset = 0)
+// to box space and fills typed result.
+func (d *Dedup) GetTyped(space, index interface{}, key interface{}, result interface{}) (err error) {
+	s := single{res: result}
+	return d.SelectAsync(space, index, 0, 1, IterEq, key).GetTyped(&s)
+}
+
+// Call calls registered read-only tarantool function.
+//
+// It is equal to d.CallAsync(functionName, args).Get().
+func (d *Dedup) Call(functionName string, args interface{}) (resp *Response, err error) {
+	return d.CallAsync(functionName, args).Get()
+}
+
+// CallTyped calls registered read-only function.
+//
+// It is equal to d.CallAsync(functionName, args).GetTyped(&result).
+func (d *Dedup) CallTyped(functionName string, args interface{}, result interface{}) (err error) {
+	return d.CallAsync(functionName, args).GetTyped(result)
+}
+
+// Call17 calls registered read-only tarantool function.
+//
+// It is equal to d.Call17Async(functionName, args).Get().
+func (d *Dedup) Call17(functionName string, args interface{}) (resp *Response, err error) {
+	return d.Call17Async(functionName, args).Get()
+}
+
+// Call17Typed calls registered read-only function.
+//
+// It is equal to d.Call17Async(functionName, args).GetTyped(&result).
+func (d *Dedup) Call17Typed(functionName string, args interface{}, result interface{}) (err error) {
+	return d.Call17Async(functionName, args).GetTyped(result)
+}
+
+// SelectAsync sends select request to tarantool or joins identical request
+// in flight, and returns Future.
+func (d *Dedup) SelectAsync(space, index interface{}, offset, limit, iterator uint32, key interface{}) *Future {
+	ref, err := d.conn.selectRef(space, index, iterator)
+	if err != nil {
+		return d.conn.newFuture(SelectRequest, ref.spaceNo).fail(d.conn, err)
+	}
+	return d.do(SelectRequest, ref.spaceNo, "", selectRefBody(ref, offset, limit, iterator, key))
+}
+
+// CallAsync sends a call to registered read-only function or joins
+// identical call in flight, and returns Future.
+func (d *Dedup) CallAsync(functionName string, args interface{}) *Future {
+	return d.do(CallRequest, 0, functionName, callBody(functionName, args))
+}
+
+// Call17Async sends a call to registered read-only function or joins
+// identical call in flight, and returns Future.
+func (d *Dedup) Call17Async(functionName string, args interface{}) *Future {
+	requestCode, body := d.conn.call17Body(functionName, args)
+	return d.do(requestCode, 0, functionName, body)
+}
+
+func (d *Dedup) do(requestCode int32, spaceNo uint32, function string, body func(*msgpack.Encoder) error) *Future {
+	packet, err := encodeBody(body)
+	if err != nil {
+		return d.conn.newFuture(requestCode, spaceNo).fail(d.conn, err)
+	}
+	key := string(append([]byte{byte(requestCode)}, packet...))
+
+	fut := &Future{
+		requestCode: requestCode,
+		spaceNo:     spaceNo,
+		ready:       make(chan struct{}),
+		conn:        d.conn,
+	}
+	d.mutex.Lock()
+	if group, ok := d.inflight[key]; ok {
+		group.futures = append(group.futures, fut)
+		d.mutex.Unlock()
+		return fut
+	}
+	group := &dedupGroup{futures: []*Future{fut}}
+	d.inflight[key] = group
+	d.mutex.Unlock()
+
+	shared := d.conn.newFutureFor(requestCode, spaceNo, function)
+	shared.send(d.conn, rawBody(packet))
+	go d.resolve(key, group, shared)
+	return fut
+}
+
+func (d *Dedup) resolve(key string, group *dedupGroup, shared *Future) {
+	shared.wait()
+	d.mutex.Lock()
+	delete(d.inflight, key)
+	futures := group.futures
+	d.mutex.Unlock()
+	for _, fut := range futures {
+		fut.requestId = shared.requestId
+		fut.err = shared.err
+		if shared.resp != nil {
+			// body is not decoded yet, so each copy decodes it on its own
+			resp := *shared.resp
+			fut.resp = &resp
+		}
+		close(fut.ready)
+	}
+}
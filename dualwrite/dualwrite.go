@@ -0,0 +1,342 @@
+// Package dualwrite mirrors writes to a secondary Tarantool cluster.
+//
+// It supports live migrations between clusters driven from the Go client:
+// all requests are served by the primary connector, and data modification
+// requests are also sent to the secondary connector, either synchronously
+// or asynchronously.
+package dualwrite
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// Mode defines how writes are mirrored to secondary.
+type Mode int
+
+const (
+	// Async mirrors writes in background, caller waits for primary only.
+	Async Mode = iota
+	// Sync mirrors writes before returning to caller.
+	Sync
+)
+
+// ErrorPolicy defines what happens when mirrored write fails.
+type ErrorPolicy int
+
+const (
+	// IgnoreErrors counts secondary errors and reports them to OnError.
+	IgnoreErrors ErrorPolicy = iota
+	// FailOnError returns secondary error to caller in Sync mode.
+	// In Async mode it is the same as IgnoreErrors.
+	FailOnError
+)
+
+var ErrQueueFull = errors.New("dualwrite: queue of mirrored writes is full")
+
+// Opts is a way to configure DualWriter.
+type Opts struct {
+	Mode        Mode
+	ErrorPolicy ErrorPolicy
+	// QueueSize is a maximum number of pending mirrored writes in Async
+	// mode. Writes beyond it are dropped and reported with ErrQueueFull.
+	// By default, it is 1024.
+	QueueSize int
+	// OnError is called for every failed or dropped mirrored write.
+	OnError func(err error)
+}
+
+// Stats contains counters of mirrored writes.
+type Stats struct {
+	// Mirrored is a number of successfully mirrored writes.
+	Mirrored uint64
+	// Failed is a number of mirrored writes failed on secondary.
+	Failed uint64
+	// Dropped is a number of writes dropped due to full queue.
+	Dropped uint64
+	// Pending is a number of writes waiting to be mirrored.
+	Pending int
+	// Lag is a delay between primary and secondary write of the last
+	// mirrored write.
+	Lag time.Duration
+}
+
+// DualWriter is a Connector that sends all requests to primary and
+// mirrors data modification requests to secondary.
+//
+// Async* methods always mirror writes in background regardless of Mode.
+type DualWriter struct {
+	primary   tarantool.Connector
+	secondary tarantool.Connector
+	opts      Opts
+
+	mutex    sync.RWMutex
+	closed   bool
+	queue    chan mirrorTask
+	wg       sync.WaitGroup
+	mirrored uint64
+	failed   uint64
+	dropped  uint64
+	lag      int64
+}
+
+var _ = tarantool.Connector(&DualWriter{}) // check compatibility with connector interface
+
+type mirrorTask struct {
+	req   func(tarantool.Connector) *tarantool.Future
+	start time.Time
+	// primary is a future of write on primary for writes which are
+	// queued before it is done, write is mirrored only if it succeeds
+	primary *tarantool.Future
+}
+
+// New creates DualWriter over primary and secondary connectors.
+func New(primary, secondary tarantool.Connector, opts Opts) *DualWriter {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1024
+	}
+	dw := &DualWriter{
+		primary:   primary,
+		secondary: secondary,
+		opts:      opts,
+		queue:     make(chan mirrorTask, opts.QueueSize),
+	}
+	dw.wg.Add(1)
+	go dw.worker()
+	return dw
+}
+
+// Stats returns counters of mirrored writes.
+func (dw *DualWriter) Stats() Stats {
+	return Stats{
+		Mirrored: atomic.LoadUint64(&dw.mirrored),
+		Failed:   atomic.LoadUint64(&dw.failed),
+		Dropped:  atomic.LoadUint64(&dw.dropped),
+		Pending:  len(dw.queue),
+		Lag:      time.Duration(atomic.LoadInt64(&dw.lag)),
+	}
+}
+
+func (dw *DualWriter) worker() {
+	defer dw.wg.Done()
+	for task := range dw.queue {
+		if task.primary != nil && task.primary.Err() != nil {
+			continue
+		}
+		dw.apply(task)
+	}
+}
+
+func (dw *DualWriter) apply(task mirrorTask) error {
+	_, err := task.req(dw.secondary).Get()
+	atomic.StoreInt64(&dw.lag, int64(time.Since(task.start)))
+	if err != nil {
+		atomic.AddUint64(&dw.failed, 1)
+		if dw.opts.OnError != nil {
+			dw.opts.OnError(err)
+		}
+		return err
+	}
+	atomic.AddUint64(&dw.mirrored, 1)
+	return nil
+}
+
+// mirrorAsync queues write to be mirrored, writes are mirrored in order
+// they are queued.
+func (dw *DualWriter) mirrorAsync(req func(tarantool.Connector) *tarantool.Future, primary *tarantool.Future) {
+	dw.mutex.RLock()
+	defer dw.mutex.RUnlock()
+	if dw.closed {
+		return
+	}
+	select {
+	case dw.queue <- mirrorTask{req: req, start: time.Now(), primary: primary}:
+	default:
+		atomic.AddUint64(&dw.dropped, 1)
+		if dw.opts.OnError != nil {
+			dw.opts.OnError(ErrQueueFull)
+		}
+	}
+}
+
+// write performs write on primary and mirrors it according to Mode.
+func (dw *DualWriter) write(req func(tarantool.Connector) *tarantool.Future, get func(*tarantool.Future) error) error {
+	if err := get(req(dw.primary)); err != nil {
+		return err
+	}
+	if dw.opts.Mode == Async {
+		dw.mirrorAsync(req, nil)
+		return nil
+	}
+	err := dw.apply(mirrorTask{req: req, start: time.Now()})
+	if dw.opts.ErrorPolicy == FailOnError {
+		return err
+	}
+	return nil
+}
+
+func (dw *DualWriter) writeResp(req func(tarantool.Connector) *tarantool.Future) (resp *tarantool.Response, err error) {
+	err = dw.write(req, func(fut *tarantool.Future) (err error) {
+		resp, err = fut.Get()
+		return
+	})
+	return
+}
+
+func (dw *DualWriter) writeTyped(req func(tarantool.Connector) *tarantool.Future, result interface{}) error {
+	return dw.write(req, func(fut *tarantool.Future) error {
+		return fut.GetTyped(result)
+	})
+}
+
+// writeAsync queues write right after it is sent to primary, so writes
+// reach secondary in order they are sent to primary.
+func (dw *DualWriter) writeAsync(req func(tarantool.Connector) *tarantool.Future) *tarantool.Future {
+	fut := req(dw.primary)
+	dw.mirrorAsync(req, fut)
+	return fut
+}
+
+// ConnectedNow reports if primary is connected.
+func (dw *DualWriter) ConnectedNow() bool {
+	return dw.primary.ConnectedNow()
+}
+
+// Close waits for pending mirrored writes and closes both connectors.
+func (dw *DualWriter) Close() (err error) {
+	dw.mutex.Lock()
+	if !dw.closed {
+		dw.closed = true
+		close(dw.queue)
+	}
+	dw.mutex.Unlock()
+	dw.wg.Wait()
+	err = dw.primary.Close()
+	if serr := dw.secondary.Close(); err == nil {
+		err = serr
+	}
+	return
+}
+
+func (dw *DualWriter) Ping() (resp *tarantool.Response, err error) {
+	return dw.primary.Ping()
+}
+
+func (dw *DualWriter) ConfiguredTimeout() time.Duration {
+	return dw.primary.ConfiguredTimeout()
+}
+
+func (dw *DualWriter) Select(space, index interface{}, offset, limit, iterator uint32, key interface{}) (resp *tarantool.Response, err error) {
+	return dw.primary.Select(space, index, offset, limit, iterator, key)
+}
+
+func (dw *DualWriter) Insert(space interface{}, tuple interface{}) (resp *tarantool.Response, err error) {
+	return dw.writeResp(func(c tarantool.Connector) *tarantool.Future { return c.InsertAsync(space, tuple) })
+}
+
+func (dw *DualWriter) Replace(space interface{}, tuple interface{}) (resp *tarantool.Response, err error) {
+	return dw.writeResp(func(c tarantool.Connector) *tarantool.Future { return c.ReplaceAsync(space, tuple) })
+}
+
+func (dw *DualWriter) Delete(space, index interface{}, key interface{}) (resp *tarantool.Response, err error) {
+	return dw.writeResp(func(c tarantool.Connector) *tarantool.Future { return c.DeleteAsync(space, index, key) })
+}
+
+func (dw *DualWriter) Update(space, index interface{}, key, ops interface{}) (resp *tarantool.Response, err error) {
+	return dw.writeResp(func(c tarantool.Connector) *tarantool.Future { return c.UpdateAsync(space, index, key, ops) })
+}
+
+func (dw *DualWriter) Upsert(space interface{}, tuple, ops interface{}) (resp *tarantool.Response, err error) {
+	return dw.writeResp(func(c tarantool.Connector) *tarantool.Future { return c.UpsertAsync(space, tuple, ops) })
+}
+
+// Call is not mirrored, since it is not known if function modifies data.
+func (dw *DualWriter) Call(functionName string, args interface{}) (resp *tarantool.Response, err error) {
+	return dw.primary.Call(functionName, args)
+}
+
+// Call17 is not mirrored, since it is not known if function modifies data.
+func (dw *DualWriter) Call17(functionName string, args interface{}) (resp *tarantool.Response, err error) {
+	return dw.primary.Call17(functionName, args)
+}
+
+// Eval is not mirrored, since it is not known if expression modifies data.
+func (dw *DualWriter) Eval(expr string, args interface{}) (resp *tarantool.Response, err error) {
+	return dw.primary.Eval(expr, args)
+}
+
+func (dw *DualWriter) GetTyped(space, index interface{}, key interface{}, result interface{}) (err error) {
+	return dw.primary.GetTyped(space, index, key, result)
+}
+
+func (dw *DualWriter) SelectTyped(space, index interface{}, offset, limit, iterator uint32, key interface{}, result interface{}) (err error) {
+	return dw.primary.SelectTyped(space, index, offset, limit, iterator, key, result)
+}
+
+func (dw *DualWriter) InsertTyped(space interface{}, tuple interface{}, result interface{}) (err error) {
+	return dw.writeTyped(func(c tarantool.Connector) *tarantool.Future { return c.InsertAsync(space, tuple) }, result)
+}
+
+func (dw *DualWriter) ReplaceTyped(space interface{}, tuple interface{}, result interface{}) (err error) {
+	return dw.writeTyped(func(c tarantool.Connector) *tarantool.Future { return c.ReplaceAsync(space, tuple) }, result)
+}
+
+func (dw *DualWriter) DeleteTyped(space, index interface{}, key interface{}, result interface{}) (err error) {
+	return dw.writeTyped(func(c tarantool.Connector) *tarantool.Future { return c.DeleteAsync(space, index, key) }, result)
+}
+
+func (dw *DualWriter) UpdateTyped(space, index interface{}, key, ops interface{}, result interface{}) (err error) {
+	return dw.writeTyped(func(c tarantool.Connector) *tarantool.Future { return c.UpdateAsync(space, index, key, ops) }, result)
+}
+
+func (dw *DualWriter) CallTyped(functionName string, args interface{}, result interface{}) (err error) {
+	return dw.primary.CallTyped(functionName, args, result)
+}
+
+func (dw *DualWriter) Call17Typed(functionName string, args interface{}, result interface{}) (err error) {
+	return dw.primary.Call17Typed(functionName, args, result)
+}
+
+func (dw *DualWriter) EvalTyped(expr string, args interface{}, result interface{}) (err error) {
+	return dw.primary.EvalTyped(expr, args, result)
+}
+
+func (dw *DualWriter) SelectAsync(space, index interface{}, offset, limit, iterator uint32, key interface{}) *tarantool.Future {
+	return dw.primary.SelectAsync(space, index, offset, limit, iterator, key)
+}
+
+func (dw *DualWriter) InsertAsync(space interface{}, tuple interface{}) *tarantool.Future {
+	return dw.writeAsync(func(c tarantool.Connector) *tarantool.Future { return c.InsertAsync(space, tuple) })
+}
+
+func (dw *DualWriter) ReplaceAsync(space interface{}, tuple interface{}) *tarantool.Future {
+	return dw.writeAsync(func(c tarantool.Connector) *tarantool.Future { return c.ReplaceAsync(space, tuple) })
+}
+
+func (dw *DualWriter) DeleteAsync(space, index interface{}, key interface{}) *tarantool.Future {
+	return dw.writeAsync(func(c tarantool.Connector) *tarantool.Future { return c.DeleteAsync(space, index, key) })
+}
+
+func (dw *DualWriter) UpdateAsync(space, index interface{}, key, ops interface{}) *tarantool.Future {
+	return dw.writeAsync(func(c tarantool.Connector) *tarantool.Future { return c.UpdateAsync(space, index, key, ops) })
+}
+
+func (dw *DualWriter) UpsertAsync(space interface{}, tuple interface{}, ops interface{}) *tarantool.Future {
+	return dw.writeAsync(func(c tarantool.Connector) *tarantool.Future { return c.UpsertAsync(space, tuple, ops) })
+}
+
+func (dw *DualWriter) CallAsync(functionName string, args interface{}) *tarantool.Future {
+	return dw.primary.CallAsync(functionName, args)
+}
+
+func (dw *DualWriter) Call17Async(functionName string, args interface{}) *tarantool.Future {
+	return dw.primary.Call17Async(functionName, args)
+}
+
+func (dw *DualWriter) EvalAsync(expr string, args interface{}) *tarantool.Future {
+	return dw.primary.EvalAsync(expr, args)
+}
@@ -0,0 +1,115 @@
+package dualwrite
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+var server = "127.0.0.1:3013"
+var spaceNo = uint32(512)
+var indexNo = uint32(0)
+var connOpts = tarantool.Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+func connect(t *testing.T) (primary, secondary *tarantool.Connection) {
+	var err error
+	if primary, err = tarantool.Connect(server, connOpts); err != nil {
+		t.Fatalf("Failed to connect: %s", err.Error())
+	}
+	if secondary, err = tarantool.Connect(server, connOpts); err != nil {
+		primary.Close()
+		t.Fatalf("Failed to connect: %s", err.Error())
+	}
+	return
+}
+
+func TestSyncFailOnError(t *testing.T) {
+	primary, secondary := connect(t)
+	dw := New(primary, secondary, Opts{Mode: Sync, ErrorPolicy: FailOnError})
+	defer dw.Close()
+
+	dw.Delete(spaceNo, indexNo, []interface{}{uint(2001)})
+	// Both connectors point to the same instance, so the mirrored insert
+	// fails with duplicate key.
+	_, err := dw.Insert(spaceNo, []interface{}{uint(2001), "hello", "world"})
+	if tntErr, ok := err.(tarantool.Error); !ok || tntErr.Code != tarantool.ErrTupleFound {
+		t.Errorf("Expected ErrTupleFound from secondary but got: %v", err)
+	}
+	if stats := dw.Stats(); stats.Failed != 1 || stats.Mirrored != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestAsync(t *testing.T) {
+	primary, secondary := connect(t)
+	var errs []error
+	dw := New(primary, secondary, Opts{
+		Mode:    Async,
+		OnError: func(err error) { errs = append(errs, err) },
+	})
+
+	for i := 0; i < 10; i++ {
+		_, err := dw.Replace(spaceNo, []interface{}{uint(2002), "hello", "world"})
+		if err != nil {
+			t.Errorf("Failed to Replace: %s", err.Error())
+		}
+	}
+	if err := dw.ReplaceAsync(spaceNo, []interface{}{uint(2002), "hello", "world"}).Err(); err != nil {
+		t.Errorf("Failed to ReplaceAsync: %s", err.Error())
+	}
+	time.Sleep(100 * time.Millisecond)
+	dw.Close()
+
+	if stats := dw.Stats(); stats.Mirrored != 11 || stats.Pending != 0 || stats.Lag == 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if len(errs) != 0 {
+		t.Errorf("Unexpected mirroring errors: %v", errs)
+	}
+}
+
+// recordingConnector records tuples of replaces in order they are sent.
+type recordingConnector struct {
+	tarantool.Connector
+	mutex  sync.Mutex
+	tuples []interface{}
+}
+
+func (c *recordingConnector) ReplaceAsync(space interface{}, tuple interface{}) *tarantool.Future {
+	c.mutex.Lock()
+	c.tuples = append(c.tuples, tuple)
+	c.mutex.Unlock()
+	return c.Connector.ReplaceAsync(space, tuple)
+}
+
+func TestAsyncOrder(t *testing.T) {
+	primary, secondary := connect(t)
+	recorder := &recordingConnector{Connector: secondary}
+	dw := New(primary, recorder, Opts{Mode: Async})
+
+	futs := make([]*tarantool.Future, 0, 50)
+	for i := 0; i < 50; i++ {
+		futs = append(futs, dw.ReplaceAsync(spaceNo, []interface{}{uint(2003), "hello", i}))
+	}
+	for _, fut := range futs {
+		if err := fut.Err(); err != nil {
+			t.Errorf("Failed to ReplaceAsync: %s", err.Error())
+		}
+	}
+	dw.Close()
+
+	if len(recorder.tuples) != 50 {
+		t.Fatalf("Unexpected number of mirrored writes: %d", len(recorder.tuples))
+	}
+	for i, tuple := range recorder.tuples {
+		if tuple.([]interface{})[2] != i {
+			t.Errorf("Write %d is mirrored at position %d", tuple.([]interface{})[2], i)
+		}
+	}
+}
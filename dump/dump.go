@@ -0,0 +1,216 @@
+// Package dump renders iproto packets and responses in human-readable form
+// with names of iproto keys, request types and iterators, e.g.:
+//
+//	{IPROTO_REQUEST_TYPE: SELECT, IPROTO_SYNC: 1} {IPROTO_SPACE_ID: 512, IPROTO_ITERATOR: EQ, IPROTO_KEY: [1]}
+//
+// It is intended for debug logs and bug reports.
+package dump
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+var keyNames = map[uint64]string{
+	tarantool.KeyCode:         "IPROTO_REQUEST_TYPE",
+	tarantool.KeySync:         "IPROTO_SYNC",
+	tarantool.KeySpaceNo:      "IPROTO_SPACE_ID",
+	tarantool.KeyIndexNo:      "IPROTO_INDEX_ID",
+	tarantool.KeyLimit:        "IPROTO_LIMIT",
+	tarantool.KeyOffset:       "IPROTO_OFFSET",
+	tarantool.KeyIterator:     "IPROTO_ITERATOR",
+	tarantool.KeyKey:          "IPROTO_KEY",
+	tarantool.KeyTuple:        "IPROTO_TUPLE",
+	tarantool.KeyFunctionName: "IPROTO_FUNCTION_NAME",
+	tarantool.KeyUserName:     "IPROTO_USER_NAME",
+	tarantool.KeyExpression:   "IPROTO_EXPR",
+	tarantool.KeyDefTuple:     "IPROTO_OPS",
+	tarantool.KeyData:         "IPROTO_DATA",
+	tarantool.KeyError:        "IPROTO_ERROR",
+	tarantool.KeyVersion:      "IPROTO_VERSION",
+	tarantool.KeyFeatures:     "IPROTO_FEATURES",
+	tarantool.KeySpaceName:    "IPROTO_SPACE_NAME",
+	tarantool.KeyIndexName:    "IPROTO_INDEX_NAME",
+	tarantool.KeyTupleFormats: "IPROTO_TUPLE_FORMATS",
+}
+
+var requestNames = map[uint64]string{
+	tarantool.SelectRequest:    "SELECT",
+	tarantool.InsertRequest:    "INSERT",
+	tarantool.ReplaceRequest:   "REPLACE",
+	tarantool.UpdateRequest:    "UPDATE",
+	tarantool.DeleteRequest:    "DELETE",
+	tarantool.CallRequest:      "CALL_16",
+	tarantool.AuthRequest:      "AUTH",
+	tarantool.EvalRequest:      "EVAL",
+	tarantool.UpsertRequest:    "UPSERT",
+	tarantool.Call17Request:    "CALL",
+	tarantool.PingRequest:      "PING",
+	tarantool.SubscribeRequest: "SUBSCRIBE",
+	tarantool.IdRequest:        "ID",
+}
+
+var iteratorNames = map[uint64]string{
+	uint64(tarantool.IterEq):            "EQ",
+	uint64(tarantool.IterReq):           "REQ",
+	uint64(tarantool.IterAll):           "ALL",
+	uint64(tarantool.IterLt):            "LT",
+	uint64(tarantool.IterLe):            "LE",
+	uint64(tarantool.IterGe):            "GE",
+	uint64(tarantool.IterGt):            "GT",
+	uint64(tarantool.IterBitsAllSet):    "BITS_ALL_SET",
+	uint64(tarantool.IterBitsAnySet):    "BITS_ANY_SET",
+	uint64(tarantool.IterBitsAllNotSet): "BITS_ALL_NOT_SET",
+	uint64(tarantool.IterOverlaps):      "OVERLAPS",
+	uint64(tarantool.IterNeighbor):      "NEIGHBOR",
+}
+
+// KeyName returns name of iproto key.
+func KeyName(key uint64) string {
+	if name, ok := keyNames[key]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%x", key)
+}
+
+// RequestName returns name of request type or response code. Names of
+// custom request types are taken from tarantool.RegisterRequestType.
+func RequestName(code uint64) string {
+	if name, ok := requestNames[code]; ok {
+		return name
+	}
+	if typ, ok := tarantool.LookupRequestType(int32(code)); ok && typ.Name != "" {
+		return typ.Name
+	}
+	if code == uint64(tarantool.OkCode) {
+		return "OK"
+	}
+	if code&tarantool.ErrorCodeBit != 0 {
+		return fmt.Sprintf("ERROR(%d)", code&^tarantool.ErrorCodeBit)
+	}
+	return fmt.Sprintf("0x%x", code)
+}
+
+// Packet renders iproto packet: header and body without length prefix.
+func Packet(packet []byte) (string, error) {
+	var sb strings.Builder
+	d := msgpack.NewDecoder(bytes.NewReader(packet))
+	if err := writeMap(&sb, d); err != nil {
+		return sb.String(), err
+	}
+	sb.WriteByte(' ')
+	if err := writeMap(&sb, d); err != nil {
+		if err == io.EOF {
+			// packet without body
+			return strings.TrimSuffix(sb.String(), " "), nil
+		}
+		return sb.String(), err
+	}
+	return sb.String(), nil
+}
+
+// Response renders response header and data.
+func Response(resp *tarantool.Response) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "{%s: %s, %s: %d}", KeyName(tarantool.KeyCode),
+		RequestName(uint64(resp.Code)), KeyName(tarantool.KeySync), resp.RequestId)
+	if resp.Code != tarantool.OkCode {
+		fmt.Fprintf(&sb, " {%s: %q}", KeyName(tarantool.KeyError), resp.Error)
+	} else {
+		fmt.Fprintf(&sb, " {%s: ", KeyName(tarantool.KeyData))
+		writeValue(&sb, resp.Data)
+		sb.WriteByte('}')
+	}
+	return sb.String()
+}
+
+// writeMap renders iproto map with integer keys.
+func writeMap(sb *strings.Builder, d *msgpack.Decoder) error {
+	l, err := d.DecodeMapLen()
+	if err != nil {
+		return err
+	}
+	sb.WriteByte('{')
+	for i := 0; i < l; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		key, err := d.DecodeUint64()
+		if err != nil {
+			return err
+		}
+		value, err := d.DecodeInterface()
+		if err != nil {
+			return err
+		}
+		sb.WriteString(KeyName(key))
+		sb.WriteString(": ")
+		if n, ok := toUint64(value); ok && key == tarantool.KeyCode {
+			sb.WriteString(RequestName(n))
+		} else if name, ok := iteratorNames[n]; ok && key == tarantool.KeyIterator {
+			sb.WriteString(name)
+		} else {
+			writeValue(sb, value)
+		}
+	}
+	sb.WriteByte('}')
+	return nil
+}
+
+func toUint64(v interface{}) (uint64, bool) {
+	switch v := v.(type) {
+	case uint64:
+		return v, true
+	case int64:
+		return uint64(v), v >= 0
+	}
+	return 0, false
+}
+
+func writeValue(sb *strings.Builder, v interface{}) {
+	switch v := v.(type) {
+	case string:
+		fmt.Fprintf(sb, "%q", v)
+	case []byte:
+		fmt.Fprintf(sb, "0x%x", v)
+	case []interface{}:
+		sb.WriteByte('[')
+		for i := range v {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			writeValue(sb, v[i])
+		}
+		sb.WriteByte(']')
+	case map[interface{}]interface{}:
+		keys := make([]string, 0, len(v))
+		values := make(map[string]interface{}, len(v))
+		for k := range v {
+			var ks strings.Builder
+			writeValue(&ks, k)
+			keys = append(keys, ks.String())
+			values[ks.String()] = v[k]
+		}
+		sort.Strings(keys)
+		sb.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(k)
+			sb.WriteString(": ")
+			writeValue(sb, values[k])
+		}
+		sb.WriteByte('}')
+	case nil:
+		sb.WriteString("nil")
+	default:
+		fmt.Fprintf(sb, "%v", v)
+	}
+}
@@ -0,0 +1,53 @@
+package dump_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/dump"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+func TestPacket(t *testing.T) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.EncodeMapLen(2)
+	enc.EncodeUint64(tarantool.KeyCode)
+	enc.EncodeUint64(tarantool.SelectRequest)
+	enc.EncodeUint64(tarantool.KeySync)
+	enc.EncodeUint64(5)
+	enc.EncodeMapLen(3)
+	enc.EncodeUint64(tarantool.KeySpaceNo)
+	enc.EncodeUint64(512)
+	enc.EncodeUint64(tarantool.KeyIterator)
+	enc.EncodeUint64(uint64(tarantool.IterGe))
+	enc.EncodeUint64(tarantool.KeyKey)
+	enc.Encode([]interface{}{1, "a"})
+
+	s, err := dump.Packet(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to render packet: %s", err.Error())
+	}
+	expected := `{IPROTO_REQUEST_TYPE: SELECT, IPROTO_SYNC: 5} ` +
+		`{IPROTO_SPACE_ID: 512, IPROTO_ITERATOR: GE, IPROTO_KEY: [1, "a"]}`
+	if s != expected {
+		t.Errorf("Unexpected packet:\n%s\nexpected:\n%s", s, expected)
+	}
+}
+
+func TestResponse(t *testing.T) {
+	resp := &tarantool.Response{RequestId: 3, Data: []interface{}{
+		[]interface{}{uint64(1), map[interface{}]interface{}{"b": nil, "a": []byte{1}}},
+	}}
+	expected := `{IPROTO_REQUEST_TYPE: OK, IPROTO_SYNC: 3} {IPROTO_DATA: [[1, {"a": 0x01, "b": nil}]]}`
+	if s := dump.Response(resp); s != expected {
+		t.Errorf("Unexpected response:\n%s\nexpected:\n%s", s, expected)
+	}
+
+	resp = &tarantool.Response{RequestId: 4, Code: tarantool.ErrorCodeBit | 2, Error: "oops"}
+	expected = `{IPROTO_REQUEST_TYPE: ERROR(2), IPROTO_SYNC: 4} {IPROTO_ERROR: "oops"}`
+	if s := dump.Response(resp); s != expected {
+		t.Errorf("Unexpected error response:\n%s\nexpected:\n%s", s, expected)
+	}
+}
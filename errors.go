@@ -29,10 +29,11 @@ func (clierr ClientError) Error() string {
 // Currently it returns true when:
 // - Connection is not connected at the moment,
 // - or request is timeouted,
-// - or request is aborted due to rate limit.
+// - or request is aborted due to rate limit,
+// - or memory limit is exceeded.
 func (clierr ClientError) Temporary() bool {
 	switch clierr.Code {
-	case ErrConnectionNotReady, ErrTimeouted, ErrRateLimited:
+	case ErrConnectionNotReady, ErrTimeouted, ErrRateLimited, ErrMemoryLimit:
 		return true
 	default:
 		return false
@@ -46,6 +47,8 @@ const (
 	ErrProtocolError      = 0x4000 + iota
 	ErrTimeouted          = 0x4000 + iota
 	ErrRateLimited        = 0x4000 + iota
+	ErrFeatureUnsupported = 0x4000 + iota
+	ErrMemoryLimit        = 0x4000 + iota
 )
 
 // Tarantool server error codes
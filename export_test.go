@@ -1,5 +1,7 @@
 package tarantool
 
-func (schema *Schema) ResolveSpaceIndex(s interface{}, i interface{}) (spaceNo, indexNo uint32, err error) {
-	return schema.resolveSpaceIndex(s, i)
+// GreetingVersionAtLeast reports whether Tarantool version of greeting is
+// at least major.minor.patch.
+func GreetingVersionAtLeast(greeting string, major, minor, patch uint32) bool {
+	return parseGreetingVersion(greeting) >= makeVersion(major, minor, patch)
 }
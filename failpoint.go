@@ -0,0 +1,39 @@
+package tarantool
+
+// Failpoint is a point in the client where a failure may be injected in
+// tests. Failpoints are compiled in only with build tag
+// tarantool_failpoints and are controlled with EnableFailpoint:
+//
+//	go test -tags tarantool_failpoints ./...
+//
+// Without the tag they cost nothing and can't be enabled.
+type Failpoint int
+
+const (
+	// FailpointEncode fails encoding of a request, the request fails with
+	// the injected error and is not sent.
+	FailpointEncode Failpoint = iota
+	// FailpointDecode fails decoding of a response by Future.Get and
+	// Future.GetTyped, the error is wrapped into DecodeError.
+	FailpointDecode
+	// FailpointWrite fails write of a batch of requests to the socket, so
+	// the connection is reconnected or closed as on a real write error.
+	FailpointWrite
+	// FailpointTimeout fails a request with ErrTimeouted instead of
+	// sending it.
+	FailpointTimeout
+)
+
+var failpointNames = map[Failpoint]string{
+	FailpointEncode:  "encode",
+	FailpointDecode:  "decode",
+	FailpointWrite:   "write",
+	FailpointTimeout: "timeout",
+}
+
+func (fp Failpoint) String() string {
+	if name, ok := failpointNames[fp]; ok {
+		return name
+	}
+	return "unknown"
+}
@@ -0,0 +1,9 @@
+//go:build !tarantool_failpoints
+// +build !tarantool_failpoints
+
+package tarantool
+
+// hitFailpoint is a no-op without build tag tarantool_failpoints.
+func hitFailpoint(fp Failpoint) error {
+	return nil
+}
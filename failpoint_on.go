@@ -0,0 +1,84 @@
+//go:build tarantool_failpoints
+// +build tarantool_failpoints
+
+package tarantool
+
+import (
+	"fmt"
+	"sync"
+)
+
+// FailpointConfig defines when enabled failpoint fires. Hits of the
+// failpoint are counted for all connections, including internal requests
+// of connect, e.g. schema loading.
+type FailpointConfig struct {
+	// Skip is a number of hits to pass before the failpoint fires.
+	Skip int
+	// Times is a number of hits the failpoint fires, it fires on every
+	// hit if Times is zero.
+	Times int
+	// Err is an injected error. By default, it is an error naming the
+	// failpoint, or ClientError with ErrTimeouted for FailpointTimeout.
+	Err error
+}
+
+type failpointState struct {
+	cfg   FailpointConfig
+	hits  int
+	fired int
+}
+
+var failpoints = struct {
+	mutex  sync.Mutex
+	states map[Failpoint]*failpointState
+}{states: make(map[Failpoint]*failpointState)}
+
+// EnableFailpoint enables failpoint, its counters are reset. It is
+// available only with build tag tarantool_failpoints.
+func EnableFailpoint(fp Failpoint, cfg FailpointConfig) {
+	failpoints.mutex.Lock()
+	defer failpoints.mutex.Unlock()
+	failpoints.states[fp] = &failpointState{cfg: cfg}
+}
+
+// DisableFailpoint disables failpoint and returns number of times it
+// fired.
+func DisableFailpoint(fp Failpoint) int {
+	failpoints.mutex.Lock()
+	defer failpoints.mutex.Unlock()
+	state, ok := failpoints.states[fp]
+	if !ok {
+		return 0
+	}
+	delete(failpoints.states, fp)
+	return state.fired
+}
+
+// DisableFailpoints disables all failpoints.
+func DisableFailpoints() {
+	failpoints.mutex.Lock()
+	defer failpoints.mutex.Unlock()
+	failpoints.states = make(map[Failpoint]*failpointState)
+}
+
+// hitFailpoint returns injected error if failpoint is enabled and fires.
+func hitFailpoint(fp Failpoint) error {
+	failpoints.mutex.Lock()
+	defer failpoints.mutex.Unlock()
+	state, ok := failpoints.states[fp]
+	if !ok {
+		return nil
+	}
+	state.hits++
+	if state.hits <= state.cfg.Skip || state.cfg.Times > 0 && state.fired >= state.cfg.Times {
+		return nil
+	}
+	state.fired++
+	switch {
+	case state.cfg.Err != nil:
+		return state.cfg.Err
+	case fp == FailpointTimeout:
+		return ClientError{ErrTimeouted, "request timeout (failpoint)"}
+	}
+	return fmt.Errorf("failpoint %s", fp)
+}
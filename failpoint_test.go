@@ -0,0 +1,63 @@
+//go:build tarantool_failpoints
+// +build tarantool_failpoints
+
+package tarantool_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+)
+
+func TestFailpoints(t *testing.T) {
+	defer DisableFailpoints()
+	reconnectOpts := opts
+	reconnectOpts.Reconnect = 100 * time.Millisecond
+	reconnectOpts.SkipSchema = true
+	conn, err := Connect(server, reconnectOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	injected := errors.New("injected")
+	EnableFailpoint(FailpointEncode, FailpointConfig{Times: 1, Err: injected})
+	if _, err = conn.Ping(); err != injected {
+		t.Errorf("Unexpected error of encode failpoint: %v", err)
+	}
+	if _, err = conn.Ping(); err != nil {
+		t.Errorf("Failed to ping after encode failpoint: %s", err.Error())
+	}
+
+	EnableFailpoint(FailpointDecode, FailpointConfig{Skip: 1, Times: 1})
+	for i := 0; i < 3; i++ {
+		_, err = conn.Eval("return 1", []interface{}{})
+		var decodeErr DecodeError
+		if i == 1 && !errors.As(err, &decodeErr) {
+			t.Errorf("Unexpected error of decode failpoint: %v", err)
+		} else if i != 1 && err != nil {
+			t.Errorf("Failed to eval with decode failpoint %d: %s", i, err.Error())
+		}
+	}
+	if fired := DisableFailpoint(FailpointDecode); fired != 1 {
+		t.Errorf("Decode failpoint fired %d times", fired)
+	}
+
+	EnableFailpoint(FailpointTimeout, FailpointConfig{Times: 1})
+	_, err = conn.Ping()
+	if clientErr, ok := err.(ClientError); !ok || clientErr.Code != ErrTimeouted {
+		t.Errorf("Unexpected error of timeout failpoint: %v", err)
+	}
+
+	EnableFailpoint(FailpointWrite, FailpointConfig{Times: 1})
+	if _, err = conn.Ping(); err == nil {
+		t.Errorf("Ping is successful with write failpoint")
+	}
+	time.Sleep(2 * reconnectOpts.Reconnect)
+	if _, err = conn.Ping(); err != nil {
+		t.Errorf("Failed to ping after reconnect: %s", err.Error())
+	}
+}
@@ -0,0 +1,90 @@
+//go:build go1.18
+// +build go1.18
+
+package functions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// Function is a typed signature of a stored procedure: Args are encoded
+// as arguments of call and the first returned value is decoded into Result.
+//
+// Args should be encoded as msgpack array, e.g. a slice, an array or
+// a struct with asArray tag.
+type Function[Args any, Result any] struct {
+	name string
+	reg  *Registry
+	// Validate checks arguments before call, if set.
+	Validate func(args Args) error
+}
+
+// Register defines function with given name in registry. It panics if
+// function is already registered, as it is intended to be used for
+// package level definitions.
+func Register[Args any, Result any](reg *Registry, name string) *Function[Args, Result] {
+	if err := reg.add(name); err != nil {
+		panic(err)
+	}
+	return &Function[Args, Result]{name: name, reg: reg}
+}
+
+// Name returns name of function.
+func (f *Function[Args, Result]) Name() string {
+	return f.name
+}
+
+// Call calls function with Call17 and returns its first result.
+func (f *Function[Args, Result]) Call(conn tarantool.Connector, args Args) (res Result, err error) {
+	start := time.Now()
+	defer func() {
+		f.reg.observe(f.name, time.Since(start), err)
+	}()
+
+	if err = f.validate(args); err != nil {
+		return
+	}
+	var results []Result
+	if err = conn.Call17Typed(f.name, args, &results); err != nil {
+		return
+	}
+	if len(results) == 0 {
+		err = ErrNoResult
+		return
+	}
+	return results[0], nil
+}
+
+// Exec calls function with Call17 ignoring its results.
+func (f *Function[Args, Result]) Exec(conn tarantool.Connector, args Args) (err error) {
+	start := time.Now()
+	defer func() {
+		f.reg.observe(f.name, time.Since(start), err)
+	}()
+
+	if err = f.validate(args); err != nil {
+		return
+	}
+	_, err = conn.Call17(f.name, args)
+	return
+}
+
+func (f *Function[Args, Result]) validate(args Args) error {
+	b, err := msgpack.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("function %s: %s", f.name, err)
+	}
+	if !isArray(b) {
+		return fmt.Errorf("function %s: arguments of type %T are not encoded as array", f.name, args)
+	}
+	if f.Validate != nil {
+		if err = f.Validate(args); err != nil {
+			return fmt.Errorf("function %s: %s", f.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+//go:build go1.18
+// +build go1.18
+
+package functions_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/functions"
+)
+
+var server = "127.0.0.1:3013"
+var opts = Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+func TestFunction(t *testing.T) {
+	reg := functions.NewRegistry()
+	incr := functions.Register[[]int, int](reg, "simple_incr")
+	incr.Validate = func(args []int) error {
+		if len(args) != 1 {
+			return errors.New("exactly one argument is expected")
+		}
+		return nil
+	}
+	notArray := functions.Register[int, int](reg, "simple_incr_scalar")
+
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	if res, err := incr.Call(conn, []int{41}); err != nil {
+		t.Errorf("Failed to call: %s", err.Error())
+	} else if res != 42 {
+		t.Errorf("Unexpected result: %d", res)
+	}
+	if _, err := incr.Call(conn, []int{1, 2}); err == nil {
+		t.Errorf("Arguments are not validated")
+	}
+	if _, err := notArray.Call(conn, 1); err == nil {
+		t.Errorf("Arguments that are not array are not rejected")
+	}
+
+	stats, ok := reg.Stats("simple_incr")
+	if !ok || stats.Calls != 2 || stats.Errors != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if names := reg.Names(); len(names) != 2 || names[0] != "simple_incr" {
+		t.Errorf("Unexpected names: %v", names)
+	}
+}
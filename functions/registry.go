@@ -0,0 +1,93 @@
+// Package functions defines typed signatures of stored procedures (Lua
+// functions) once and calls them with encoding validation and per-function
+// metrics.
+//
+//	reg := functions.NewRegistry()
+//	add := functions.Register[[2]int, int](reg, "add")
+//	sum, err := add.Call(conn, [2]int{1, 2})
+package functions
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNoResult is returned when function returns nothing, while result is
+// expected.
+var ErrNoResult = errors.New("function returned no result")
+
+// Stats are metrics of calls of a function.
+type Stats struct {
+	// Calls is a number of calls.
+	Calls uint64
+	// Errors is a number of failed calls, including invalid arguments.
+	Errors uint64
+	// Duration is a total duration of calls.
+	Duration time.Duration
+}
+
+// Registry keeps functions and their metrics.
+type Registry struct {
+	mutex sync.Mutex
+	stats map[string]*Stats
+}
+
+// NewRegistry creates empty registry.
+func NewRegistry() *Registry {
+	return &Registry{stats: make(map[string]*Stats)}
+}
+
+func (reg *Registry) add(name string) error {
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+	if _, ok := reg.stats[name]; ok {
+		return fmt.Errorf("function %s is already registered", name)
+	}
+	reg.stats[name] = &Stats{}
+	return nil
+}
+
+func (reg *Registry) observe(name string, d time.Duration, err error) {
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+	stats := reg.stats[name]
+	stats.Calls++
+	stats.Duration += d
+	if err != nil {
+		stats.Errors++
+	}
+}
+
+// Names returns sorted names of registered functions.
+func (reg *Registry) Names() []string {
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+	names := make([]string, 0, len(reg.stats))
+	for name := range reg.stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Stats returns metrics of a function.
+func (reg *Registry) Stats(name string) (stats Stats, ok bool) {
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+	if s, found := reg.stats[name]; found {
+		return *s, true
+	}
+	return Stats{}, false
+}
+
+// isArray reports if encoded value is msgpack array, arguments of call
+// should be an array.
+func isArray(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	return b[0]&0xf0 == 0x90 || b[0] == 0xdc || b[0] == 0xdd
+}
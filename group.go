@@ -0,0 +1,199 @@
+package tarantool
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ConnGroup is a set of connections to the same Tarantool instance.
+//
+// Requests are distributed among connections in round-robin manner,
+// so throughput is not limited by a single socket. Connections which are
+// not connected at the moment are skipped.
+//
+// Use Conns() to pin a sequence of requests to one socket.
+type ConnGroup struct {
+	conns []*Connection
+	next  uint32
+}
+
+var _ = Connector(&ConnGroup{}) // check compatibility with connector interface
+
+// ConnectGroup opens n connections to addr configured with opts.
+//
+// Schema is loaded only by the first connection and shared with others.
+// If any connection fails, already opened connections are closed and
+// error is returned.
+func ConnectGroup(addr string, n int, opts Opts) (group *ConnGroup, err error) {
+	if n <= 0 {
+		return nil, errors.New("number of connections should be positive")
+	}
+	group = &ConnGroup{conns: make([]*Connection, 0, n)}
+	for i := 0; i < n; i++ {
+		var conn *Connection
+		if conn, err = Connect(addr, opts); err != nil {
+			group.Close()
+			return nil, err
+		}
+		if i == 0 {
+			opts.SkipSchema = true
+		} else {
+			conn.OverrideSchema(group.conns[0].Schema)
+		}
+		group.conns = append(group.conns, conn)
+	}
+	return group, nil
+}
+
+// Conns returns connections of the group.
+func (group *ConnGroup) Conns() []*Connection {
+	return group.conns
+}
+
+func (group *ConnGroup) getConnection() *Connection {
+	n := uint32(len(group.conns))
+	start := atomic.AddUint32(&group.next, 1)
+	for i := uint32(0); i < n; i++ {
+		if conn := group.conns[(start+i)%n]; conn.ConnectedNow() {
+			return conn
+		}
+	}
+	return group.conns[start%n]
+}
+
+// ConnectedNow reports if any connection of the group is established.
+func (group *ConnGroup) ConnectedNow() bool {
+	for _, conn := range group.conns {
+		if conn.ConnectedNow() {
+			return true
+		}
+	}
+	return false
+}
+
+// Close closes all connections of the group.
+func (group *ConnGroup) Close() (err error) {
+	for _, conn := range group.conns {
+		if cerr := conn.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return
+}
+
+func (group *ConnGroup) Ping() (resp *Response, err error) {
+	return group.getConnection().Ping()
+}
+
+func (group *ConnGroup) ConfiguredTimeout() time.Duration {
+	return group.conns[0].ConfiguredTimeout()
+}
+
+func (group *ConnGroup) Select(space, index interface{}, offset, limit, iterator uint32, key interface{}) (resp *Response, err error) {
+	return group.getConnection().Select(space, index, offset, limit, iterator, key)
+}
+
+func (group *ConnGroup) Insert(space interface{}, tuple interface{}) (resp *Response, err error) {
+	return group.getConnection().Insert(space, tuple)
+}
+
+func (group *ConnGroup) Replace(space interface{}, tuple interface{}) (resp *Response, err error) {
+	return group.getConnection().Replace(space, tuple)
+}
+
+func (group *ConnGroup) Delete(space, index interface{}, key interface{}) (resp *Response, err error) {
+	return group.getConnection().Delete(space, index, key)
+}
+
+func (group *ConnGroup) Update(space, index interface{}, key, ops interface{}) (resp *Response, err error) {
+	return group.getConnection().Update(space, index, key, ops)
+}
+
+func (group *ConnGroup) Upsert(space interface{}, tuple, ops interface{}) (resp *Response, err error) {
+	return group.getConnection().Upsert(space, tuple, ops)
+}
+
+func (group *ConnGroup) Call(functionName string, args interface{}) (resp *Response, err error) {
+	return group.getConnection().Call(functionName, args)
+}
+
+func (group *ConnGroup) Call17(functionName string, args interface{}) (resp *Response, err error) {
+	return group.getConnection().Call17(functionName, args)
+}
+
+func (group *ConnGroup) Eval(expr string, args interface{}) (resp *Response, err error) {
+	return group.getConnection().Eval(expr, args)
+}
+
+func (group *ConnGroup) GetTyped(space, index interface{}, key interface{}, result interface{}) (err error) {
+	return group.getConnection().GetTyped(space, index, key, result)
+}
+
+func (group *ConnGroup) SelectTyped(space, index interface{}, offset, limit, iterator uint32, key interface{}, result interface{}) (err error) {
+	return group.getConnection().SelectTyped(space, index, offset, limit, iterator, key, result)
+}
+
+func (group *ConnGroup) InsertTyped(space interface{}, tuple interface{}, result interface{}) (err error) {
+	return group.getConnection().InsertTyped(space, tuple, result)
+}
+
+func (group *ConnGroup) ReplaceTyped(space interface{}, tuple interface{}, result interface{}) (err error) {
+	return group.getConnection().ReplaceTyped(space, tuple, result)
+}
+
+func (group *ConnGroup) DeleteTyped(space, index interface{}, key interface{}, result interface{}) (err error) {
+	return group.getConnection().DeleteTyped(space, index, key, result)
+}
+
+func (group *ConnGroup) UpdateTyped(space, index interface{}, key, ops interface{}, result interface{}) (err error) {
+	return group.getConnection().UpdateTyped(space, index, key, ops, result)
+}
+
+func (group *ConnGroup) CallTyped(functionName string, args interface{}, result interface{}) (err error) {
+	return group.getConnection().CallTyped(functionName, args, result)
+}
+
+func (group *ConnGroup) Call17Typed(functionName string, args interface{}, result interface{}) (err error) {
+	return group.getConnection().Call17Typed(functionName, args, result)
+}
+
+func (group *ConnGroup) EvalTyped(expr string, args interface{}, result interface{}) (err error) {
+	return group.getConnection().EvalTyped(expr, args, result)
+}
+
+func (group *ConnGroup) SelectAsync(space, index interface{}, offset, limit, iterator uint32, key interface{}) *Future {
+	return group.getConnection().SelectAsync(space, index, offset, limit, iterator, key)
+}
+
+func (group *ConnGroup) InsertAsync(space interface{}, tuple interface{}) *Future {
+	return group.getConnection().InsertAsync(space, tuple)
+}
+
+func (group *ConnGroup) ReplaceAsync(space interface{}, tuple interface{}) *Future {
+	return group.getConnection().ReplaceAsync(space, tuple)
+}
+
+func (group *ConnGroup) DeleteAsync(space, index interface{}, key interface{}) *Future {
+	return group.getConnection().DeleteAsync(space, index, key)
+}
+
+func (group *ConnGroup) UpdateAsync(space, index interface{}, key, ops interface{}) *Future {
+	return group.getConnection().UpdateAsync(space, index, key, ops)
+}
+
+func (group *ConnGroup) UpsertAsync(space interface{}, tuple interface{}, ops interface{}) *Future {
+	return group.getConnection().UpsertAsync(space, tuple, ops)
+}
+
+func (group *ConnGroup) CallAsync(functionName string, args interface{}) *Future {
+	return group.getConnection().CallAsync(functionName, args)
+}
+
+func (group *ConnGroup) Call17Async(functionName string, args interface{}) *Future {
+	return group.getConnection().Call17Async(functionName, args)
+}
+
+func (group *ConnGroup) EvalAsync(expr string, args interface{}) *Future {
+	return group.getConnection().EvalAsync(expr, args)
+}
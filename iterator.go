@@ -0,0 +1,107 @@
+package tarantool
+
+import (
+	"fmt"
+	"strings"
+)
+
+var iteratorNames = map[uint32]string{
+	IterEq:            "EQ",
+	IterReq:           "REQ",
+	IterAll:           "ALL",
+	IterLt:            "LT",
+	IterLe:            "LE",
+	IterGe:            "GE",
+	IterGt:            "GT",
+	IterBitsAllSet:    "BITS_ALL_SET",
+	IterBitsAnySet:    "BITS_ANY_SET",
+	IterBitsAllNotSet: "BITS_ALL_NOT_SET",
+	IterOverlaps:      "OVERLAPS",
+	IterNeighbor:      "NEIGHBOR",
+}
+
+// IteratorName returns name of iterator type as in Lua API.
+func IteratorName(iterator uint32) string {
+	if name, ok := iteratorNames[iterator]; ok {
+		return name
+	}
+	return fmt.Sprintf("iterator(%d)", iterator)
+}
+
+// indexIterators are iterators supported by index types.
+var indexIterators = map[string][]uint32{
+	"TREE":   {IterEq, IterReq, IterAll, IterLt, IterLe, IterGe, IterGt},
+	"HASH":   {IterEq, IterAll, IterGt},
+	"BITSET": {IterEq, IterAll, IterBitsAllSet, IterBitsAnySet, IterBitsAllNotSet},
+	"RTREE":  {IterEq, IterAll, IterLt, IterLe, IterGe, IterGt, IterOverlaps, IterNeighbor},
+}
+
+// Iterators returns iterators supported by the index type. It returns nil
+// if index type is unknown.
+func (index *Index) Iterators() []uint32 {
+	return indexIterators[strings.ToUpper(index.Type)]
+}
+
+// ValidateIterator returns error if iterator is not supported by the
+// index type. Iterators of unknown index types are not validated.
+func (index *Index) ValidateIterator(iterator uint32) error {
+	iterators := index.Iterators()
+	if iterators == nil {
+		return nil
+	}
+	names := make([]string, len(iterators))
+	for i, iter := range iterators {
+		if iter == iterator {
+			return nil
+		}
+		names[i] = IteratorName(iter)
+	}
+	return fmt.Errorf("iterator %s is not supported by %s index %s, supported iterators: %s",
+		IteratorName(iterator), strings.ToUpper(index.Type), index.Name, strings.Join(names, ", "))
+}
+
+// validateIterator checks iterator of select if Opts.ValidateIterators is
+// set and schema of the space is loaded.
+func (conn *Connection) validateIterator(spaceNo, indexNo, iterator uint32) error {
+	if !conn.opts.ValidateIterators || conn.Schema == nil {
+		return nil
+	}
+	space, ok := conn.Schema.SpacesById[spaceNo]
+	if !ok {
+		return nil
+	}
+	index, ok := space.IndexesById[indexNo]
+	if !ok {
+		return nil
+	}
+	if err := index.ValidateIterator(iterator); err != nil {
+		return fmt.Errorf("space %s: %s", space.Name, err)
+	}
+	return nil
+}
+
+// RTreePoint returns key of RTREE index for a point with coordinates.
+func RTreePoint(coords ...float64) []interface{} {
+	key := make([]interface{}, len(coords))
+	for i, c := range coords {
+		key[i] = c
+	}
+	return key
+}
+
+// RTreeRect returns key of RTREE index for a rectangle (a box in
+// multidimensional case) with the lowest corner min and the highest
+// corner max. It returns error if dimensions of corners differ.
+func RTreeRect(min, max []float64) ([]interface{}, error) {
+	if len(min) != len(max) || len(min) == 0 {
+		return nil, fmt.Errorf("rectangle corners have dimensions %d and %d", len(min), len(max))
+	}
+	key := make([]interface{}, 0, 2*len(min))
+	for _, c := range min {
+		key = append(key, c)
+	}
+	for _, c := range max {
+		key = append(key, c)
+	}
+	return key, nil
+}
@@ -0,0 +1,195 @@
+// Package journal implements a journal of failed write requests for
+// fire-and-forget ingestion (e.g. telemetry): requests that failed
+// because connection was not available are persisted to a Store and
+// replayed after connectivity is restored.
+//
+// Requests are replayed at least once: a request may be applied by server
+// even if its response was lost, so journaled requests should be
+// idempotent (e.g. replace instead of insert) or tolerate duplicates.
+package journal
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// Opts is a configuration of Journal.
+type Opts struct {
+	// OnDrop is called when a replayed request is rejected by server,
+	// such requests are removed from the journal.
+	OnDrop func(e Entry, err error)
+}
+
+// Journal sends write requests and journals those that failed because
+// of connection errors.
+type Journal struct {
+	conn  *tarantool.Connection
+	store Store
+	opts  Opts
+	// replay is held while journal is replayed, so entries are not
+	// replayed twice concurrently.
+	replay sync.Mutex
+}
+
+// New creates journal of requests sent over conn.
+func New(conn *tarantool.Connection, store Store, opts Opts) *Journal {
+	return &Journal{conn: conn, store: store, opts: opts}
+}
+
+// request is a request with encoded body.
+type request struct {
+	code int32
+	body []byte
+}
+
+func (req request) Code() int32 {
+	return req.code
+}
+
+func (req request) Body(enc *msgpack.Encoder) error {
+	_, err := enc.Writer().Write(req.body)
+	return err
+}
+
+func encode(code int32, fields ...interface{}) (request, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	if err := enc.EncodeMapLen(len(fields) / 2); err != nil {
+		return request{}, err
+	}
+	for _, f := range fields {
+		if err := enc.Encode(f); err != nil {
+			return request{}, err
+		}
+	}
+	return request{code: code, body: buf.Bytes()}, nil
+}
+
+func (j *Journal) spaceNo(space interface{}) (uint32, error) {
+	switch s := space.(type) {
+	case string:
+		if j.conn.Schema == nil {
+			return 0, fmt.Errorf("schema is not loaded, space %q can't be resolved", s)
+		}
+		sp, ok := j.conn.Schema.Spaces[s]
+		if !ok {
+			return 0, fmt.Errorf("there is no space with name %s", s)
+		}
+		return sp.Id, nil
+	case uint32:
+		return s, nil
+	case int:
+		return uint32(s), nil
+	default:
+		return 0, fmt.Errorf("wrong space %v", space)
+	}
+}
+
+// Insert inserts tuple, see Write.
+func (j *Journal) Insert(space interface{}, tuple interface{}) error {
+	return j.writeSpace(tarantool.InsertRequest, space, tarantool.KeyTuple, tuple)
+}
+
+// Replace replaces tuple, see Write.
+func (j *Journal) Replace(space interface{}, tuple interface{}) error {
+	return j.writeSpace(tarantool.ReplaceRequest, space, tarantool.KeyTuple, tuple)
+}
+
+// Upsert updates or inserts tuple, see Write.
+func (j *Journal) Upsert(space interface{}, tuple, ops interface{}) error {
+	return j.writeSpace(tarantool.UpsertRequest, space, tarantool.KeyTuple, tuple, tarantool.KeyDefTuple, ops)
+}
+
+// Call17 calls function, see Write.
+func (j *Journal) Call17(functionName string, args interface{}) error {
+	req, err := encode(tarantool.Call17Request, tarantool.KeyFunctionName, functionName, tarantool.KeyTuple, args)
+	if err != nil {
+		return err
+	}
+	return j.Write(req)
+}
+
+func (j *Journal) writeSpace(code int32, space interface{}, fields ...interface{}) error {
+	spaceNo, err := j.spaceNo(space)
+	if err != nil {
+		return err
+	}
+	req, err := encode(code, append([]interface{}{tarantool.KeySpaceNo, spaceNo}, fields...)...)
+	if err != nil {
+		return err
+	}
+	return j.Write(req)
+}
+
+// Write sends request and waits for response. If request fails because
+// connection is not available, it is journaled and nil is returned.
+// Errors returned by server and errors of journal store are returned.
+func (j *Journal) Write(req tarantool.Request) error {
+	raw, ok := req.(request)
+	if !ok {
+		var buf bytes.Buffer
+		if err := req.Body(msgpack.NewEncoder(&buf)); err != nil {
+			return err
+		}
+		raw = request{code: req.Code(), body: buf.Bytes()}
+	}
+	_, err := j.conn.Do(raw).Get()
+	if !isConnectionError(err) {
+		return err
+	}
+	_, serr := j.store.Append(Entry{
+		Code: raw.code,
+		Body: raw.body,
+		Time: time.Now(),
+		Err:  err.Error(),
+	})
+	return serr
+}
+
+// Len returns number of journaled requests.
+func (j *Journal) Len() (int, error) {
+	entries, err := j.store.Load()
+	return len(entries), err
+}
+
+// Replay sends journaled requests in order they were journaled and
+// removes them from the journal. It stops on the first connection error,
+// the rest of requests are kept for the next Replay. It is intended to be
+// called when connection is restored, e.g. on tarantool.Connected event.
+func (j *Journal) Replay() (replayed int, err error) {
+	j.replay.Lock()
+	defer j.replay.Unlock()
+
+	entries, err := j.store.Load()
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range entries {
+		_, err = j.conn.Do(request{code: e.Code, body: e.Body}).Get()
+		if isConnectionError(err) {
+			return replayed, err
+		}
+		if err != nil && j.opts.OnDrop != nil {
+			j.opts.OnDrop(e, err)
+		}
+		if err = j.store.Delete(e.Id); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// isConnectionError reports if request failed because connection was
+// not available, so it may succeed later.
+func isConnectionError(err error) bool {
+	if clientErr, ok := err.(tarantool.ClientError); ok {
+		return clientErr.Temporary() || clientErr.Code == tarantool.ErrConnectionClosed
+	}
+	return false
+}
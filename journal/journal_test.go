@@ -0,0 +1,112 @@
+package journal_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/journal"
+)
+
+var server = "127.0.0.1:3013"
+var opts = Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatalf("Failed to create dir: %s", err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := journal.NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %s", err.Error())
+	}
+	for i := 0; i < 3; i++ {
+		if _, err = store.Append(journal.Entry{Code: int32(i), Body: []byte{0x80}}); err != nil {
+			t.Fatalf("Failed to append: %s", err.Error())
+		}
+	}
+	if err = store.Delete(2); err != nil {
+		t.Errorf("Failed to delete: %s", err.Error())
+	}
+
+	// Entries and ids survive reopening.
+	store, err = journal.NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %s", err.Error())
+	}
+	id, err := store.Append(journal.Entry{Code: 3})
+	if err != nil || id != 4 {
+		t.Errorf("Unexpected id %d: %v", id, err)
+	}
+	entries, err := store.Load()
+	if err != nil {
+		t.Fatalf("Failed to load: %s", err.Error())
+	}
+	var codes []int32
+	for _, e := range entries {
+		codes = append(codes, e.Code)
+	}
+	if len(codes) != 3 || codes[0] != 0 || codes[1] != 2 || codes[2] != 3 {
+		t.Errorf("Unexpected entries: %v", codes)
+	}
+}
+
+func TestJournal(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	store := new(journal.MemoryStore)
+	j := journal.New(conn, store, journal.Opts{})
+	conn.Close()
+
+	// Requests over closed connection are journaled.
+	if err = j.Replace("test", []interface{}{uint(4000), "journal", "one"}); err != nil {
+		t.Errorf("Failed to journal: %s", err.Error())
+	}
+	if err = j.Replace("test", []interface{}{uint(4001), "journal", "two"}); err != nil {
+		t.Errorf("Failed to journal: %s", err.Error())
+	}
+	if n, _ := j.Len(); n != 2 {
+		t.Errorf("Unexpected journal length: %d", n)
+	}
+
+	conn, err = Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+	var dropped int
+	j = journal.New(conn, store, journal.Opts{
+		OnDrop: func(journal.Entry, error) { dropped++ },
+	})
+	replayed, err := j.Replay()
+	if err != nil || replayed != 2 || dropped != 0 {
+		t.Errorf("Unexpected replay: %d, %d dropped, %v", replayed, dropped, err)
+	}
+	if n, _ := j.Len(); n != 0 {
+		t.Errorf("Journal is not empty after replay: %d", n)
+	}
+	resp, err := conn.Select("test", "primary", 0, 2, IterGe, []interface{}{uint(4000)})
+	if err != nil || len(resp.Data) != 2 {
+		t.Errorf("Replayed tuples are not found: %v, %v", resp, err)
+	}
+
+	// Server errors are returned and not journaled.
+	if err = j.Insert("test", []interface{}{uint(4000), "journal", "dup"}); err == nil {
+		t.Errorf("Expected duplicate key error")
+	}
+	if n, _ := j.Len(); n != 0 {
+		t.Errorf("Server error is journaled")
+	}
+}
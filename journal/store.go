@@ -0,0 +1,182 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a journaled request.
+type Entry struct {
+	// Id is assigned by Store, entries are replayed in order of ids.
+	Id uint64
+	// Code is a request type.
+	Code int32
+	// Body is an encoded request body.
+	Body []byte
+	// Time is a time of the first failed attempt.
+	Time time.Time
+	// Err is an error of the first failed attempt.
+	Err string
+}
+
+// Store persists journaled requests.
+type Store interface {
+	// Append stores entry and returns its id.
+	Append(e Entry) (uint64, error)
+	// Load returns stored entries ordered by id.
+	Load() ([]Entry, error)
+	// Delete removes entry.
+	Delete(id uint64) error
+}
+
+// MemoryStore keeps entries in memory, so they are lost on restart.
+// It is useful in tests and to survive short disconnects.
+type MemoryStore struct {
+	mutex   sync.Mutex
+	lastId  uint64
+	entries []Entry
+}
+
+// Append implements Store.
+func (s *MemoryStore) Append(e Entry) (uint64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastId++
+	e.Id = s.lastId
+	s.entries = append(s.entries, e)
+	return e.Id, nil
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load() ([]Entry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Entry(nil), s.entries...), nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(id uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, e := range s.entries {
+		if e.Id == id {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// FileStore keeps each entry in a separate JSON file of a directory.
+type FileStore struct {
+	dir    string
+	mutex  sync.Mutex
+	lastId uint64
+}
+
+const entryExt = ".json"
+
+// NewFileStore creates store in dir, it is created if not exists.
+// Entries left in dir by previous runs are kept.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	s := &FileStore{dir: dir}
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		s.lastId = ids[len(ids)-1]
+	}
+	return s, nil
+}
+
+func (s *FileStore) path(id uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", id, entryExt))
+}
+
+func (s *FileStore) ids() ([]uint64, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint64
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, entryExt) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, entryExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// Append implements Store. Entry is written to a temporary file first,
+// so partially written entries are never loaded.
+func (s *FileStore) Append(e Entry) (uint64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e.Id = s.lastId + 1
+	data, err := json.Marshal(e)
+	if err != nil {
+		return 0, err
+	}
+	tmp := s.path(e.Id) + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return 0, err
+	}
+	if err = os.Rename(tmp, s.path(e.Id)); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	s.lastId = e.Id
+	return e.Id, nil
+}
+
+// Load implements Store.
+func (s *FileStore) Load() ([]Entry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(ids))
+	for _, id := range ids {
+		data, err := ioutil.ReadFile(s.path(id))
+		if err != nil {
+			return nil, err
+		}
+		var e Entry
+		if err = json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("journal entry %d: %s", id, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(id uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := os.Remove(s.path(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package tarantool
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// JSONOpts configures rendering of response data to JSON, see Response.JSON.
+type JSONOpts struct {
+	// TimeFormat is a layout of time.Time values, time.RFC3339Nano by
+	// default.
+	TimeFormat string
+	// Format allows to render values of extension types (decimal, uuid and
+	// so on) in a custom way. It is called for every value before default
+	// conversion and returns replacement of the value and true if value is
+	// converted.
+	Format func(v interface{}) (interface{}, bool)
+}
+
+// JSON renders decoded response data to JSON array. If space is not nil,
+// tuples are rendered as objects keyed by field names of space format (see
+// Space.TupleMap), otherwise as arrays. Maps with non-string keys are
+// rendered with keys converted by fmt.Sprint.
+//
+// It is intended for gateways which pass data to JSON consumers without
+// intermediate structs.
+func (resp *Response) JSON(space *Space, opts JSONOpts) ([]byte, error) {
+	if opts.TimeFormat == "" {
+		opts.TimeFormat = time.RFC3339Nano
+	}
+	data := make([]interface{}, len(resp.Data))
+	for i, row := range resp.Data {
+		if tuple, ok := row.([]interface{}); ok && space != nil {
+			row = space.TupleMap(tuple)
+		}
+		data[i] = opts.convert(row)
+	}
+	return json.Marshal(data)
+}
+
+// convert replaces values which could not be marshaled by encoding/json.
+func (opts *JSONOpts) convert(v interface{}) interface{} {
+	if opts.Format != nil {
+		if res, ok := opts.Format(v); ok {
+			return res
+		}
+	}
+	switch v := v.(type) {
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i := range v {
+			res[i] = opts.convert(v[i])
+		}
+		return res
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k := range v {
+			res[k] = opts.convert(v[k])
+		}
+		return res
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k := range v {
+			res[fmt.Sprint(k)] = opts.convert(v[k])
+		}
+		return res
+	case time.Time:
+		return v.Format(opts.TimeFormat)
+	}
+	return v
+}
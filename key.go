@@ -0,0 +1,131 @@
+package tarantool
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Key builds key of index from parts: number of parts and their types are
+// validated against index definition, and values are converted to types
+// expected by index, e.g. int to uint64 for unsigned parts or string to
+// int64 for integer parts. Parts of unknown types are passed as is.
+//
+// Tree indexes accept partial keys, other indexes require all parts.
+func (index *Index) Key(parts ...interface{}) ([]interface{}, error) {
+	if len(parts) > len(index.Fields) {
+		return nil, fmt.Errorf("index %s has %d parts, got %d", index.Name, len(index.Fields), len(parts))
+	}
+	if len(parts) < len(index.Fields) && index.Type != "" && index.Type != "tree" && index.Type != "TREE" {
+		return nil, fmt.Errorf("%s index %s requires all %d parts, got %d", index.Type, index.Name, len(index.Fields), len(parts))
+	}
+	key := make([]interface{}, len(parts))
+	for i, part := range parts {
+		v, err := convertPart(part, index.Fields[i].Type)
+		if err != nil {
+			return nil, fmt.Errorf("part %d of index %s: %s", i+1, index.Name, err)
+		}
+		key[i] = v
+	}
+	return key, nil
+}
+
+// ResolveIndex resolves space and index given as numbers or names, like
+// requests of the connection do, and returns index description from
+// loaded schema.
+func (conn *Connection) ResolveIndex(space, index interface{}) (*Index, error) {
+	spaceDesc, err := conn.spaceOf(space)
+	if err != nil {
+		return nil, err
+	}
+	_, indexNo, err := conn.resolveSpaceIndex(spaceDesc.Id, index)
+	if err != nil {
+		return nil, err
+	}
+	indexDesc, ok := spaceDesc.IndexesById[indexNo]
+	if !ok {
+		return nil, fmt.Errorf("there is no index with id %d in space %s", indexNo, spaceDesc.Name)
+	}
+	return indexDesc, nil
+}
+
+// Key resolves index in loaded schema and builds its key, see Index.Key.
+func (conn *Connection) Key(space, index interface{}, parts ...interface{}) ([]interface{}, error) {
+	indexDesc, err := conn.ResolveIndex(space, index)
+	if err != nil {
+		return nil, err
+	}
+	return indexDesc.Key(parts...)
+}
+
+func convertPart(v interface{}, partType string) (interface{}, error) {
+	switch partType {
+	case "unsigned", "uint", "num":
+		if s, ok := v.(string); ok {
+			return strconv.ParseUint(s, 10, 64)
+		}
+		if class, _ := valueClass(v); class == classNumber {
+			n := toNumber(v)
+			switch {
+			case n.isUint:
+				return n.u, nil
+			case !n.isF && n.i >= 0:
+				return uint64(n.i), nil
+			}
+		}
+	case "integer", "int":
+		if s, ok := v.(string); ok {
+			return strconv.ParseInt(s, 10, 64)
+		}
+		if class, _ := valueClass(v); class == classNumber {
+			if n := toNumber(v); !n.isF && !n.isUint {
+				return n.i, nil
+			}
+		}
+	case "number":
+		if s, ok := v.(string); ok {
+			return strconv.ParseFloat(s, 64)
+		}
+		if class, _ := valueClass(v); class == classNumber {
+			return v, nil
+		}
+	case "double":
+		// integers are encoded as MP_INT or MP_UINT, which are rejected
+		// by double fields
+		if s, ok := v.(string); ok {
+			return strconv.ParseFloat(s, 64)
+		}
+		if class, _ := valueClass(v); class == classNumber {
+			return toNumber(v).float(), nil
+		}
+	case "string", "str":
+		switch s := v.(type) {
+		case string:
+			return s, nil
+		case []byte:
+			return string(s), nil
+		case fmt.Stringer:
+			return s.String(), nil
+		}
+	case "boolean":
+		switch b := v.(type) {
+		case bool:
+			return b, nil
+		case string:
+			return strconv.ParseBool(b)
+		}
+	case "varbinary":
+		switch b := v.(type) {
+		case []byte:
+			return b, nil
+		case string:
+			return []byte(b), nil
+		}
+	case "scalar":
+		if _, err := valueClass(v); err == nil {
+			return v, nil
+		}
+	default:
+		return v, nil
+	}
+	return nil, fmt.Errorf("value %v of type %T does not match %s", v, v, partType)
+}
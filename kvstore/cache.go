@@ -0,0 +1,75 @@
+package kvstore
+
+import (
+	"fmt"
+	"time"
+)
+
+// CacheOpts is a configuration of Cache.
+type CacheOpts struct {
+	// TTL is used by Set, by default keys never expire.
+	TTL time.Duration
+	// OnError is called with errors of requests, since cache interfaces
+	// don't report them.
+	OnError func(err error)
+}
+
+// Cache adapts Store to interface of in-memory caches (ristretto-style
+// Get, Set, SetWithTTL, Del and Clear), so Tarantool could be used as a
+// drop-in shared cache backend. Keys which are not strings are converted
+// with fmt.Sprint, values are encoded with Store codec.
+type Cache struct {
+	store *Store
+	opts  CacheOpts
+}
+
+// NewCache creates cache over the store.
+func NewCache(store *Store, opts CacheOpts) *Cache {
+	return &Cache{store: store, opts: opts}
+}
+
+func (c *Cache) report(err error) {
+	if err != nil && c.opts.OnError != nil {
+		c.opts.OnError(err)
+	}
+}
+
+func cacheKey(key interface{}) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	return fmt.Sprint(key)
+}
+
+// Get returns value of the key and whether it is found.
+// Value is decoded into interface{}, so structs become maps.
+func (c *Cache) Get(key interface{}) (interface{}, bool) {
+	var value interface{}
+	ok, err := c.store.Get(cacheKey(key), &value)
+	c.report(err)
+	return value, ok && err == nil
+}
+
+// Set stores value with CacheOpts.TTL. Cost is ignored.
+func (c *Cache) Set(key, value interface{}, cost int64) bool {
+	return c.SetWithTTL(key, value, cost, c.opts.TTL)
+}
+
+// SetWithTTL stores value with ttl. Cost is ignored.
+func (c *Cache) SetWithTTL(key, value interface{}, cost int64, ttl time.Duration) bool {
+	err := c.store.Set(cacheKey(key), value, ttl)
+	c.report(err)
+	return err == nil
+}
+
+// Del deletes the key.
+func (c *Cache) Del(key interface{}) {
+	c.report(c.store.Delete(cacheKey(key)))
+}
+
+// Clear deletes all keys of the store.
+// Note: it uses Eval, so user needs 'execute universe' privilege.
+func (c *Cache) Clear() {
+	_, err := c.store.conn.Eval("box.space[...]:truncate()", []interface{}{c.store.space})
+	c.report(err)
+}
@@ -0,0 +1,236 @@
+// Package kvstore implements a key-value store with expiration on top of a
+// Tarantool space. It could be used as a session or state store: Store
+// implements Find, Commit and Delete methods expected by session
+// middlewares (e.g. scs.Store).
+package kvstore
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// DefaultSpace is a name of store space used by default.
+const DefaultSpace = "kvstore"
+
+// Codec encodes values of Store.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+var (
+	// MsgpackCodec encodes values with msgpack.
+	MsgpackCodec Codec = msgpackCodec{}
+	// JSONCodec encodes values with encoding/json.
+	JSONCodec Codec = jsonCodec{}
+)
+
+// Opts is a configuration of Store.
+type Opts struct {
+	// Space is a name of store space, DefaultSpace by default.
+	Space string
+	// Codec encodes values, MsgpackCodec by default.
+	Codec Codec
+}
+
+// tuple of store space, zero Expires means that key never expires.
+type tuple struct {
+	Key     string
+	Data    []byte
+	Expires float64 // unix time in seconds
+}
+
+func (t *tuple) EncodeMsgpack(e *msgpack.Encoder) error {
+	if err := e.EncodeSliceLen(3); err != nil {
+		return err
+	}
+	if err := e.EncodeString(t.Key); err != nil {
+		return err
+	}
+	if err := e.EncodeBytes(t.Data); err != nil {
+		return err
+	}
+	return e.EncodeFloat64(t.Expires)
+}
+
+func (t *tuple) DecodeMsgpack(d *msgpack.Decoder) error {
+	var err error
+	if _, err = d.DecodeSliceLen(); err != nil {
+		return err
+	}
+	if t.Key, err = d.DecodeString(); err != nil {
+		return err
+	}
+	if t.Data, err = d.DecodeBytes(); err != nil {
+		return err
+	}
+	t.Expires, err = d.DecodeFloat64()
+	return err
+}
+
+func (t *tuple) expired(now time.Time) bool {
+	return t.Expires != 0 && t.Expires <= unixTime(now)
+}
+
+func unixTime(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+const initLua = `
+local space = ...
+local s = box.schema.space.create(space, {if_not_exists = true})
+s:create_index('primary', {parts = {1, 'string'}, if_not_exists = true})
+return s.id
+`
+
+const purgeLua = `
+local space, now = ...
+local s = box.space[space]
+local keys = {}
+for _, t in s:pairs() do
+    if t[3] ~= 0 and t[3] <= now then
+        table.insert(keys, t[1])
+    end
+end
+for _, key in ipairs(keys) do
+    s:delete{key}
+end
+return #keys
+`
+
+// Store is a key-value store.
+type Store struct {
+	conn    tarantool.Connector
+	space   string
+	spaceNo uint32
+	codec   Codec
+}
+
+// New creates store space if it doesn't exist and returns Store.
+// Note: it uses Eval, so user needs 'execute universe' privilege.
+func New(conn tarantool.Connector, opts Opts) (*Store, error) {
+	if opts.Space == "" {
+		opts.Space = DefaultSpace
+	}
+	if opts.Codec == nil {
+		opts.Codec = MsgpackCodec
+	}
+	var ids []uint32
+	if err := conn.EvalTyped(initLua, []interface{}{opts.Space}, &ids); err != nil {
+		return nil, err
+	}
+	store := &Store{conn: conn, space: opts.Space, codec: opts.Codec}
+	if len(ids) > 0 {
+		store.spaceNo = ids[0]
+	}
+	return store, nil
+}
+
+func (s *Store) get(key string) (*tuple, error) {
+	var tuples []tuple
+	err := s.conn.SelectTyped(s.spaceNo, uint32(0), 0, 1, tarantool.IterEq, []interface{}{key}, &tuples)
+	if err != nil {
+		return nil, err
+	}
+	if len(tuples) == 0 || tuples[0].expired(time.Now()) {
+		return nil, nil
+	}
+	return &tuples[0], nil
+}
+
+// Set stores value for the key. Zero ttl means that key never expires.
+func (s *Store) Set(key string, value interface{}, ttl time.Duration) error {
+	data, err := s.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	var expiry time.Time
+	if ttl > 0 {
+		expiry = time.Now().Add(ttl)
+	}
+	return s.Commit(key, data, expiry)
+}
+
+// Get decodes value of the key into value. It returns false if key does
+// not exist or is expired.
+func (s *Store) Get(key string, value interface{}) (bool, error) {
+	t, err := s.get(key)
+	if err != nil || t == nil {
+		return false, err
+	}
+	return true, s.codec.Unmarshal(t.Data, value)
+}
+
+// TTL returns time left until the key expires. It returns false if key
+// does not exist, and zero duration if key never expires.
+func (s *Store) TTL(key string) (time.Duration, bool, error) {
+	t, err := s.get(key)
+	if err != nil || t == nil {
+		return 0, false, err
+	}
+	if t.Expires == 0 {
+		return 0, true, nil
+	}
+	left := time.Duration((t.Expires - unixTime(time.Now())) * float64(time.Second))
+	return left, true, nil
+}
+
+// Delete deletes the key.
+func (s *Store) Delete(key string) error {
+	_, err := s.conn.Delete(s.spaceNo, uint32(0), []interface{}{key})
+	return err
+}
+
+// Find returns encoded data of the key, it is used by session middlewares.
+func (s *Store) Find(key string) ([]byte, bool, error) {
+	t, err := s.get(key)
+	if err != nil || t == nil {
+		return nil, false, err
+	}
+	return t.Data, true, nil
+}
+
+// Commit stores encoded data of the key until expiry, it is used by
+// session middlewares. Zero expiry means that key never expires.
+func (s *Store) Commit(key string, data []byte, expiry time.Time) error {
+	t := &tuple{Key: key, Data: data}
+	if !expiry.IsZero() {
+		t.Expires = unixTime(expiry)
+	}
+	_, err := s.conn.Replace(s.spaceNo, t)
+	return err
+}
+
+// Purge deletes expired keys and returns their number.
+// Note: it uses Eval, so user needs 'execute universe' privilege.
+func (s *Store) Purge() (int, error) {
+	var res []int
+	err := s.conn.EvalTyped(purgeLua, []interface{}{s.space, unixTime(time.Now())}, &res)
+	if err != nil || len(res) == 0 {
+		return 0, err
+	}
+	return res[0], nil
+}
@@ -0,0 +1,117 @@
+package kvstore_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/kvstore"
+)
+
+var server = "127.0.0.1:3013"
+var opts = Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+type session struct {
+	User  string
+	Visit int
+}
+
+func TestStore(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	for _, codec := range []kvstore.Codec{kvstore.MsgpackCodec, kvstore.JSONCodec} {
+		store, err := kvstore.New(conn, kvstore.Opts{Codec: codec})
+		if err != nil {
+			t.Errorf("Failed to create store: %s", err.Error())
+			return
+		}
+
+		value := session{User: "alice", Visit: 2}
+		if err = store.Set("test_key", value, 200*time.Millisecond); err != nil {
+			t.Errorf("Failed to set: %s", err.Error())
+			return
+		}
+		var got session
+		if ok, err := store.Get("test_key", &got); err != nil || !ok || got != value {
+			t.Errorf("Unexpected value: %+v, %v, %v", got, ok, err)
+		}
+		if ttl, ok, err := store.TTL("test_key"); err != nil || !ok || ttl <= 0 || ttl > 200*time.Millisecond {
+			t.Errorf("Unexpected ttl: %s, %v, %v", ttl, ok, err)
+		}
+
+		time.Sleep(300 * time.Millisecond)
+		if ok, err := store.Get("test_key", &got); err != nil || ok {
+			t.Errorf("Key is not expired: %v, %v", ok, err)
+		}
+		if n, err := store.Purge(); err != nil || n != 1 {
+			t.Errorf("Expired key is not purged: %d, %v", n, err)
+		}
+
+		if err = store.Commit("test_key", []byte("data"), time.Time{}); err != nil {
+			t.Errorf("Failed to commit: %s", err.Error())
+		}
+		if data, ok, err := store.Find("test_key"); err != nil || !ok || string(data) != "data" {
+			t.Errorf("Unexpected data: %q, %v, %v", data, ok, err)
+		}
+		if err = store.Delete("test_key"); err != nil {
+			t.Errorf("Failed to delete: %s", err.Error())
+		}
+		if _, ok, err := store.Find("test_key"); err != nil || ok {
+			t.Errorf("Key is not deleted: %v, %v", ok, err)
+		}
+	}
+}
+
+func TestCache(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	store, err := kvstore.New(conn, kvstore.Opts{Space: "test_cache"})
+	if err != nil {
+		t.Errorf("Failed to create store: %s", err.Error())
+		return
+	}
+	var lastErr error
+	cache := kvstore.NewCache(store, kvstore.CacheOpts{
+		TTL:     time.Minute,
+		OnError: func(err error) { lastErr = err },
+	})
+
+	if !cache.Set(1, "one", 1) || !cache.SetWithTTL("two", int64(2), 1, time.Millisecond) {
+		t.Errorf("Failed to set: %v", lastErr)
+		return
+	}
+	if v, ok := cache.Get(1); !ok || v != "one" {
+		t.Errorf("Unexpected value: %v, %v", v, ok)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if v, ok := cache.Get("two"); ok {
+		t.Errorf("Key is not expired: %v", v)
+	}
+
+	cache.Del(1)
+	if v, ok := cache.Get(1); ok {
+		t.Errorf("Key is not deleted: %v", v)
+	}
+	cache.Set("three", 3, 1)
+	cache.Clear()
+	if v, ok := cache.Get("three"); ok {
+		t.Errorf("Cache is not cleared: %v", v)
+	}
+	if lastErr != nil {
+		t.Errorf("Unexpected error: %s", lastErr.Error())
+	}
+}
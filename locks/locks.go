@@ -0,0 +1,290 @@
+// Package locks implements lease-based distributed locks on top of a
+// Tarantool space. A lock could be used for leader election among several
+// Go workers: the worker which holds the lock is the leader.
+//
+// A lock is held for a lease (Opts.TTL) which is refreshed in background
+// while the lock is held. Every new owner gets a greater fencing token, so
+// storages could reject writes of stale owners. Lease time is measured by
+// Tarantool clock, so workers' clocks don't need to be synchronized.
+//
+// Note: locks use Eval, so user needs 'execute universe' privilege. The
+// space should use memtx engine (see Init), because lock update relies on
+// atomicity of requests without yields.
+package locks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+var (
+	// ErrNotHeld is returned on attempt to release lock which is not held.
+	ErrNotHeld = errors.New("lock is not held")
+	// ErrHeld is returned on attempt to acquire lock which is already held
+	// by this Lock.
+	ErrHeld = errors.New("lock is already held")
+)
+
+// DefaultSpace is a name of locks space used by default.
+const DefaultSpace = "locks"
+
+// Opts is a configuration of Lock.
+type Opts struct {
+	// Space is a name of locks space, DefaultSpace by default.
+	Space string
+	// Owner identifies lock holder. By default, random id is generated.
+	Owner string
+	// TTL is a lease time, lock is lost if it is not refreshed during TTL.
+	// By default, it is 10 seconds.
+	TTL time.Duration
+	// RefreshInterval is an interval of lease refresh, TTL / 3 by default.
+	RefreshInterval time.Duration
+	// RetryInterval is a pause between attempts of Acquire, TTL / 10 by
+	// default.
+	RetryInterval time.Duration
+}
+
+const initLua = `
+local space = ...
+local s = box.schema.space.create(space, {engine = 'memtx', if_not_exists = true})
+s:create_index('primary', {parts = {1, 'string'}, if_not_exists = true})
+`
+
+// lock tuple: {name, owner, token, expires}
+const acquireLua = `
+local space, name, owner, ttl = ...
+local now = require('clock').time()
+local s = box.space[space]
+local t = s:get{name}
+if t == nil then
+    s:replace{name, owner, 1, now + ttl}
+    return true, 1
+end
+if t[4] > now and t[2] ~= owner then
+    return false, t[3]
+end
+local token = t[3]
+if t[2] ~= owner or t[4] <= now then
+    token = token + 1
+end
+s:replace{name, owner, token, now + ttl}
+return true, token
+`
+
+const refreshLua = `
+local space, name, owner, token, ttl = ...
+local now = require('clock').time()
+local s = box.space[space]
+local t = s:get{name}
+if t == nil or t[2] ~= owner or t[3] ~= token or t[4] <= now then
+    return false
+end
+s:update({name}, {{'=', 4, now + ttl}})
+return true
+`
+
+// Token is kept on release, so next owner gets a greater one.
+const releaseLua = `
+local space, name, owner, token = ...
+local s = box.space[space]
+local t = s:get{name}
+if t == nil or t[2] ~= owner or t[3] ~= token then
+    return false
+end
+s:update({name}, {{'=', 4, 0}})
+return true
+`
+
+// Init creates locks space if it doesn't exist.
+func Init(conn tarantool.Connector, space string) error {
+	if space == "" {
+		space = DefaultSpace
+	}
+	_, err := conn.Eval(initLua, []interface{}{space})
+	return err
+}
+
+// Lock is a distributed lock with a name.
+type Lock struct {
+	conn tarantool.Connector
+	name string
+	opts Opts
+
+	mutex sync.Mutex
+	held  bool
+	token uint64
+	lost  chan struct{}
+	stop  chan struct{}
+}
+
+// New creates a handle of lock with the name. Locks space should exist,
+// see Init.
+func New(conn tarantool.Connector, name string, opts Opts) *Lock {
+	if opts.Space == "" {
+		opts.Space = DefaultSpace
+	}
+	if opts.Owner == "" {
+		opts.Owner = randomOwner()
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 10 * time.Second
+	}
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = opts.TTL / 3
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = opts.TTL / 10
+	}
+	return &Lock{conn: conn, name: name, opts: opts}
+}
+
+// TryAcquire makes an attempt to acquire lock. It returns false if lock is
+// held by another owner.
+func (l *Lock) TryAcquire() (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.held {
+		return false, ErrHeld
+	}
+	var res []interface{}
+	args := []interface{}{l.opts.Space, l.name, l.opts.Owner, l.opts.TTL.Seconds()}
+	if err := l.conn.EvalTyped(acquireLua, args, &res); err != nil {
+		return false, err
+	}
+	if len(res) != 2 {
+		return false, fmt.Errorf("unexpected response: %v", res)
+	}
+	if ok, _ := res[0].(bool); !ok {
+		return false, nil
+	}
+	l.held = true
+	l.token = toUint64(res[1])
+	l.lost = make(chan struct{})
+	l.stop = make(chan struct{})
+	go l.refresher(l.token, l.lost, l.stop)
+	return true, nil
+}
+
+// Acquire waits until lock is acquired or ctx is done.
+func (l *Lock) Acquire(ctx context.Context) error {
+	for {
+		ok, err := l.TryAcquire()
+		if ok || err == ErrHeld {
+			return err
+		}
+		t := time.NewTimer(l.opts.RetryInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Token returns fencing token of the held lock. Tokens increase with every
+// change of lock owner.
+func (l *Lock) Token() uint64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.token
+}
+
+// Held reports whether lock is held at the moment.
+func (l *Lock) Held() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.held
+}
+
+// Lost returns channel which is closed when held lock is lost: lease is not
+// refreshed in time or lock is taken over by another owner. It returns
+// nil if lock is not held.
+func (l *Lock) Lost() <-chan struct{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if !l.held {
+		return nil
+	}
+	return l.lost
+}
+
+// Release releases held lock.
+func (l *Lock) Release() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if !l.held {
+		return ErrNotHeld
+	}
+	l.held = false
+	close(l.stop)
+	var res []bool
+	args := []interface{}{l.opts.Space, l.name, l.opts.Owner, l.token}
+	if err := l.conn.EvalTyped(releaseLua, args, &res); err != nil {
+		return err
+	}
+	if len(res) == 0 || !res[0] {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+func (l *Lock) refresher(token uint64, lost, stop chan struct{}) {
+	deadline := time.Now().Add(l.opts.TTL)
+	t := time.NewTicker(l.opts.RefreshInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+		var res []bool
+		args := []interface{}{l.opts.Space, l.name, l.opts.Owner, token, l.opts.TTL.Seconds()}
+		start := time.Now()
+		err := l.conn.EvalTyped(refreshLua, args, &res)
+		if err == nil && len(res) == 1 && res[0] {
+			deadline = start.Add(l.opts.TTL)
+			continue
+		}
+		if err != nil && time.Now().Before(deadline) {
+			// temporary failure, lease is not expired yet
+			continue
+		}
+		l.mutex.Lock()
+		select {
+		case <-stop:
+		default:
+			l.held = false
+			close(stop)
+			close(lost)
+		}
+		l.mutex.Unlock()
+		return
+	}
+}
+
+func randomOwner() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func toUint64(v interface{}) uint64 {
+	switch v := v.(type) {
+	case uint64:
+		return v
+	case int64:
+		return uint64(v)
+	}
+	return 0
+}
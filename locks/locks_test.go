@@ -0,0 +1,83 @@
+package locks_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/locks"
+)
+
+var server = "127.0.0.1:3013"
+var opts = Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+func TestLock(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	if err = locks.Init(conn, ""); err != nil {
+		t.Errorf("Failed to init locks space: %s", err.Error())
+		return
+	}
+
+	lockOpts := locks.Opts{TTL: 300 * time.Millisecond}
+	l1 := locks.New(conn, "test_lock", lockOpts)
+	l2 := locks.New(conn, "test_lock", lockOpts)
+
+	ok, err := l1.TryAcquire()
+	if err != nil || !ok {
+		t.Errorf("Failed to acquire lock: %v, %v", ok, err)
+		return
+	}
+	token := l1.Token()
+	if ok, err = l2.TryAcquire(); err != nil || ok {
+		t.Errorf("Lock is acquired twice: %v, %v", ok, err)
+	}
+
+	// Lease is refreshed in background.
+	time.Sleep(2 * lockOpts.TTL)
+	if !l1.Held() {
+		t.Errorf("Lock is lost")
+	}
+
+	if err = l1.Release(); err != nil {
+		t.Errorf("Failed to release lock: %s", err.Error())
+	}
+	if err = l1.Release(); err != locks.ErrNotHeld {
+		t.Errorf("Expected ErrNotHeld, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err = l2.Acquire(ctx); err != nil {
+		t.Errorf("Failed to acquire released lock: %s", err.Error())
+		return
+	}
+	if l2.Token() <= token {
+		t.Errorf("Fencing token is not increased: %d <= %d", l2.Token(), token)
+	}
+
+	// Lock is lost when lease is taken over.
+	lost := l2.Lost()
+	if _, err = conn.Eval("box.space.locks:update({'test_lock'}, {{'=', 2, 'other'}})", []interface{}{}); err != nil {
+		t.Errorf("Failed to take over lock: %s", err.Error())
+		return
+	}
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Errorf("Lock loss is not reported")
+	}
+	if l2.Held() {
+		t.Errorf("Lost lock is reported as held")
+	}
+}
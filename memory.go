@@ -0,0 +1,82 @@
+package tarantool
+
+import (
+	"bufio"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+)
+
+// memoryLimiter accounts memory used by buffered requests and responses.
+type memoryLimiter struct {
+	max  int64
+	used int64
+}
+
+// reserve accounts n bytes if they fit into the limit.
+func (m *memoryLimiter) reserve(n int) bool {
+	if m == nil {
+		return true
+	}
+	for {
+		used := atomic.LoadInt64(&m.used)
+		if used+int64(n) > m.max {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&m.used, used, used+int64(n)) {
+			return true
+		}
+	}
+}
+
+func (m *memoryLimiter) release(n int) {
+	if m == nil || n == 0 {
+		return
+	}
+	atomic.AddInt64(&m.used, -int64(n))
+}
+
+func (m *memoryLimiter) usage() int64 {
+	if m == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&m.used)
+}
+
+func memoryLimitError(what string, size int, max int64) ClientError {
+	return ClientError{
+		ErrMemoryLimit,
+		fmt.Sprintf("%s of %d bytes exceeds memory limit of %d bytes", what, size, max),
+	}
+}
+
+// skipResponse discards response which does not fit into memory limit and
+// fails its request.
+func (conn *Connection) skipResponse(r *bufio.Reader, length int) error {
+	var fut *Future
+	if resp, _, ok := conn.peekHeader(r, length); ok {
+		fut = conn.fetchFuture(resp.RequestId)
+	}
+	if _, err := r.Discard(length); err != nil {
+		return err
+	}
+	if fut != nil {
+		fut.err = memoryLimitError("response", length, conn.memory.max)
+		fut.markReady(conn)
+	}
+	return nil
+}
+
+// holdMemory keeps n bytes of response accounted until Future is retrieved
+// or garbage collected.
+func (fut *Future) holdMemory(n int) {
+	atomic.StoreInt64(&fut.memory, int64(n))
+	runtime.SetFinalizer(fut, (*Future).releaseMemory)
+}
+
+// releaseMemory releases memory of response when it is retrieved.
+func (fut *Future) releaseMemory() {
+	if n := atomic.SwapInt64(&fut.memory, 0); n > 0 {
+		fut.conn.memory.release(int(n))
+	}
+}
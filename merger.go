@@ -0,0 +1,112 @@
+package tarantool
+
+import (
+	"container/heap"
+	"fmt"
+)
+
+// MergeOpts is a configuration of MergeTuples.
+type MergeOpts struct {
+	// Desc means that sources are sorted in descending order of keys.
+	Desc bool
+	// Offset is a number of merged tuples to skip.
+	Offset int
+	// Limit is a maximum number of merged tuples, zero means no limit.
+	Limit int
+}
+
+// MergeTuples merges tuples of sources sorted by index key into one
+// sorted sequence, like merger module of Tarantool does. It is intended
+// for reads of a sharded space: results of the same select on each shard
+// are merged into a result of select on the whole space.
+func MergeTuples(index *Index, opts MergeOpts, sources ...[][]interface{}) ([][]interface{}, error) {
+	h := &mergeHeap{index: index, desc: opts.Desc}
+	for _, src := range sources {
+		if len(src) > 0 {
+			h.cursors = append(h.cursors, mergeCursor{tuples: src})
+		}
+	}
+	// comparison errors are reported by heap operations through h.err
+	heap.Init(h)
+	var res [][]interface{}
+	for skipped := 0; h.Len() > 0 && h.err == nil; {
+		if opts.Limit > 0 && len(res) == opts.Limit {
+			break
+		}
+		cur := &h.cursors[0]
+		tuple := cur.tuples[cur.pos]
+		if cur.pos++; cur.pos == len(cur.tuples) {
+			heap.Pop(h)
+		} else {
+			heap.Fix(h, 0)
+		}
+		if skipped < opts.Offset {
+			skipped++
+			continue
+		}
+		res = append(res, tuple)
+	}
+	if h.err != nil {
+		return nil, h.err
+	}
+	return res, nil
+}
+
+// MergeResponses merges tuples of select responses, see MergeTuples.
+func MergeResponses(index *Index, opts MergeOpts, responses ...*Response) ([][]interface{}, error) {
+	sources := make([][][]interface{}, 0, len(responses))
+	for _, resp := range responses {
+		tuples := make([][]interface{}, 0, len(resp.Data))
+		for _, row := range resp.Data {
+			tuple, ok := row.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("unexpected tuple type %T", row)
+			}
+			tuples = append(tuples, tuple)
+		}
+		sources = append(sources, tuples)
+	}
+	return MergeTuples(index, opts, sources...)
+}
+
+type mergeCursor struct {
+	tuples [][]interface{}
+	pos    int
+}
+
+type mergeHeap struct {
+	index   *Index
+	desc    bool
+	cursors []mergeCursor
+	err     error
+}
+
+func (h *mergeHeap) Len() int {
+	return len(h.cursors)
+}
+
+func (h *mergeHeap) Less(i, j int) bool {
+	a, b := h.cursors[i], h.cursors[j]
+	c, err := h.index.Compare(a.tuples[a.pos], b.tuples[b.pos])
+	if err != nil && h.err == nil {
+		h.err = err
+	}
+	if h.desc {
+		return c > 0
+	}
+	return c < 0
+}
+
+func (h *mergeHeap) Swap(i, j int) {
+	h.cursors[i], h.cursors[j] = h.cursors[j], h.cursors[i]
+}
+
+func (h *mergeHeap) Push(x interface{}) {
+	h.cursors = append(h.cursors, x.(mergeCursor))
+}
+
+func (h *mergeHeap) Pop() interface{} {
+	last := h.cursors[len(h.cursors)-1]
+	h.cursors = h.cursors[:len(h.cursors)-1]
+	return last
+}
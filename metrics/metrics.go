@@ -0,0 +1,349 @@
+// Package metrics scrapes tarantool/metrics Lua module of an instance and
+// re-exports collected metrics in Prometheus text format, so metrics of
+// instances are scraped together with metrics of the application:
+//
+//	s := metrics.NewScraper(conn)
+//	s.ConstLabels = map[string]string{"instance": "storage-1"}
+//	http.Handle("/metrics/tarantool", s)
+//
+// Metrics are collected by Lua code sent with Eval, so user needs
+// 'execute universe' privilege.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// collectLua returns families of metrics as
+// {{name, kind, help, {{metric_name, label_pairs, value, timestamp}, ...}}, ...}.
+const collectLua = `
+local ok, metrics = pcall(require, 'metrics')
+if not ok then
+    error('metrics module is not available: ' .. tostring(metrics))
+end
+if metrics.invoke_callbacks ~= nil then
+    metrics.invoke_callbacks()
+end
+local families = {}
+for _, c in pairs(metrics.registry.collectors) do
+    local samples = {}
+    for _, obs in pairs(c:collect()) do
+        table.insert(samples, {obs.metric_name, obs.label_pairs, obs.value, obs.timestamp})
+    end
+    table.insert(families, {c.name, c.kind, c.help or '', samples})
+end
+return families
+`
+
+// Kind is a kind of metric.
+type Kind string
+
+const (
+	Counter   = Kind("counter")
+	Gauge     = Kind("gauge")
+	Histogram = Kind("histogram")
+	Summary   = Kind("summary")
+)
+
+// Sample is an observed value of metric with labels. Histograms and
+// summaries consist of several samples, e.g. name_bucket, name_sum and
+// name_count for histograms.
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+	// Timestamp is a time of observation in microseconds since epoch.
+	Timestamp int64
+}
+
+// Family is a metric with all its samples.
+type Family struct {
+	Name    string
+	Kind    Kind
+	Help    string
+	Samples []Sample
+}
+
+// Bucket is a bucket of histogram.
+type Bucket struct {
+	// UpperBound is an inclusive upper bound of values, label le.
+	UpperBound float64
+	// Count is a cumulative count of values not greater than UpperBound.
+	Count float64
+}
+
+// HistogramValue is a histogram with a set of labels.
+type HistogramValue struct {
+	Labels  map[string]string
+	Buckets []Bucket
+	Sum     float64
+	Count   float64
+}
+
+// Histograms returns histograms of histogram family, grouped by labels
+// other than le. Buckets are ordered by upper bound.
+func (f *Family) Histograms() []HistogramValue {
+	if f.Kind != Histogram {
+		return nil
+	}
+	var hists []HistogramValue
+	index := make(map[string]int)
+	for _, s := range f.Samples {
+		labels := make(map[string]string, len(s.Labels))
+		for k, v := range s.Labels {
+			if k != "le" {
+				labels[k] = v
+			}
+		}
+		key := formatLabels(labels, nil)
+		i, ok := index[key]
+		if !ok {
+			i = len(hists)
+			index[key] = i
+			hists = append(hists, HistogramValue{Labels: labels})
+		}
+		h := &hists[i]
+		switch s.Name {
+		case f.Name + "_bucket":
+			le, err := strconv.ParseFloat(s.Labels["le"], 64)
+			if err == nil {
+				h.Buckets = append(h.Buckets, Bucket{UpperBound: le, Count: s.Value})
+			}
+		case f.Name + "_sum":
+			h.Sum = s.Value
+		case f.Name + "_count":
+			h.Count = s.Value
+		}
+	}
+	for _, h := range hists {
+		sort.Slice(h.Buckets, func(i, j int) bool {
+			return h.Buckets[i].UpperBound < h.Buckets[j].UpperBound
+		})
+	}
+	return hists
+}
+
+// DecodeMsgpack decodes family from {name, kind, help, samples}.
+func (f *Family) DecodeMsgpack(d *msgpack.Decoder) error {
+	n, err := d.DecodeSliceLen()
+	if err != nil {
+		return err
+	}
+	if n != 4 {
+		return fmt.Errorf("metrics family has %d fields", n)
+	}
+	if f.Name, err = d.DecodeString(); err != nil {
+		return err
+	}
+	var kind string
+	if kind, err = d.DecodeString(); err != nil {
+		return err
+	}
+	f.Kind = Kind(kind)
+	if f.Help, err = d.DecodeString(); err != nil {
+		return err
+	}
+	if n, err = d.DecodeSliceLen(); err != nil {
+		return err
+	}
+	f.Samples = make([]Sample, 0, n)
+	for ; n > 0; n-- {
+		var s Sample
+		if err = s.DecodeMsgpack(d); err != nil {
+			return err
+		}
+		f.Samples = append(f.Samples, s)
+	}
+	return nil
+}
+
+// DecodeMsgpack decodes sample from {metric_name, label_pairs, value,
+// timestamp}.
+func (s *Sample) DecodeMsgpack(d *msgpack.Decoder) error {
+	n, err := d.DecodeSliceLen()
+	if err != nil {
+		return err
+	}
+	if n != 4 {
+		return fmt.Errorf("metrics sample has %d fields", n)
+	}
+	if s.Name, err = d.DecodeString(); err != nil {
+		return err
+	}
+	labels, err := d.DecodeInterface()
+	if err != nil {
+		return err
+	}
+	// empty Lua table is encoded as array
+	s.Labels = make(map[string]string)
+	if m, ok := labels.(map[interface{}]interface{}); ok {
+		for k, v := range m {
+			s.Labels[fmt.Sprint(k)] = formatValue(v)
+		}
+	}
+	if s.Value, err = d.DecodeFloat64(); err != nil {
+		return err
+	}
+	ts, err := d.DecodeInterface()
+	if err != nil {
+		return err
+	}
+	switch ts := ts.(type) {
+	case int64:
+		s.Timestamp = ts
+	case uint64:
+		s.Timestamp = int64(ts)
+	case float64:
+		s.Timestamp = int64(ts)
+	}
+	return nil
+}
+
+// formatValue formats label value, numbers are formatted as in
+// Prometheus, e.g. le label of histogram buckets.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case float64:
+		return formatFloat(v)
+	case float32:
+		return formatFloat(float64(v))
+	}
+	return fmt.Sprint(v)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Collect collects metrics of instance, families are ordered by name.
+func Collect(conn tarantool.Connector) ([]Family, error) {
+	var res collectResult
+	if err := conn.EvalTyped(collectLua, []interface{}{}, &res); err != nil {
+		return nil, err
+	}
+	families := res.families
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].Name < families[j].Name
+	})
+	return families, nil
+}
+
+// collectResult decodes families returned by collectLua.
+type collectResult struct {
+	families []Family
+}
+
+func (r *collectResult) DecodeMsgpack(d *msgpack.Decoder) error {
+	n, err := d.DecodeSliceLen()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return fmt.Errorf("unexpected number of results %d", n)
+	}
+	return d.Decode(&r.families)
+}
+
+// Scraper collects metrics of instance on each HTTP request and writes
+// them in Prometheus text format.
+type Scraper struct {
+	conn tarantool.Connector
+	// ConstLabels are added to all samples, e.g. instance name.
+	ConstLabels map[string]string
+}
+
+// NewScraper creates scraper of instance.
+func NewScraper(conn tarantool.Connector) *Scraper {
+	return &Scraper{conn: conn}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Scraper) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	families, err := Collect(s.conn)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	WriteText(w, families, s.ConstLabels)
+}
+
+// WriteText writes families in Prometheus text format, constLabels are
+// added to all samples.
+func WriteText(w io.Writer, families []Family, constLabels map[string]string) error {
+	for _, f := range families {
+		kind := f.Kind
+		switch kind {
+		case Counter, Gauge, Histogram, Summary:
+		default:
+			kind = "untyped"
+		}
+		if f.Help != "" {
+			if _, err := fmt.Fprintf(w, "# HELP %s %s\n", f.Name, escapeHelp(f.Help)); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "# TYPE %s %s\n", f.Name, kind); err != nil {
+			return err
+		}
+		for _, s := range f.Samples {
+			if _, err := fmt.Fprintf(w, "%s%s %s\n", s.Name,
+				formatLabels(s.Labels, constLabels), formatFloat(s.Value)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+// formatLabels formats labels ordered by name, labels override
+// constLabels.
+func formatLabels(labels, constLabels map[string]string) string {
+	merged := make(map[string]string, len(labels)+len(constLabels))
+	for k, v := range constLabels {
+		merged[k] = v
+	}
+	for k, v := range labels {
+		merged[k] = v
+	}
+	if len(merged) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(merged))
+	for k := range merged {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, k := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `%s="%s"`, k, escaper.Replace(merged[k]))
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
@@ -0,0 +1,98 @@
+package metrics_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/metrics"
+)
+
+var server = "127.0.0.1:3013"
+var opts = Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+var families = []metrics.Family{
+	{
+		Name: "http_requests",
+		Kind: metrics.Counter,
+		Help: "Number of \"HTTP\" requests",
+		Samples: []metrics.Sample{
+			{Name: "http_requests", Labels: map[string]string{"path": "/a\"b"}, Value: 3},
+		},
+	},
+	{
+		Name: "latency",
+		Kind: metrics.Histogram,
+		Samples: []metrics.Sample{
+			{Name: "latency_count", Labels: map[string]string{}, Value: 3},
+			{Name: "latency_sum", Labels: map[string]string{}, Value: 1.5},
+			{Name: "latency_bucket", Labels: map[string]string{"le": "+Inf"}, Value: 3},
+			{Name: "latency_bucket", Labels: map[string]string{"le": "0.5"}, Value: 2},
+		},
+	},
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := metrics.WriteText(&buf, families, map[string]string{"instance": "i1"}); err != nil {
+		t.Errorf("Failed to write metrics: %s", err.Error())
+		return
+	}
+	expected := `# HELP http_requests Number of "HTTP" requests
+# TYPE http_requests counter
+http_requests{instance="i1",path="/a\"b"} 3
+# TYPE latency histogram
+latency_count{instance="i1"} 3
+latency_sum{instance="i1"} 1.5
+latency_bucket{instance="i1",le="+Inf"} 3
+latency_bucket{instance="i1",le="0.5"} 2
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected metrics:\n%s", buf.String())
+	}
+}
+
+func TestHistograms(t *testing.T) {
+	if families[0].Histograms() != nil {
+		t.Errorf("Histograms of counter")
+	}
+	hists := families[1].Histograms()
+	if len(hists) != 1 {
+		t.Errorf("Unexpected histograms: %v", hists)
+		return
+	}
+	h := hists[0]
+	if h.Count != 3 || h.Sum != 1.5 || len(h.Buckets) != 2 ||
+		h.Buckets[0].UpperBound != 0.5 || !math.IsInf(h.Buckets[1].UpperBound, 1) {
+		t.Errorf("Unexpected histogram: %v", h)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	families, err := metrics.Collect(conn)
+	if err != nil {
+		if strings.Contains(err.Error(), "metrics module is not available") {
+			t.Skip("metrics module is not installed")
+		}
+		t.Errorf("Failed to collect metrics: %s", err.Error())
+		return
+	}
+	var buf bytes.Buffer
+	if err = metrics.WriteText(&buf, families, nil); err != nil {
+		t.Errorf("Failed to write metrics: %s", err.Error())
+	}
+}
@@ -0,0 +1,30 @@
+package multi
+
+import (
+	"github.com/tarantool/go-tarantool"
+)
+
+func (connMulti *ConnectionMulti) getConnOpts() tarantool.Opts {
+	connMulti.connOptsMutex.Lock()
+	defer connMulti.connOptsMutex.Unlock()
+	return connMulti.connOpts
+}
+
+// Authenticate re-authenticates connections to all instances as user of
+// req, see tarantool.Connection.Authenticate. It returns the first error,
+// other connections are authenticated regardless of it. If all
+// connections are authenticated, connections created later are
+// authenticated as the user too.
+func (connMulti *ConnectionMulti) Authenticate(req *tarantool.Auth) (err error) {
+	for _, conn := range connMulti.Instances() {
+		if aerr := conn.Authenticate(req); err == nil {
+			err = aerr
+		}
+	}
+	if err == nil {
+		connMulti.connOptsMutex.Lock()
+		connMulti.connOpts = connMulti.connOpts.WithAuth(req)
+		connMulti.connOptsMutex.Unlock()
+	}
+	return
+}
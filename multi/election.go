@@ -0,0 +1,95 @@
+package multi
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// ElectionInfo is a state of Raft election on an instance,
+// see box.info.election.
+type ElectionInfo struct {
+	// State is "leader", "follower" or "candidate".
+	State string `msgpack:"state"`
+	// Term is a current election term.
+	Term uint64 `msgpack:"term"`
+	// Vote is an instance id this instance voted for in current term.
+	Vote uint64 `msgpack:"vote"`
+	// Leader is an instance id of the current leader, it is zero
+	// if leader is unknown.
+	Leader uint64 `msgpack:"leader"`
+}
+
+// IsLeader reports if instance is a Raft leader.
+func (info ElectionInfo) IsLeader() bool {
+	return info.State == "leader"
+}
+
+// Election returns last known election state of an instance.
+// Election state is polled only if OptsMulti.LeaderOnly is set.
+func (connMulti *ConnectionMulti) Election(addr string) (info ElectionInfo, ok bool) {
+	connMulti.mutex.RLock()
+	defer connMulti.mutex.RUnlock()
+	info, ok = connMulti.election[addr]
+	return
+}
+
+func (connMulti *ConnectionMulti) pollElection() {
+	connMulti.mutex.RLock()
+	conns := make(map[string]*tarantool.Connection, len(connMulti.pool))
+	for addr, conn := range connMulti.pool {
+		conns[addr] = conn
+	}
+	connMulti.mutex.RUnlock()
+
+	election := make(map[string]ElectionInfo, len(conns))
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for addr, conn := range conns {
+		if !conn.ConnectedNow() {
+			continue
+		}
+		wg.Add(1)
+		go func(addr string, conn *tarantool.Connection) {
+			defer wg.Done()
+			// box.info.election is nil on instances without election support
+			fut := conn.EvalAsync("return box.info.election or {}", []interface{}{})
+			timer := time.NewTimer(connMulti.opts.CheckTimeout)
+			defer timer.Stop()
+			select {
+			case <-fut.WaitChan():
+			case <-timer.C:
+				return
+			}
+			var res []ElectionInfo
+			if err := fut.GetTyped(&res); err != nil || len(res) != 1 {
+				return
+			}
+			mutex.Lock()
+			election[addr] = res[0]
+			mutex.Unlock()
+		}(addr, conn)
+	}
+	wg.Wait()
+
+	connMulti.mutex.Lock()
+	connMulti.election = election
+	connMulti.mutex.Unlock()
+}
+
+// getLeaderConnection returns connection to the leader with the highest
+// term. It should be called under connMulti.mutex.
+func (connMulti *ConnectionMulti) getLeaderConnection() *tarantool.Connection {
+	var leader *tarantool.Connection
+	var term uint64
+	for addr, info := range connMulti.election {
+		if !info.IsLeader() || info.Term < term {
+			continue
+		}
+		if conn := connMulti.pool[addr]; conn != nil && conn.ConnectedNow() {
+			leader, term = conn, info.Term
+		}
+	}
+	return leader
+}
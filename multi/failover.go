@@ -0,0 +1,54 @@
+package multi
+
+import (
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// failoverRetryInterval is a pause between attempts while there are no
+// connected instances.
+const failoverRetryInterval = 10 * time.Millisecond
+
+// isConnectionError reports if request failed because its connection
+// was closed or is not established yet.
+func isConnectionError(err error) bool {
+	if clientErr, ok := err.(tarantool.ClientError); ok {
+		return clientErr.Code == tarantool.ErrConnectionClosed ||
+			clientErr.Code == tarantool.ErrConnectionNotReady
+	}
+	return false
+}
+
+// isReadOnlyFunction reports if function is listed in
+// OptsMulti.ReadOnlyFunctions.
+func (connMulti *ConnectionMulti) isReadOnlyFunction(functionName string) bool {
+	return indexOf(functionName, connMulti.opts.ReadOnlyFunctions) >= 0
+}
+
+// failover performs request with connection chosen by route. If request is
+// idempotent and fails on connection error, it is resent with a newly
+// chosen connection until OptsMulti.FailoverTimeout passes.
+func (connMulti *ConnectionMulti) failover(idempotent bool, route func() *tarantool.Connection,
+	req func(*tarantool.Connection) error) error {
+	conn := route()
+	if conn == nil {
+		return ErrNoInstance
+	}
+	err := req(conn)
+	if !idempotent || connMulti.opts.FailoverTimeout <= 0 {
+		return err
+	}
+
+	deadline := time.Now().Add(connMulti.opts.FailoverTimeout)
+	for isConnectionError(err) && connMulti.getState() != connClosed && time.Now().Before(deadline) {
+		next := route()
+		if next == nil || !next.ConnectedNow() {
+			time.Sleep(failoverRetryInterval)
+			continue
+		}
+		conn = next
+		err = req(conn)
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package multi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// MapOpts is a configuration of Map.
+type MapOpts struct {
+	// Addrs is a subset of instances to send request to. By default,
+	// request is sent to all instances of the pool.
+	Addrs []string
+	// Concurrency is a maximum number of requests in flight. By default,
+	// requests are sent to all instances at once.
+	Concurrency int
+	// AllowPartial passes results to combine function even if request
+	// failed on some instances. By default, Map fails on the first error.
+	AllowPartial bool
+}
+
+// MapResult is a result of request on an instance.
+type MapResult struct {
+	Addr     string
+	Response *tarantool.Response
+	Err      error
+}
+
+// Map sends request built by req to instances of the pool concurrently,
+// collects their responses and passes them to combine function in order
+// of instances. Instances which are not connected fail with
+// ErrNoConnection. If ctx is done, requests which are not answered yet
+// fail with ctx error.
+func (connMulti *ConnectionMulti) Map(ctx context.Context, req func(tarantool.Connector) *tarantool.Future,
+	combine func(results []MapResult) error, opts MapOpts) error {
+	addrs := opts.Addrs
+	if len(addrs) == 0 {
+		connMulti.mutex.RLock()
+		addrs = append([]string(nil), connMulti.addrs...)
+		connMulti.mutex.RUnlock()
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 || concurrency > len(addrs) {
+		concurrency = len(addrs)
+	}
+
+	results := make([]MapResult, len(addrs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		results[i].Addr = addr
+		conn, _ := connMulti.getConnectionFromPool(addr)
+		if conn == nil || !conn.ConnectedNow() {
+			results[i].Err = ErrNoConnection
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(res *MapResult, conn *tarantool.Connection) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fut := req(conn)
+			select {
+			case <-fut.WaitChan():
+				res.Response, res.Err = fut.Get()
+			case <-ctx.Done():
+				res.Err = ctx.Err()
+			}
+		}(&results[i], conn)
+	}
+	wg.Wait()
+
+	if !opts.AllowPartial {
+		for _, res := range results {
+			if res.Err != nil {
+				return fmt.Errorf("%s: %s", res.Addr, res.Err)
+			}
+		}
+	}
+	return combine(results)
+}
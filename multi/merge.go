@@ -0,0 +1,86 @@
+package multi
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// SelectMerged performs select on all instances of the pool and merges
+// their sorted results by index key, see tarantool.MergeTuples. It is
+// intended for spaces sharded across instances of the pool.
+//
+// Each instance is asked for offset+limit tuples, offset and limit are
+// applied to merged result. As select of a single instance, select with
+// zero limit returns no tuples. Select fails if any instance of the pool
+// is not connected or fails, since partial result of a sharded space is
+// not correct.
+func (connMulti *ConnectionMulti) SelectMerged(space, index interface{}, offset, limit, iterator uint32, key interface{}) ([][]interface{}, error) {
+	conns, err := connMulti.getAllConnections()
+	if err != nil {
+		return nil, err
+	}
+	indexDesc, err := conns[0].ResolveIndex(space, index)
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 {
+		return [][]interface{}{}, nil
+	}
+
+	futures := make([]*tarantool.Future, len(conns))
+	for i, conn := range conns {
+		futures[i] = conn.SelectAsync(space, index, 0, mergedLimit(offset, limit), iterator, key)
+	}
+	responses := make([]*tarantool.Response, len(conns))
+	for i, fut := range futures {
+		if responses[i], err = fut.Get(); err != nil {
+			return nil, fmt.Errorf("%s: %s", conns[i].Addr(), err)
+		}
+	}
+
+	var desc bool
+	switch iterator {
+	case tarantool.IterReq, tarantool.IterLt, tarantool.IterLe:
+		desc = true
+	}
+	return tarantool.MergeResponses(indexDesc, tarantool.MergeOpts{
+		Desc:   desc,
+		Offset: int(offset),
+		Limit:  int(limit),
+	}, responses...)
+}
+
+// getAllConnections returns connections to all instances of the pool or
+// fails if any of them is not connected.
+func (connMulti *ConnectionMulti) getAllConnections() ([]*tarantool.Connection, error) {
+	connMulti.mutex.RLock()
+	n := len(connMulti.addrs)
+	connMulti.mutex.RUnlock()
+	connMulti.connectLazy(n)
+
+	connMulti.mutex.RLock()
+	defer connMulti.mutex.RUnlock()
+	conns := make([]*tarantool.Connection, 0, len(connMulti.addrs))
+	for _, addr := range connMulti.addrs {
+		conn := connMulti.pool[addr]
+		if conn == nil || !conn.ConnectedNow() {
+			return nil, fmt.Errorf("%s: %s", addr, ErrNoConnection)
+		}
+		conns = append(conns, conn)
+	}
+	if len(conns) == 0 {
+		return nil, ErrNoConnection
+	}
+	return conns, nil
+}
+
+// mergedLimit returns limit of select on each instance: offset+limit
+// capped by math.MaxUint32, which is used to select all tuples.
+func mergedLimit(offset, limit uint32) uint32 {
+	if limit > math.MaxUint32-offset {
+		return math.MaxUint32
+	}
+	return offset + limit
+}
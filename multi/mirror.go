@@ -0,0 +1,119 @@
+package multi
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// defaultMaxMirrors is a default limit of mirrored requests in progress.
+const defaultMaxMirrors = 64
+
+// Mirror is a result of a read request mirrored to OptsMulti.Shadow.
+type Mirror struct {
+	// Latency is a latency of the request on the pool.
+	Latency time.Duration
+	// ShadowLatency is a latency of the mirrored request on shadow.
+	ShadowLatency time.Duration
+	// Err is an error of the mirrored request.
+	Err error
+}
+
+// Delta returns difference of latencies of shadow and of the pool.
+func (m Mirror) Delta() time.Duration {
+	return m.ShadowLatency - m.Latency
+}
+
+// MirrorStats contains counters of mirrored requests.
+type MirrorStats struct {
+	// Mirrored is a number of mirrored requests completed on shadow.
+	Mirrored uint64
+	// Failed is a number of mirrored requests failed on shadow.
+	Failed uint64
+	// Skipped is a number of requests not mirrored due to
+	// OptsMulti.MaxMirrors.
+	Skipped uint64
+	// Delta is an average difference of latencies of shadow and of the
+	// pool, see Mirror.Delta.
+	Delta time.Duration
+}
+
+// mirrorStats are counters of mirrored requests.
+type mirrorStats struct {
+	inFlight int64
+	mirrored uint64
+	failed   uint64
+	skipped  uint64
+	delta    int64
+}
+
+// MirrorStats returns counters of requests mirrored to OptsMulti.Shadow.
+func (connMulti *ConnectionMulti) MirrorStats() MirrorStats {
+	s := &connMulti.mirrors
+	stats := MirrorStats{
+		Mirrored: atomic.LoadUint64(&s.mirrored),
+		Failed:   atomic.LoadUint64(&s.failed),
+		Skipped:  atomic.LoadUint64(&s.skipped),
+	}
+	if n := stats.Mirrored + stats.Failed; n > 0 {
+		stats.Delta = time.Duration(atomic.LoadInt64(&s.delta) / int64(n))
+	}
+	return stats
+}
+
+// mirror sends read request to OptsMulti.Shadow in background with
+// probability OptsMulti.MirrorPercent. Start is a start time of the
+// request on the pool, its result is ignored.
+func (connMulti *ConnectionMulti) mirror(start time.Time, req func(tarantool.Connector) error) {
+	shadow := connMulti.opts.Shadow
+	if shadow == nil || rand.Float64()*100 >= connMulti.opts.MirrorPercent {
+		return
+	}
+	latency := time.Since(start)
+	s := &connMulti.mirrors
+	if atomic.AddInt64(&s.inFlight, 1) > int64(connMulti.opts.MaxMirrors) {
+		atomic.AddInt64(&s.inFlight, -1)
+		atomic.AddUint64(&s.skipped, 1)
+		return
+	}
+	go func() {
+		defer atomic.AddInt64(&s.inFlight, -1)
+		shadowStart := time.Now()
+		err := req(shadow)
+		m := Mirror{Latency: latency, ShadowLatency: time.Since(shadowStart), Err: err}
+		if err != nil {
+			atomic.AddUint64(&s.failed, 1)
+		} else {
+			atomic.AddUint64(&s.mirrored, 1)
+		}
+		atomic.AddInt64(&s.delta, int64(m.Delta()))
+		if connMulti.opts.OnMirror != nil {
+			connMulti.opts.OnMirror(m)
+		}
+	}()
+}
+
+func (connMulti *ConnectionMulti) mirrorSelect(start time.Time, space, index interface{}, offset, limit, iterator uint32, key interface{}) {
+	connMulti.mirror(start, func(shadow tarantool.Connector) error {
+		_, err := shadow.Select(space, index, offset, limit, iterator, key)
+		return err
+	})
+}
+
+// mirrorCall mirrors call of function if it is listed in
+// OptsMulti.ReadOnlyFunctions.
+func (connMulti *ConnectionMulti) mirrorCall(start time.Time, call17 bool, functionName string, args interface{}) {
+	if !connMulti.isReadOnlyFunction(functionName) {
+		return
+	}
+	connMulti.mirror(start, func(shadow tarantool.Connector) (err error) {
+		if call17 {
+			_, err = shadow.Call17(functionName, args)
+		} else {
+			_, err = shadow.Call(functionName, args)
+		}
+		return
+	})
+}
@@ -1,6 +1,7 @@
 package multi
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"sync/atomic"
@@ -18,6 +19,18 @@ var (
 	ErrEmptyAddrs        = errors.New("addrs should not be empty")
 	ErrWrongCheckTimeout = errors.New("wrong check timeout, must be greater than 0")
 	ErrNoConnection      = errors.New("no active connections")
+	ErrWrongMinAvailable = errors.New("wrong min available, must be in range [0, len(addrs)]")
+)
+
+// ConnectMode defines when pool connects to instances.
+type ConnectMode int
+
+const (
+	// ConnectEager connects to all instances at start.
+	ConnectEager ConnectMode = iota
+	// ConnectLazy connects to MinAvailable instances at start, the rest are
+	// connected on first use.
+	ConnectLazy
 )
 
 func indexOf(sstring string, data []string) int {
@@ -30,9 +43,13 @@ func indexOf(sstring string, data []string) int {
 }
 
 type ConnectionMulti struct {
-	addrs    []string
-	connOpts tarantool.Opts
-	opts     OptsMulti
+	addrs []string
+	opts  OptsMulti
+
+	// connOpts are options of new connections, they are guarded by
+	// connOptsMutex since credentials may be changed by Authenticate
+	connOptsMutex sync.Mutex
+	connOpts      tarantool.Opts
 
 	mutex    sync.RWMutex
 	notify   chan tarantool.ConnEvent
@@ -40,6 +57,16 @@ type ConnectionMulti struct {
 	control  chan struct{}
 	pool     map[string]*tarantool.Connection
 	fallback *tarantool.Connection
+	election map[string]ElectionInfo
+	readOnly map[string]bool
+	vclocks  map[string]Vclock
+	latency  map[string]time.Duration
+	mirrors  mirrorStats
+
+	// rolesOnce starts polling of read-only state and vclocks of
+	// instances, rolesTracked is set after that
+	rolesOnce    sync.Once
+	rolesTracked uint32
 }
 
 var _ = tarantool.Connector(&ConnectionMulti{}) // check compatibility with connector interface
@@ -48,6 +75,57 @@ type OptsMulti struct {
 	CheckTimeout         time.Duration
 	NodesGetFunctionName string
 	ClusterDiscoveryTime time.Duration
+	// LeaderOnly routes requests to the current Raft leader of the cluster,
+	// which is tracked by polling box.info.election every CheckTimeout.
+	// Instances that do not respond within CheckTimeout are skipped.
+	// While leader is unknown, requests are routed as usual.
+	LeaderOnly bool
+	// ConnectMode defines whether instances are connected at start or on
+	// first use. Default is ConnectEager.
+	ConnectMode ConnectMode
+	// MinAvailable is a number of connected instances required to start
+	// the pool and to report it as ready. Default is 1.
+	MinAvailable int
+	// FailoverTimeout enables resending of idempotent requests that failed
+	// because their connection was closed. Requests are resent on other
+	// instances until FailoverTimeout passes since the first failure.
+	// Idempotent requests are selects, calls of ReadOnlyFunctions and
+	// requests performed with Do and RouteHint.Idempotent.
+	FailoverTimeout time.Duration
+	// ReadOnlyFunctions is a list of functions that do not modify data,
+	// so their calls may be resent on failover.
+	ReadOnlyFunctions []string
+	// Zones maps instance address to its zone (e.g. datacenter).
+	Zones map[string]string
+	// LocalZone enables zone aware routing: requests are routed to the
+	// instance with the least latency, and CrossZonePenalty is added to
+	// latency of instances out of LocalZone. Latency is measured every
+	// CheckTimeout.
+	LocalZone string
+	// TrackRoles enables polling of read-only state, vclock and latency of
+	// instances every CheckTimeout since the start. Without it polling is
+	// started on first use of routing by Mode, sessions or zone health,
+	// and with LocalZone.
+	TrackRoles bool
+	// CrossZonePenalty is added to latency of instances out of LocalZone.
+	// By default, they are used only if there are no connected instances
+	// in LocalZone.
+	CrossZonePenalty time.Duration
+	// Shadow is a connector to a shadow environment, e.g. to a new cluster
+	// under load testing. MirrorPercent percents of read requests are
+	// mirrored to it in background, their results are ignored. Read
+	// requests are selects and calls of ReadOnlyFunctions. Shadow is not
+	// closed with the pool.
+	Shadow tarantool.Connector
+	// MirrorPercent is a percentage of read requests mirrored to Shadow,
+	// from 0 to 100.
+	MirrorPercent float64
+	// MaxMirrors limits number of mirrored requests in progress, requests
+	// beyond it are not mirrored. Default is 64.
+	MaxMirrors int
+	// OnMirror is called after every mirrored request with latencies of
+	// the request on the pool and on Shadow.
+	OnMirror func(Mirror)
 }
 
 func ConnectWithOpts(addrs []string, connOpts tarantool.Opts, opts OptsMulti) (connMulti *ConnectionMulti, err error) {
@@ -60,6 +138,15 @@ func ConnectWithOpts(addrs []string, connOpts tarantool.Opts, opts OptsMulti) (c
 	if opts.ClusterDiscoveryTime <= 0 {
 		opts.ClusterDiscoveryTime = 60 * time.Second
 	}
+	if opts.MinAvailable < 0 || opts.MinAvailable > len(addrs) {
+		return nil, ErrWrongMinAvailable
+	}
+	if opts.MinAvailable == 0 {
+		opts.MinAvailable = 1
+	}
+	if opts.MaxMirrors <= 0 {
+		opts.MaxMirrors = defaultMaxMirrors
+	}
 
 	notify := make(chan tarantool.ConnEvent, 10*len(addrs)) // x10 to accept disconnected and closed event (with a margin)
 	connOpts.Notify = notify
@@ -70,12 +157,22 @@ func ConnectWithOpts(addrs []string, connOpts tarantool.Opts, opts OptsMulti) (c
 		notify:   notify,
 		control:  make(chan struct{}),
 		pool:     make(map[string]*tarantool.Connection),
+		election: make(map[string]ElectionInfo),
+		readOnly: make(map[string]bool),
+		vclocks:  make(map[string]Vclock),
+		latency:  make(map[string]time.Duration),
 	}
-	somebodyAlive, _ := connMulti.warmUp()
-	if !somebodyAlive {
+	alive, _ := connMulti.warmUp()
+	if alive < opts.MinAvailable {
 		connMulti.Close()
 		return nil, ErrNoConnection
 	}
+	if opts.LeaderOnly {
+		connMulti.pollElection()
+	}
+	if opts.TrackRoles || opts.LocalZone != "" {
+		connMulti.trackRoles()
+	}
 	go connMulti.checker()
 
 	return connMulti, nil
@@ -88,11 +185,14 @@ func Connect(addrs []string, connOpts tarantool.Opts) (connMulti *ConnectionMult
 	return ConnectWithOpts(addrs, connOpts, opts)
 }
 
-func (connMulti *ConnectionMulti) warmUp() (somebodyAlive bool, errs []error) {
+func (connMulti *ConnectionMulti) warmUp() (alive int, errs []error) {
 	errs = make([]error, len(connMulti.addrs))
 
 	for i, addr := range connMulti.addrs {
-		conn, err := tarantool.Connect(addr, connMulti.connOpts)
+		if connMulti.opts.ConnectMode == ConnectLazy && alive >= connMulti.opts.MinAvailable {
+			break
+		}
+		conn, err := tarantool.Connect(addr, connMulti.getConnOpts())
 		errs[i] = err
 		if conn != nil && err == nil {
 			if connMulti.fallback == nil {
@@ -100,13 +200,96 @@ func (connMulti *ConnectionMulti) warmUp() (somebodyAlive bool, errs []error) {
 			}
 			connMulti.pool[addr] = conn
 			if conn.ConnectedNow() {
-				somebodyAlive = true
+				alive++
 			}
 		}
 	}
 	return
 }
 
+// connectLazy connects to instances that were not used yet until n
+// instances are connected. It does nothing in eager mode. Instances are
+// dialed without the lock, so routing of requests is not blocked meanwhile.
+func (connMulti *ConnectionMulti) connectLazy(n int) {
+	if connMulti.opts.ConnectMode != ConnectLazy {
+		return
+	}
+	connMulti.mutex.RLock()
+	if connMulti.state == connClosed {
+		connMulti.mutex.RUnlock()
+		return
+	}
+	alive := 0
+	var unused []string
+	for _, addr := range connMulti.addrs {
+		if conn, ok := connMulti.pool[addr]; !ok {
+			unused = append(unused, addr)
+		} else if conn != nil && conn.ConnectedNow() {
+			alive++
+		}
+	}
+	connMulti.mutex.RUnlock()
+
+	for _, addr := range unused {
+		if alive >= n {
+			return
+		}
+		conn, _ := tarantool.Connect(addr, connMulti.getConnOpts())
+		if conn == nil {
+			continue
+		}
+		connMulti.mutex.Lock()
+		if connMulti.state == connClosed {
+			connMulti.mutex.Unlock()
+			conn.Close()
+			return
+		}
+		if installed, ok := connMulti.pool[addr]; ok {
+			// instance is connected by another goroutine meanwhile
+			connMulti.mutex.Unlock()
+			conn.Close()
+			conn = installed
+		} else {
+			connMulti.pool[addr] = conn
+			connMulti.mutex.Unlock()
+		}
+		if conn != nil && conn.ConnectedNow() {
+			alive++
+		}
+	}
+}
+
+// Ready reports whether at least MinAvailable instances are connected.
+// In lazy mode instances that were not used yet are not counted.
+func (connMulti *ConnectionMulti) Ready() bool {
+	if connMulti.getState() == connClosed {
+		return false
+	}
+	connMulti.mutex.RLock()
+	defer connMulti.mutex.RUnlock()
+
+	alive := 0
+	for _, conn := range connMulti.pool {
+		if conn.ConnectedNow() {
+			alive++
+		}
+	}
+	return alive >= connMulti.opts.MinAvailable
+}
+
+// Instances returns connections of the pool by instance addresses. In lazy
+// mode instances that were not used yet are not included.
+func (connMulti *ConnectionMulti) Instances() map[string]*tarantool.Connection {
+	connMulti.mutex.RLock()
+	defer connMulti.mutex.RUnlock()
+
+	conns := make(map[string]*tarantool.Connection, len(connMulti.pool))
+	for addr, conn := range connMulti.pool {
+		conns[addr] = conn
+	}
+	return conns
+}
+
 func (connMulti *ConnectionMulti) getState() uint32 {
 	return atomic.LoadUint32(&connMulti.state)
 }
@@ -151,7 +334,7 @@ func (connMulti *ConnectionMulti) checker() {
 				if _, ok := connMulti.getConnectionFromPool(addr); !ok {
 					continue
 				}
-				conn, _ := tarantool.Connect(addr, connMulti.connOpts)
+				conn, _ := tarantool.Connect(addr, connMulti.getConnOpts())
 				if conn != nil {
 					connMulti.setConnectionToPool(addr, conn)
 				} else {
@@ -172,7 +355,7 @@ func (connMulti *ConnectionMulti) checker() {
 				// Fill pool with new connections
 				for _, v := range addrs {
 					if indexOf(v, connMulti.addrs) < 0 {
-						conn, _ := tarantool.Connect(v, connMulti.connOpts)
+						conn, _ := tarantool.Connect(v, connMulti.getConnOpts())
 						if conn != nil {
 							connMulti.setConnectionToPool(v, conn)
 						}
@@ -188,9 +371,17 @@ func (connMulti *ConnectionMulti) checker() {
 						connMulti.deleteConnectionFromPool(v)
 					}
 				}
+				connMulti.mutex.Lock()
 				connMulti.addrs = addrs
+				connMulti.mutex.Unlock()
 			}
 		case <-timer.C:
+			if connMulti.opts.LeaderOnly {
+				connMulti.pollElection()
+			}
+			if atomic.LoadUint32(&connMulti.rolesTracked) != 0 {
+				connMulti.pollReadOnly()
+			}
 			for _, addr := range connMulti.addrs {
 				if connMulti.getState() == connClosed {
 					return
@@ -199,8 +390,11 @@ func (connMulti *ConnectionMulti) checker() {
 					if !conn.ClosedNow() {
 						continue
 					}
+				} else if connMulti.opts.ConnectMode == ConnectLazy {
+					// not used yet
+					continue
 				}
-				conn, _ := tarantool.Connect(addr, connMulti.connOpts)
+				conn, _ := tarantool.Connect(addr, connMulti.getConnOpts())
 				if conn != nil {
 					connMulti.setConnectionToPool(addr, conn)
 				}
@@ -210,19 +404,48 @@ func (connMulti *ConnectionMulti) checker() {
 }
 
 func (connMulti *ConnectionMulti) getCurrentConnection() *tarantool.Connection {
+	if conn := connMulti.getConnectedConnection(); conn != nil {
+		return conn
+	}
+	connMulti.connectLazy(1)
+	if conn := connMulti.getConnectedConnection(); conn != nil {
+		return conn
+	}
+	connMulti.mutex.RLock()
+	defer connMulti.mutex.RUnlock()
+	return connMulti.fallback
+}
+
+func (connMulti *ConnectionMulti) getConnectedConnection() *tarantool.Connection {
 	connMulti.mutex.RLock()
 	defer connMulti.mutex.RUnlock()
 
+	if connMulti.opts.LeaderOnly {
+		if conn := connMulti.getLeaderConnection(); conn != nil {
+			return conn
+		}
+	}
+
+	var best string
 	for _, addr := range connMulti.addrs {
 		conn := connMulti.pool[addr]
 		if conn != nil {
 			if conn.ConnectedNow() {
-				return conn
+				if connMulti.opts.LocalZone == "" {
+					return conn
+				}
+				if best == "" || connMulti.closerZone(addr, best) {
+					best = addr
+				}
+				continue
 			}
 			connMulti.fallback = conn
 		}
 	}
-	return connMulti.fallback
+	if best != "" {
+		return connMulti.pool[best]
+	}
+	return nil
 }
 
 func (connMulti *ConnectionMulti) ConnectedNow() bool {
@@ -233,8 +456,11 @@ func (connMulti *ConnectionMulti) Close() (err error) {
 	connMulti.mutex.Lock()
 	defer connMulti.mutex.Unlock()
 
+	if connMulti.state == connClosed {
+		return nil
+	}
 	close(connMulti.control)
-	connMulti.state = connClosed
+	atomic.StoreUint32(&connMulti.state, connClosed)
 
 	for _, conn := range connMulti.pool {
 		if err == nil {
@@ -250,6 +476,41 @@ func (connMulti *ConnectionMulti) Close() (err error) {
 	return
 }
 
+// CloseGraceful closes all connections of the pool with
+// tarantool.Connection.CloseGraceful, so requests in flight are done
+// before connections are closed. Instances are not reconnected meanwhile.
+// It does nothing if the pool is already closed.
+func (connMulti *ConnectionMulti) CloseGraceful(ctx context.Context) (err error) {
+	connMulti.mutex.Lock()
+	if connMulti.state == connClosed {
+		connMulti.mutex.Unlock()
+		return nil
+	}
+	close(connMulti.control)
+	atomic.StoreUint32(&connMulti.state, connClosed)
+	conns := make(map[*tarantool.Connection]bool, len(connMulti.pool)+1)
+	for _, conn := range connMulti.pool {
+		conns[conn] = true
+	}
+	if connMulti.fallback != nil {
+		conns[connMulti.fallback] = true
+	}
+	connMulti.mutex.Unlock()
+
+	errs := make(chan error, len(conns))
+	for conn := range conns {
+		go func(conn *tarantool.Connection) {
+			errs <- conn.CloseGraceful(ctx)
+		}(conn)
+	}
+	for range conns {
+		if cerr := <-errs; err == nil {
+			err = cerr
+		}
+	}
+	return
+}
+
 func (connMulti *ConnectionMulti) Ping() (resp *tarantool.Response, err error) {
 	return connMulti.getCurrentConnection().Ping()
 }
@@ -259,7 +520,13 @@ func (connMulti *ConnectionMulti) ConfiguredTimeout() time.Duration {
 }
 
 func (connMulti *ConnectionMulti) Select(space, index interface{}, offset, limit, iterator uint32, key interface{}) (resp *tarantool.Response, err error) {
-	return connMulti.getCurrentConnection().Select(space, index, offset, limit, iterator, key)
+	start := time.Now()
+	err = connMulti.failover(true, connMulti.getCurrentConnection, func(conn *tarantool.Connection) (err error) {
+		resp, err = conn.Select(space, index, offset, limit, iterator, key)
+		return
+	})
+	connMulti.mirrorSelect(start, space, index, offset, limit, iterator, key)
+	return
 }
 
 func (connMulti *ConnectionMulti) Insert(space interface{}, tuple interface{}) (resp *tarantool.Response, err error) {
@@ -283,11 +550,23 @@ func (connMulti *ConnectionMulti) Upsert(space interface{}, tuple, ops interface
 }
 
 func (connMulti *ConnectionMulti) Call(functionName string, args interface{}) (resp *tarantool.Response, err error) {
-	return connMulti.getCurrentConnection().Call(functionName, args)
+	start := time.Now()
+	err = connMulti.failover(connMulti.isReadOnlyFunction(functionName), connMulti.getCurrentConnection, func(conn *tarantool.Connection) (err error) {
+		resp, err = conn.Call(functionName, args)
+		return
+	})
+	connMulti.mirrorCall(start, false, functionName, args)
+	return
 }
 
 func (connMulti *ConnectionMulti) Call17(functionName string, args interface{}) (resp *tarantool.Response, err error) {
-	return connMulti.getCurrentConnection().Call17(functionName, args)
+	start := time.Now()
+	err = connMulti.failover(connMulti.isReadOnlyFunction(functionName), connMulti.getCurrentConnection, func(conn *tarantool.Connection) (err error) {
+		resp, err = conn.Call17(functionName, args)
+		return
+	})
+	connMulti.mirrorCall(start, true, functionName, args)
+	return
 }
 
 func (connMulti *ConnectionMulti) Eval(expr string, args interface{}) (resp *tarantool.Response, err error) {
@@ -295,11 +574,21 @@ func (connMulti *ConnectionMulti) Eval(expr string, args interface{}) (resp *tar
 }
 
 func (connMulti *ConnectionMulti) GetTyped(space, index interface{}, key interface{}, result interface{}) (err error) {
-	return connMulti.getCurrentConnection().GetTyped(space, index, key, result)
+	start := time.Now()
+	err = connMulti.failover(true, connMulti.getCurrentConnection, func(conn *tarantool.Connection) error {
+		return conn.GetTyped(space, index, key, result)
+	})
+	connMulti.mirrorSelect(start, space, index, 0, 1, tarantool.IterEq, key)
+	return
 }
 
 func (connMulti *ConnectionMulti) SelectTyped(space, index interface{}, offset, limit, iterator uint32, key interface{}, result interface{}) (err error) {
-	return connMulti.getCurrentConnection().SelectTyped(space, index, offset, limit, iterator, key, result)
+	start := time.Now()
+	err = connMulti.failover(true, connMulti.getCurrentConnection, func(conn *tarantool.Connection) error {
+		return conn.SelectTyped(space, index, offset, limit, iterator, key, result)
+	})
+	connMulti.mirrorSelect(start, space, index, offset, limit, iterator, key)
+	return
 }
 
 func (connMulti *ConnectionMulti) InsertTyped(space interface{}, tuple interface{}, result interface{}) (err error) {
@@ -319,11 +608,21 @@ func (connMulti *ConnectionMulti) UpdateTyped(space, index interface{}, key, ops
 }
 
 func (connMulti *ConnectionMulti) CallTyped(functionName string, args interface{}, result interface{}) (err error) {
-	return connMulti.getCurrentConnection().CallTyped(functionName, args, result)
+	start := time.Now()
+	err = connMulti.failover(connMulti.isReadOnlyFunction(functionName), connMulti.getCurrentConnection, func(conn *tarantool.Connection) error {
+		return conn.CallTyped(functionName, args, result)
+	})
+	connMulti.mirrorCall(start, false, functionName, args)
+	return
 }
 
 func (connMulti *ConnectionMulti) Call17Typed(functionName string, args interface{}, result interface{}) (err error) {
-	return connMulti.getCurrentConnection().Call17Typed(functionName, args, result)
+	start := time.Now()
+	err = connMulti.failover(connMulti.isReadOnlyFunction(functionName), connMulti.getCurrentConnection, func(conn *tarantool.Connection) error {
+		return conn.Call17Typed(functionName, args, result)
+	})
+	connMulti.mirrorCall(start, true, functionName, args)
+	return
 }
 
 func (connMulti *ConnectionMulti) EvalTyped(expr string, args interface{}, result interface{}) (err error) {
@@ -1,6 +1,11 @@
 package multi
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -204,3 +209,524 @@ func TestRefresh(t *testing.T) {
 		t.Error("Expect to get data after reconnect")
 	}
 }
+
+func TestQuorumDo(t *testing.T) {
+	multiConn, _ := Connect([]string{server1, server2}, connOpts)
+	if multiConn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer multiConn.Close()
+
+	eval := func(conn tarantool.Connector) *tarantool.Future {
+		return conn.EvalAsync("return 42", []interface{}{})
+	}
+	resp, err := multiConn.QuorumDo(eval, 2, nil)
+	if err != nil {
+		t.Errorf("Failed to get quorum: %s", err.Error())
+	} else if len(resp.Data) != 1 || resp.Data[0] != int64(42) && resp.Data[0] != uint64(42) {
+		t.Errorf("Unexpected quorum response: %v", resp.Data)
+	}
+
+	// box.cfg.listen differs on each instance
+	port := func(conn tarantool.Connector) *tarantool.Future {
+		return conn.EvalAsync("return box.cfg.listen", []interface{}{})
+	}
+	if _, err = multiConn.QuorumDo(port, 2, nil); err != ErrNoQuorum {
+		t.Errorf("Expected ErrNoQuorum, got %v", err)
+	}
+	if _, err = multiConn.QuorumDo(port, 3, nil); err != ErrNoQuorum {
+		t.Errorf("Expected ErrNoQuorum for unavailable instances, got %v", err)
+	}
+}
+
+func TestLeaderOnly(t *testing.T) {
+	opts := OptsMulti{
+		CheckTimeout: 100 * time.Millisecond,
+		LeaderOnly:   true,
+	}
+	multiConn, _ := ConnectWithOpts([]string{server1, server2}, connOpts, opts)
+	if multiConn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer multiConn.Close()
+
+	for _, addr := range []string{server1, server2} {
+		info, ok := multiConn.Election(addr)
+		if !ok {
+			t.Errorf("election state of %s is not polled", addr)
+		}
+		// Election is not configured for test instances.
+		if info.IsLeader() {
+			t.Errorf("unexpected leader %s", addr)
+		}
+	}
+
+	// Requests are routed as usual while leader is unknown.
+	if _, err := multiConn.Ping(); err != nil {
+		t.Errorf("failed to Ping without leader: %s", err.Error())
+	}
+}
+
+func TestConnectModes(t *testing.T) {
+	opts := connOptsMulti
+	opts.MinAvailable = 3
+	multiConn, err := ConnectWithOpts([]string{server1, server2}, connOpts, opts)
+	if err != ErrWrongMinAvailable {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if multiConn != nil {
+		multiConn.Close()
+	}
+
+	// Eager mode fails when fewer than MinAvailable instances are alive.
+	opts.MinAvailable = 2
+	multiConn, err = ConnectWithOpts([]string{"err", server1}, connOpts, opts)
+	if err != ErrNoConnection {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if multiConn != nil {
+		multiConn.Close()
+	}
+
+	opts.MinAvailable = 1
+	opts.ConnectMode = ConnectLazy
+	multiConn, err = ConnectWithOpts([]string{server1, server2}, connOpts, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer multiConn.Close()
+
+	if !multiConn.Ready() {
+		t.Errorf("pool is not ready")
+	}
+	if _, ok := multiConn.getConnectionFromPool(server2); ok {
+		t.Errorf("%s is connected before first use", server2)
+	}
+	eval := func(conn tarantool.Connector) *tarantool.Future {
+		return conn.EvalAsync("return 1", []interface{}{})
+	}
+	if _, err := multiConn.QuorumDo(eval, 2, nil); err != nil {
+		t.Errorf("failed QuorumDo: %s", err.Error())
+	}
+	if conn, ok := multiConn.getConnectionFromPool(server2); !ok || !conn.ConnectedNow() {
+		t.Errorf("%s is not connected on first use", server2)
+	}
+}
+
+func TestDoRouteHint(t *testing.T) {
+	multiConn, _ := Connect([]string{server1, server2}, connOpts)
+	if multiConn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer multiConn.Close()
+
+	port := func(conn tarantool.Connector) *tarantool.Future {
+		return conn.EvalAsync("return box.cfg.listen", []interface{}{})
+	}
+
+	// Roles are polled only since the first request routed by mode.
+	if _, err := multiConn.Do(port, RouteHint{}); err != nil {
+		t.Errorf("Failed to Do in Any mode: %s", err.Error())
+	}
+	if atomic.LoadUint32(&multiConn.rolesTracked) != 0 {
+		t.Errorf("Roles are tracked without routing by mode")
+	}
+
+	// Test instances are writable.
+	if _, err := multiConn.Do(port, RouteHint{Mode: ModeRW}); err != nil {
+		t.Errorf("Failed to Do in RW mode: %s", err.Error())
+	}
+	if _, err := multiConn.Do(port, RouteHint{Mode: ModePreferRO}); err != nil {
+		t.Errorf("Failed to Do in PreferRO mode: %s", err.Error())
+	}
+	if _, err := multiConn.Do(port, RouteHint{Mode: ModeRO}); err != ErrNoInstance {
+		t.Errorf("Expected ErrNoInstance in RO mode, got %v", err)
+	}
+	if atomic.LoadUint32(&multiConn.rolesTracked) == 0 {
+		t.Errorf("Roles are not tracked after routing by mode")
+	}
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		first, err := multiConn.Do(port, RouteHint{AffinityKey: key})
+		if err != nil {
+			t.Errorf("Failed to Do with affinity key: %s", err.Error())
+			continue
+		}
+		for i := 0; i < 3; i++ {
+			resp, err := multiConn.Do(port, RouteHint{AffinityKey: key})
+			if err != nil || resp.Data[0] != first.Data[0] {
+				t.Errorf("Request with key %q is routed to %v, expected %v", key, resp, first.Data)
+			}
+		}
+	}
+}
+
+func TestVclock(t *testing.T) {
+	vclock := decodeVclock([]interface{}{uint64(5), uint64(0), uint64(7)})
+	if !vclock.Reached(Vclock{1: 5, 3: 6}) {
+		t.Errorf("%v has not reached {1: 5, 3: 6}", vclock)
+	}
+	if vclock.Reached(Vclock{2: 1}) {
+		t.Errorf("%v has reached {2: 1}", vclock)
+	}
+	// Local component is not compared.
+	vclock = decodeVclock(map[interface{}]interface{}{uint64(0): uint64(10), uint64(2): int64(3)})
+	if !vclock.Reached(Vclock{0: 20, 2: 3}) {
+		t.Errorf("%v has not reached {0: 20, 2: 3}", vclock)
+	}
+}
+
+func TestSession(t *testing.T) {
+	multiConn, _ := Connect([]string{server1, server2}, connOpts)
+	if multiConn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer multiConn.Close()
+
+	session := multiConn.NewSession()
+	write := func(conn tarantool.Connector) *tarantool.Future {
+		return conn.EvalAsync("box.space._schema:replace{'session_test'}", []interface{}{})
+	}
+	if _, err := session.Write(write); err != nil {
+		t.Errorf("Failed to write: %s", err.Error())
+		return
+	}
+	if len(session.Vclock()) == 0 {
+		t.Errorf("vclock of write is not remembered")
+	}
+
+	// There are no read-only instances, so read is served by writer.
+	read := func(conn tarantool.Connector) *tarantool.Future {
+		return conn.EvalAsync("return box.space._schema:get{'session_test'} ~= nil", []interface{}{})
+	}
+	resp, err := session.Read(read)
+	if err != nil {
+		t.Errorf("Failed to read: %s", err.Error())
+	} else if len(resp.Data) != 1 || resp.Data[0] != true {
+		t.Errorf("Write is not visible to read: %v", resp.Data)
+	}
+}
+
+func TestFailover(t *testing.T) {
+	opts := connOptsMulti
+	opts.FailoverTimeout = 1 * time.Second
+	multiConn, _ := ConnectWithOpts([]string{server1, server2}, connOpts, opts)
+	if multiConn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer multiConn.Close()
+
+	slow := func(conn tarantool.Connector) *tarantool.Future {
+		return conn.EvalAsync("require('fiber').sleep(0.3) return box.cfg.listen", []interface{}{})
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		conn, _ := multiConn.getConnectionFromPool(server1)
+		conn.Close()
+	}()
+	resp, err := multiConn.Do(slow, RouteHint{Idempotent: true})
+	if err != nil {
+		t.Errorf("Failed to failover: %s", err.Error())
+	} else if len(resp.Data) != 1 {
+		t.Errorf("Unexpected response after failover: %v", resp.Data)
+	}
+
+	// Requests that are not idempotent are not resent.
+	time.Sleep(opts.CheckTimeout + 100*time.Millisecond)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		conn, _ := multiConn.getConnectionFromPool(server1)
+		conn.Close()
+	}()
+	if _, err = multiConn.Do(slow, RouteHint{}); !isConnectionError(err) {
+		t.Errorf("Expected connection error, got %v", err)
+	}
+}
+
+func TestZones(t *testing.T) {
+	opts := connOptsMulti
+	opts.Zones = map[string]string{server1: "dc1", server2: "dc2"}
+	opts.LocalZone = "dc2"
+	multiConn, _ := ConnectWithOpts([]string{server1, server2}, connOpts, opts)
+	if multiConn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer multiConn.Close()
+
+	if addr := multiConn.getCurrentConnection().Addr(); addr != server2 {
+		t.Errorf("Request is routed to %s out of local zone", addr)
+	}
+
+	health := multiConn.ZonesHealth()
+	for _, zone := range []string{"dc1", "dc2"} {
+		if health[zone].Instances != 1 || health[zone].Connected != 1 {
+			t.Errorf("Unexpected health of %s: %+v", zone, health[zone])
+		}
+	}
+
+	// Local zone is not preferred when it is much slower.
+	multiConn.mutex.Lock()
+	multiConn.latency[server2] = 2 * time.Second
+	multiConn.opts.CrossZonePenalty = 100 * time.Millisecond
+	multiConn.mutex.Unlock()
+	if addr := multiConn.getCurrentConnection().Addr(); addr != server1 {
+		t.Errorf("Request is routed to slow local instance %s", addr)
+	}
+}
+
+func TestCloseGraceful(t *testing.T) {
+	multiConn, _ := Connect([]string{server1, server2}, connOpts)
+	if multiConn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+
+	fut := multiConn.EvalAsync("require('fiber').sleep(0.2) return 42", []interface{}{})
+	if err := multiConn.CloseGraceful(context.Background()); err != nil {
+		t.Errorf("Failed to close gracefully: %s", err.Error())
+	}
+	if _, err := fut.Get(); err != nil {
+		t.Errorf("Request in flight is aborted: %s", err.Error())
+	}
+	for _, addr := range []string{server1, server2} {
+		if conn, ok := multiConn.getConnectionFromPool(addr); ok && !conn.ClosedNow() {
+			t.Errorf("Connection to %s is not closed", addr)
+		}
+	}
+	// closing of closed pool does nothing
+	if err := multiConn.Close(); err != nil {
+		t.Errorf("Failed to close closed pool: %s", err.Error())
+	}
+	if err := multiConn.CloseGraceful(context.Background()); err != nil {
+		t.Errorf("Failed to close closed pool gracefully: %s", err.Error())
+	}
+}
+
+func TestSelectMerged(t *testing.T) {
+	multiConn, _ := Connect([]string{server1, server2}, connOpts)
+	if multiConn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer multiConn.Close()
+
+	// System spaces are the same on both instances, so each tuple is
+	// merged twice in a row.
+	tuples, err := multiConn.SelectMerged("_vspace", "primary", 1, 4, tarantool.IterAll, []interface{}{})
+	if err != nil {
+		t.Errorf("Failed to select: %s", err.Error())
+		return
+	}
+	if len(tuples) != 4 {
+		t.Errorf("Unexpected number of tuples: %d", len(tuples))
+		return
+	}
+	if tuples[1][0] != tuples[2][0] || tuples[0][0] == tuples[1][0] {
+		t.Errorf("Tuples are not merged by key: %v", tuples)
+	}
+
+	// math.MaxUint32 limit selects all tuples, offset must not wrap it.
+	all, err := multiConn.SelectMerged("_vspace", "primary", 0, math.MaxUint32, tarantool.IterAll, []interface{}{})
+	if err != nil {
+		t.Errorf("Failed to select all: %s", err.Error())
+		return
+	}
+	rest, err := multiConn.SelectMerged("_vspace", "primary", 2, math.MaxUint32, tarantool.IterAll, []interface{}{})
+	if err != nil {
+		t.Errorf("Failed to select all with offset: %s", err.Error())
+		return
+	}
+	if len(all) < 4 || len(rest) != len(all)-2 {
+		t.Errorf("Unexpected number of tuples: %d with offset, %d without", len(rest), len(all))
+	}
+
+	none, err := multiConn.SelectMerged("_vspace", "primary", 2, 0, tarantool.IterAll, []interface{}{})
+	if err != nil || len(none) != 0 {
+		t.Errorf("Unexpected result of select with zero limit: %v, %v", none, err)
+	}
+}
+
+func TestSelectMergedPartial(t *testing.T) {
+	multiConn, _ := Connect([]string{"err", server1}, connOpts)
+	if multiConn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer multiConn.Close()
+
+	if _, err := multiConn.SelectMerged("_vspace", "primary", 0, 4, tarantool.IterAll, []interface{}{}); err == nil {
+		t.Errorf("Select of partially connected pool should fail")
+	}
+}
+
+func TestMergedLimit(t *testing.T) {
+	cases := []struct {
+		offset, limit, expected uint32
+	}{
+		{0, 10, 10},
+		{5, 10, 15},
+		{0, math.MaxUint32, math.MaxUint32},
+		{2, math.MaxUint32, math.MaxUint32},
+		{math.MaxUint32, 1, math.MaxUint32},
+	}
+	for _, c := range cases {
+		if n := mergedLimit(c.offset, c.limit); n != c.expected {
+			t.Errorf("mergedLimit(%d, %d) = %d, expected %d", c.offset, c.limit, n, c.expected)
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	multiConn, _ := Connect([]string{server1, server2}, connOpts)
+	if multiConn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer multiConn.Close()
+
+	port := func(conn tarantool.Connector) *tarantool.Future {
+		return conn.EvalAsync("return box.cfg.listen", []interface{}{})
+	}
+	var ports []string
+	err := multiConn.Map(context.Background(), port, func(results []MapResult) error {
+		for _, res := range results {
+			ports = append(ports, fmt.Sprint(res.Response.Data[0]))
+		}
+		return nil
+	}, MapOpts{Concurrency: 1})
+	if err != nil {
+		t.Errorf("Failed to Map: %s", err.Error())
+	} else if len(ports) != 2 || ports[0] != "3013" || ports[1] != "3014" {
+		t.Errorf("Unexpected results: %v", ports)
+	}
+
+	// Unknown instance fails Map unless partial results are allowed.
+	opts := MapOpts{Addrs: []string{server1, "err"}}
+	combine := func(results []MapResult) error {
+		if len(results) != 2 || results[0].Err != nil || results[1].Err != ErrNoConnection {
+			t.Errorf("Unexpected partial results: %+v", results)
+		}
+		return nil
+	}
+	if err = multiConn.Map(context.Background(), port, combine, opts); err == nil {
+		t.Errorf("Map does not fail without partial results")
+	}
+	opts.AllowPartial = true
+	if err = multiConn.Map(context.Background(), port, combine, opts); err != nil {
+		t.Errorf("Failed to Map with partial results: %s", err.Error())
+	}
+}
+
+func TestStats(t *testing.T) {
+	opts := connOpts
+	opts.TrackRequests = true
+	multiConn, _ := Connect([]string{server1, server2}, opts)
+	if multiConn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer multiConn.Close()
+
+	// schema is loaded with selects too
+	key := tarantool.SizeKey{RequestCode: tarantool.SelectRequest, SpaceNo: 281}
+	var before, after uint64
+	for _, stats := range multiConn.Stats() {
+		before += stats.Requests[key].Count
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := multiConn.Select("_vspace", "primary", 0, 1, tarantool.IterAll, []interface{}{}); err != nil {
+			t.Errorf("Failed to select: %s", err.Error())
+			return
+		}
+	}
+
+	for addr, stats := range multiConn.Stats() {
+		if !stats.Connected {
+			t.Errorf("Instance %s is not connected", addr)
+		}
+		after += stats.Requests[key].Count
+	}
+	if after-before != 3 {
+		t.Errorf("Unexpected number of selects: %d", after-before)
+	}
+
+	vars := multiConn.Expvar().String()
+	if !strings.Contains(vars, `"_vspace":{"SELECT":{"count":`) {
+		t.Errorf("Unexpected expvar: %s", vars)
+	}
+}
+
+func TestMirror(t *testing.T) {
+	shadow, err := tarantool.Connect(server2, connOpts)
+	if err != nil {
+		t.Errorf("Failed to connect to shadow: %s", err.Error())
+		return
+	}
+	defer shadow.Close()
+
+	mirrors := make(chan Mirror, 1)
+	opts := connOptsMulti
+	opts.Shadow = shadow
+	opts.MirrorPercent = 100
+	opts.OnMirror = func(m Mirror) { mirrors <- m }
+	multiConn, _ := ConnectWithOpts([]string{server1}, connOpts, opts)
+	if multiConn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer multiConn.Close()
+
+	if _, err = multiConn.Select(0, 0, 0, 1, tarantool.IterAll, []interface{}{}); err == nil {
+		t.Errorf("Select from unknown space is successful")
+	}
+	select {
+	case m := <-mirrors:
+		if m.Err == nil || m.ShadowLatency <= 0 {
+			t.Errorf("Unexpected mirror: %v", m)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Select is not mirrored")
+		return
+	}
+	if stats := multiConn.MirrorStats(); stats.Failed != 1 || stats.Mirrored != 0 {
+		t.Errorf("Unexpected mirror stats: %v", stats)
+	}
+
+	if _, err = multiConn.Eval("return 1", []interface{}{}); err != nil {
+		t.Errorf("Failed to eval: %s", err.Error())
+	}
+	select {
+	case m := <-mirrors:
+		t.Errorf("Eval is mirrored: %v", m)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	multiConn, _ := ConnectWithOpts([]string{server1, server2}, connOpts, connOptsMulti)
+	if multiConn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer multiConn.Close()
+
+	if err := multiConn.Authenticate(tarantool.NewAuthRequest("test", "test")); err != nil {
+		t.Errorf("Failed to authenticate: %s", err.Error())
+	}
+	for addr, conn := range multiConn.Instances() {
+		if user := conn.ConnectionInfo().User; user != "test" {
+			t.Errorf("Unexpected user of %s: %s", addr, user)
+		}
+	}
+	if err := multiConn.Authenticate(tarantool.NewAuthRequest("test", "wrong")); err == nil {
+		t.Errorf("Authenticated with wrong password")
+	}
+}
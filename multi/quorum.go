@@ -0,0 +1,106 @@
+package multi
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+var ErrNoQuorum = errors.New("responses of the majority of instances do not agree")
+
+type quorumResult struct {
+	resp *tarantool.Response
+	err  error
+}
+
+// QuorumDo sends request produced by req to n connected instances
+// concurrently and returns response as soon as the majority of n
+// instances (n/2 + 1) return equal responses.
+//
+// Responses are compared with equal. If equal is nil, decoded Data of
+// responses are compared with reflect.DeepEqual.
+//
+// It is intended for correctness-critical reads on asynchronous
+// replication setups, req should not modify data.
+func (connMulti *ConnectionMulti) QuorumDo(req func(tarantool.Connector) *tarantool.Future, n int,
+	equal func(a, b *tarantool.Response) bool) (*tarantool.Response, error) {
+	if equal == nil {
+		equal = func(a, b *tarantool.Response) bool {
+			return reflect.DeepEqual(a.Data, b.Data)
+		}
+	}
+	conns := connMulti.getConnectedConnections(n)
+	quorum := n/2 + 1
+	if len(conns) < quorum {
+		return nil, ErrNoQuorum
+	}
+
+	results := make(chan quorumResult, len(conns))
+	for _, conn := range conns {
+		go func(conn *tarantool.Connection) {
+			resp, err := req(conn).Get()
+			results <- quorumResult{resp, err}
+		}(conn)
+	}
+
+	var groups [][]*tarantool.Response
+	var lastErr error
+	for i := range conns {
+		res := <-results
+		if res.err != nil {
+			lastErr = res.err
+		} else {
+			found := false
+			for j := range groups {
+				if equal(groups[j][0], res.resp) {
+					groups[j] = append(groups[j], res.resp)
+					found = true
+					if len(groups[j]) >= quorum {
+						return res.resp, nil
+					}
+					break
+				}
+			}
+			if !found {
+				groups = append(groups, []*tarantool.Response{res.resp})
+				if quorum == 1 {
+					return res.resp, nil
+				}
+			}
+		}
+		if len(conns)-i-1 < quorum-maxGroup(groups) {
+			// the rest responses could not make the majority
+			break
+		}
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, ErrNoQuorum
+}
+
+func maxGroup(groups [][]*tarantool.Response) (max int) {
+	for _, g := range groups {
+		if len(g) > max {
+			max = len(g)
+		}
+	}
+	return
+}
+
+func (connMulti *ConnectionMulti) getConnectedConnections(n int) (conns []*tarantool.Connection) {
+	connMulti.connectLazy(n)
+	connMulti.mutex.RLock()
+	defer connMulti.mutex.RUnlock()
+
+	for _, addr := range connMulti.addrs {
+		if len(conns) == n {
+			break
+		}
+		if conn := connMulti.pool[addr]; conn != nil && conn.ConnectedNow() {
+			conns = append(conns, conn)
+		}
+	}
+	return
+}
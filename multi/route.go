@@ -0,0 +1,201 @@
+package multi
+
+import (
+	"errors"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// Mode is a kind of instance a request is routed to.
+type Mode int
+
+const (
+	// ModeAny routes request to any connected instance.
+	ModeAny Mode = iota
+	// ModeRW routes request to a writable instance.
+	ModeRW
+	// ModeRO routes request to a read-only instance.
+	ModeRO
+	// ModePreferRO routes request to a read-only instance if there is one,
+	// otherwise to a writable instance.
+	ModePreferRO
+)
+
+var ErrNoInstance = errors.New("no connected instance matches routing hint")
+
+// RouteHint defines how a single request is routed by Do.
+type RouteHint struct {
+	// Mode is a kind of instance required by request. Default is ModeAny.
+	Mode Mode
+	// AffinityKey routes requests with the same key to the same instance
+	// while the set of matching instances does not change. Instances are
+	// chosen with rendezvous hashing, so when an instance goes down only
+	// its keys are moved. Requests without key are routed as usual.
+	AffinityKey string
+	// Idempotent marks request as safe to resend on another instance on
+	// failover, see OptsMulti.FailoverTimeout.
+	Idempotent bool
+}
+
+// Do routes request built by req to an instance chosen by hint and waits
+// for response.
+//
+// Read-only state of instances is polled every CheckTimeout since the
+// first request routed by Mode other than ModeAny, see
+// OptsMulti.TrackRoles. Instances with unknown state match only ModeAny.
+func (connMulti *ConnectionMulti) Do(req func(tarantool.Connector) *tarantool.Future, hint RouteHint) (resp *tarantool.Response, err error) {
+	route := func() *tarantool.Connection {
+		return connMulti.route(hint)
+	}
+	err = connMulti.failover(hint.Idempotent, route, func(conn *tarantool.Connection) (err error) {
+		resp, err = req(conn).Get()
+		return
+	})
+	return
+}
+
+// ReadOnly returns last known box.info.ro of an instance.
+func (connMulti *ConnectionMulti) ReadOnly(addr string) (ro bool, ok bool) {
+	connMulti.trackRoles()
+	connMulti.mutex.RLock()
+	defer connMulti.mutex.RUnlock()
+	ro, ok = connMulti.readOnly[addr]
+	return
+}
+
+func (connMulti *ConnectionMulti) route(hint RouteHint) *tarantool.Connection {
+	if hint.Mode == ModeAny && hint.AffinityKey == "" {
+		return connMulti.getCurrentConnection()
+	}
+	if hint.Mode != ModeAny {
+		connMulti.trackRoles()
+	}
+	if hint.Mode == ModePreferRO {
+		if conn := connMulti.routeMode(ModeRO, hint.AffinityKey); conn != nil {
+			return conn
+		}
+		return connMulti.routeMode(ModeRW, hint.AffinityKey)
+	}
+	return connMulti.routeMode(hint.Mode, hint.AffinityKey)
+}
+
+func (connMulti *ConnectionMulti) routeMode(mode Mode, key string) *tarantool.Connection {
+	connMulti.mutex.RLock()
+	defer connMulti.mutex.RUnlock()
+
+	var best *tarantool.Connection
+	var bestWeight uint64
+	for _, addr := range connMulti.addrs {
+		conn := connMulti.pool[addr]
+		if conn == nil || !conn.ConnectedNow() {
+			continue
+		}
+		if mode != ModeAny {
+			ro, ok := connMulti.readOnly[addr]
+			if !ok || ro != (mode == ModeRO) {
+				continue
+			}
+		}
+		if key == "" {
+			if connMulti.opts.LocalZone == "" {
+				return conn
+			}
+			if best == nil || connMulti.closerZone(addr, best.Addr()) {
+				best = conn
+			}
+			continue
+		}
+		if weight := rendezvousWeight(addr, key); best == nil || weight > bestWeight {
+			best, bestWeight = conn, weight
+		}
+	}
+	return best
+}
+
+func rendezvousWeight(addr, key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(addr))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return h.Sum64()
+}
+
+// trackRoles polls read-only state of instances once and enables its
+// polling every CheckTimeout. Concurrent callers wait for the first poll.
+func (connMulti *ConnectionMulti) trackRoles() {
+	connMulti.rolesOnce.Do(func() {
+		connMulti.pollReadOnly()
+		atomic.StoreUint32(&connMulti.rolesTracked, 1)
+	})
+}
+
+// pollReadOnly polls instances concurrently, instances that do not
+// respond in CheckTimeout are treated as of unknown state.
+func (connMulti *ConnectionMulti) pollReadOnly() {
+	connMulti.mutex.RLock()
+	conns := make(map[string]*tarantool.Connection, len(connMulti.pool))
+	for addr, conn := range connMulti.pool {
+		conns[addr] = conn
+	}
+	connMulti.mutex.RUnlock()
+
+	readOnly := make(map[string]bool, len(conns))
+	vclocks := make(map[string]Vclock, len(conns))
+	latency := make(map[string]time.Duration, len(conns))
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for addr, conn := range conns {
+		if !conn.ConnectedNow() {
+			continue
+		}
+		wg.Add(1)
+		go func(addr string, conn *tarantool.Connection) {
+			defer wg.Done()
+			start := time.Now()
+			fut := conn.EvalAsync("return box.info.ro, box.info.vclock", []interface{}{})
+			timer := time.NewTimer(connMulti.opts.CheckTimeout)
+			defer timer.Stop()
+			select {
+			case <-fut.WaitChan():
+			case <-timer.C:
+				return
+			}
+			resp, err := fut.Get()
+			if err != nil || len(resp.Data) != 2 {
+				return
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			latency[addr] = time.Since(start)
+			if ro, ok := resp.Data[0].(bool); ok {
+				readOnly[addr] = ro
+			}
+			vclocks[addr] = decodeVclock(resp.Data[1])
+		}(addr, conn)
+	}
+	wg.Wait()
+
+	connMulti.mutex.Lock()
+	connMulti.readOnly = readOnly
+	connMulti.vclocks = vclocks
+	connMulti.latency = latency
+	connMulti.mutex.Unlock()
+}
+
+// DoRequest routes a request of a custom type and waits for response.
+// Requests of read-only types (see tarantool.RequestType) are routed with
+// ModePreferRO and are resent on failover, other requests are routed
+// with ModeRW.
+func (connMulti *ConnectionMulti) DoRequest(req tarantool.Request) (*tarantool.Response, error) {
+	hint := RouteHint{Mode: ModeRW}
+	if typ, ok := tarantool.LookupRequestType(req.Code()); ok && typ.ReadOnly {
+		hint = RouteHint{Mode: ModePreferRO, Idempotent: true}
+	}
+	return connMulti.Do(func(conn tarantool.Connector) *tarantool.Future {
+		return conn.(*tarantool.Connection).Do(req)
+	}, hint)
+}
@@ -0,0 +1,175 @@
+package multi
+
+import (
+	"sync"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// Vclock is a vector clock of an instance, see box.info.vclock.
+// It maps replica id to LSN.
+type Vclock map[uint64]uint64
+
+// Reached reports if vclock is not behind other. Local component
+// (replica id 0) is not replicated, so it is not compared.
+func (vclock Vclock) Reached(other Vclock) bool {
+	for id, lsn := range other {
+		if id != 0 && vclock[id] < lsn {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeVclock(v interface{}) Vclock {
+	vclock := make(Vclock)
+	switch v := v.(type) {
+	case []interface{}:
+		// sequential ids starting with 1 are encoded as array
+		for i, lsn := range v {
+			if lsn, ok := toUint64(lsn); ok {
+				vclock[uint64(i+1)] = lsn
+			}
+		}
+	case map[interface{}]interface{}:
+		for id, lsn := range v {
+			id, ok1 := toUint64(id)
+			lsn, ok2 := toUint64(lsn)
+			if ok1 && ok2 {
+				vclock[id] = lsn
+			}
+		}
+	}
+	return vclock
+}
+
+func toUint64(v interface{}) (uint64, bool) {
+	switch v := v.(type) {
+	case uint64:
+		return v, true
+	case int64:
+		return uint64(v), v >= 0
+	case uint32:
+		return uint64(v), true
+	case int32:
+		return uint64(v), v >= 0
+	case uint16:
+		return uint64(v), true
+	case int16:
+		return uint64(v), v >= 0
+	case uint8:
+		return uint64(v), true
+	case int8:
+		return uint64(v), v >= 0
+	}
+	return 0, false
+}
+
+// Session provides read-your-writes consistency over pool.
+//
+// Writes of a session are routed to a writable instance and vclock of
+// the instance is remembered after each write. Reads of a session are
+// routed only to read-only instances that have reached remembered vclock,
+// or to the instance of the last write (a writable instance if it is
+// down) if there are none.
+//
+// Vclock of read-only instances is polled every CheckTimeout since the
+// first session is created, so reads shortly after a write are usually
+// served by a writable instance.
+type Session struct {
+	connMulti *ConnectionMulti
+	mutex     sync.Mutex
+	vclock    Vclock
+	writer    string
+}
+
+// NewSession creates a session with read-your-writes consistency.
+func (connMulti *ConnectionMulti) NewSession() *Session {
+	connMulti.trackRoles()
+	return &Session{connMulti: connMulti, vclock: make(Vclock)}
+}
+
+// Vclock returns vclock of the last write of the session.
+func (s *Session) Vclock() Vclock {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	vclock := make(Vclock, len(s.vclock))
+	for id, lsn := range s.vclock {
+		vclock[id] = lsn
+	}
+	return vclock
+}
+
+// Write routes request built by req to a writable instance, waits for
+// response and remembers vclock of the instance.
+func (s *Session) Write(req func(tarantool.Connector) *tarantool.Future) (*tarantool.Response, error) {
+	conn := s.connMulti.routeMode(ModeRW, "")
+	if conn == nil {
+		return nil, ErrNoInstance
+	}
+	resp, err := req(conn).Get()
+	if err != nil {
+		return resp, err
+	}
+	vclock, err := fetchVclock(conn)
+	if err != nil {
+		return resp, err
+	}
+	s.mutex.Lock()
+	s.writer = conn.Addr()
+	for id, lsn := range vclock {
+		if lsn > s.vclock[id] {
+			s.vclock[id] = lsn
+		}
+	}
+	s.mutex.Unlock()
+	return resp, nil
+}
+
+// Read routes request built by req to a read-only instance that has
+// reached vclock of the session writes, or to the instance of the last
+// write, and waits for response.
+func (s *Session) Read(req func(tarantool.Connector) *tarantool.Future) (*tarantool.Response, error) {
+	conn := s.route()
+	if conn == nil {
+		return nil, ErrNoInstance
+	}
+	return req(conn).Get()
+}
+
+func (s *Session) route() *tarantool.Connection {
+	vclock := s.Vclock()
+	s.mutex.Lock()
+	writer := s.writer
+	s.mutex.Unlock()
+	connMulti := s.connMulti
+
+	connMulti.mutex.RLock()
+	for _, addr := range connMulti.addrs {
+		conn := connMulti.pool[addr]
+		if conn == nil || !conn.ConnectedNow() || !connMulti.readOnly[addr] {
+			continue
+		}
+		if connMulti.vclocks[addr].Reached(vclock) {
+			connMulti.mutex.RUnlock()
+			return conn
+		}
+	}
+	if conn := connMulti.pool[writer]; conn != nil && conn.ConnectedNow() {
+		connMulti.mutex.RUnlock()
+		return conn
+	}
+	connMulti.mutex.RUnlock()
+	return connMulti.routeMode(ModeRW, "")
+}
+
+func fetchVclock(conn *tarantool.Connection) (Vclock, error) {
+	resp, err := conn.Eval("return box.info.vclock", []interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return make(Vclock), nil
+	}
+	return decodeVclock(resp.Data[0]), nil
+}
@@ -0,0 +1,105 @@
+package multi
+
+import (
+	"expvar"
+	"strconv"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/dump"
+)
+
+// InstanceStats is a statistics of connection to an instance of pool.
+// Request and size statistics are collected only if they are enabled
+// in connection options, see tarantool.Opts.TrackRequests and
+// tarantool.Opts.TrackSizes.
+type InstanceStats struct {
+	tarantool.Stats
+	// Connected reports if instance is connected.
+	Connected bool
+	// ReadOnly reports if instance is read only. It is known only while
+	// read-only state is polled, see OptsMulti.TrackRoles.
+	ReadOnly bool
+}
+
+// Stats returns statistics of instances by their addresses.
+func (connMulti *ConnectionMulti) Stats() map[string]InstanceStats {
+	connMulti.mutex.RLock()
+	defer connMulti.mutex.RUnlock()
+
+	stats := make(map[string]InstanceStats, len(connMulti.pool))
+	for addr, conn := range connMulti.pool {
+		stats[addr] = InstanceStats{
+			Stats:     conn.Stats(),
+			Connected: conn.ConnectedNow(),
+			ReadOnly:  connMulti.readOnly[addr],
+		}
+	}
+	return stats
+}
+
+// StatsExporter receives request statistics broken down by instance,
+// space and request type, so hot spaces could be found per instance.
+type StatsExporter interface {
+	// ExportRequestStats is called for each instance, space and request
+	// type. Space is a space name if schema is loaded, or its number
+	// otherwise, it is empty for requests without space.
+	ExportRequestStats(addr, space, request string, stats tarantool.RequestStats)
+}
+
+// ExportStats passes request statistics of all instances to exporter.
+func (connMulti *ConnectionMulti) ExportStats(exporter StatsExporter) {
+	for addr, conn := range connMulti.Instances() {
+		for key, stats := range conn.Stats().Requests {
+			exporter.ExportRequestStats(addr, spaceLabel(conn, key.SpaceNo),
+				dump.RequestName(uint64(key.RequestCode)), stats)
+		}
+	}
+}
+
+func spaceLabel(conn *tarantool.Connection, spaceNo uint32) string {
+	if spaceNo == 0 {
+		return ""
+	}
+	if schema := conn.Schema; schema != nil {
+		if space, ok := schema.SpacesById[spaceNo]; ok {
+			return space.Name
+		}
+	}
+	return strconv.FormatUint(uint64(spaceNo), 10)
+}
+
+// expvarStats collects request statistics as nested maps by instance,
+// space and request type.
+type expvarStats map[string]map[string]map[string]interface{}
+
+func (s expvarStats) ExportRequestStats(addr, space, request string, stats tarantool.RequestStats) {
+	spaces := s[addr]
+	if spaces == nil {
+		spaces = make(map[string]map[string]interface{})
+		s[addr] = spaces
+	}
+	requests := spaces[space]
+	if requests == nil {
+		requests = make(map[string]interface{})
+		spaces[space] = requests
+	}
+	requests[request] = map[string]interface{}{
+		"count":          stats.Count,
+		"errors":         stats.Errors,
+		"avg_latency_us": int64(stats.AvgLatency() / time.Microsecond),
+		"max_latency_us": int64(stats.MaxLatency / time.Microsecond),
+	}
+}
+
+// Expvar returns request statistics as expvar variable, which is
+// evaluated on each read:
+//
+//	expvar.Publish("tarantool", connMulti.Expvar())
+func (connMulti *ConnectionMulti) Expvar() expvar.Var {
+	return expvar.Func(func() interface{} {
+		stats := make(expvarStats)
+		connMulti.ExportStats(stats)
+		return stats
+	})
+}
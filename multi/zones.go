@@ -0,0 +1,67 @@
+package multi
+
+import (
+	"time"
+)
+
+// ZoneHealth is an aggregated state of instances of a zone.
+type ZoneHealth struct {
+	// Instances is a number of instances in the zone.
+	Instances int
+	// Connected is a number of connected instances in the zone.
+	Connected int
+	// Latency is an average latency of connected instances in the zone.
+	Latency time.Duration
+}
+
+// Zone returns zone of an instance.
+func (connMulti *ConnectionMulti) Zone(addr string) string {
+	return connMulti.opts.Zones[addr]
+}
+
+// ZonesHealth returns health of instances aggregated by zones. Instances
+// without zone are aggregated under empty zone.
+func (connMulti *ConnectionMulti) ZonesHealth() map[string]ZoneHealth {
+	connMulti.trackRoles()
+	connMulti.mutex.RLock()
+	defer connMulti.mutex.RUnlock()
+
+	zones := make(map[string]ZoneHealth)
+	for _, addr := range connMulti.addrs {
+		zone := connMulti.opts.Zones[addr]
+		health := zones[zone]
+		health.Instances++
+		if conn := connMulti.pool[addr]; conn != nil && conn.ConnectedNow() {
+			// sum of latencies until averaged below
+			health.Latency += connMulti.latency[addr]
+			health.Connected++
+		}
+		zones[zone] = health
+	}
+	for zone, health := range zones {
+		if health.Connected > 0 {
+			health.Latency /= time.Duration(health.Connected)
+			zones[zone] = health
+		}
+	}
+	return zones
+}
+
+// closerZone reports if instance addr is preferred over other by zone
+// aware routing. It should be called under connMulti.mutex.
+func (connMulti *ConnectionMulti) closerZone(addr, other string) bool {
+	local := connMulti.opts.Zones[addr] == connMulti.opts.LocalZone
+	otherLocal := connMulti.opts.Zones[other] == connMulti.opts.LocalZone
+	if connMulti.opts.CrossZonePenalty <= 0 && local != otherLocal {
+		return local
+	}
+	return connMulti.zoneLatency(addr, local) < connMulti.zoneLatency(other, otherLocal)
+}
+
+func (connMulti *ConnectionMulti) zoneLatency(addr string, local bool) time.Duration {
+	latency := connMulti.latency[addr]
+	if !local {
+		latency += connMulti.opts.CrossZonePenalty
+	}
+	return latency
+}
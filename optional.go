@@ -0,0 +1,77 @@
+//go:build go1.18
+// +build go1.18
+
+package tarantool
+
+import (
+	"fmt"
+	"reflect"
+
+	"gopkg.in/vmihailenco/msgpack.v2"
+	"gopkg.in/vmihailenco/msgpack.v2/codes"
+)
+
+// Optional is a field of typed decode target which distinguishes an absent
+// field from an explicit nil, e.g. for spaces with nullable trailing fields:
+// tuple {1} has no second field, while tuple {1, nil} has it set to nil.
+//
+// Fields of structs decoded from tuples are absent if tuple is shorter
+// than struct, so Present is left false. Note that msgpack decoder handles
+// nil values without calling custom decoders, so each Optional type should
+// be registered with RegisterOptional to detect explicit nils.
+type Optional[T any] struct {
+	// Value is a decoded value, it is zero if field is absent or nil.
+	Value T
+	// Present reports if field is present in tuple.
+	Present bool
+	// Null reports if field is present and is nil.
+	Null bool
+}
+
+// Some returns present non-nil Optional value.
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Present: true}
+}
+
+// Get returns value and reports if it is present and not nil.
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Present && !o.Null
+}
+
+// EncodeMsgpack encodes absent and nil values as nil.
+func (o Optional[T]) EncodeMsgpack(e *msgpack.Encoder) error {
+	if !o.Present || o.Null {
+		return e.EncodeNil()
+	}
+	return e.Encode(o.Value)
+}
+
+// DecodeMsgpack decodes present value.
+func (o *Optional[T]) DecodeMsgpack(d *msgpack.Decoder) error {
+	var zero T
+	o.Value, o.Present, o.Null = zero, true, false
+	code, err := d.PeekCode()
+	if err != nil {
+		return err
+	}
+	if code == codes.Nil {
+		o.Null = true
+		return d.DecodeNil()
+	}
+	return d.Decode(&o.Value)
+}
+
+// RegisterOptional registers decoder of Optional[T] which detects explicit
+// nils. It should be called before values are decoded, e.g. in init.
+func RegisterOptional[T any]() {
+	msgpack.Register(reflect.TypeOf(Optional[T]{}),
+		func(e *msgpack.Encoder, v reflect.Value) error {
+			return v.Interface().(Optional[T]).EncodeMsgpack(e)
+		},
+		func(d *msgpack.Decoder, v reflect.Value) error {
+			if !v.CanAddr() {
+				return fmt.Errorf("msgpack: Decode(nonsettable %s)", v.Type())
+			}
+			return v.Addr().Interface().(*Optional[T]).DecodeMsgpack(d)
+		})
+}
@@ -0,0 +1,60 @@
+//go:build go1.18
+// +build go1.18
+
+package tarantool_test
+
+import (
+	"testing"
+
+	. "github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+type nullableTuple struct {
+	Id    uint64
+	Name  Optional[string]
+	Score Optional[int64]
+}
+
+func init() {
+	RegisterOptional[string]()
+	RegisterOptional[int64]()
+}
+
+func TestOptional(t *testing.T) {
+	b, err := msgpack.Marshal([]interface{}{1, nil})
+	if err != nil {
+		t.Fatalf("Failed to encode: %s", err.Error())
+	}
+	var tuple nullableTuple
+	if err = msgpack.Unmarshal(b, &tuple); err != nil {
+		t.Fatalf("Failed to decode: %s", err.Error())
+	}
+	if !tuple.Name.Present || !tuple.Name.Null {
+		t.Errorf("Explicit nil is not detected: %+v", tuple.Name)
+	}
+	if tuple.Score.Present {
+		t.Errorf("Absent field is present: %+v", tuple.Score)
+	}
+
+	b, _ = msgpack.Marshal([]interface{}{1, "name", 5})
+	tuple = nullableTuple{}
+	if err = msgpack.Unmarshal(b, &tuple); err != nil {
+		t.Fatalf("Failed to decode: %s", err.Error())
+	}
+	if name, ok := tuple.Name.Get(); !ok || name != "name" {
+		t.Errorf("Unexpected name: %+v", tuple.Name)
+	}
+	if score, ok := tuple.Score.Get(); !ok || score != 5 {
+		t.Errorf("Unexpected score: %+v", tuple.Score)
+	}
+
+	b, err = msgpack.Marshal([]interface{}{Some("x"), Optional[int64]{}})
+	if err != nil {
+		t.Fatalf("Failed to encode: %s", err.Error())
+	}
+	var decoded []interface{}
+	if err = msgpack.Unmarshal(b, &decoded); err != nil || len(decoded) != 2 || decoded[0] != "x" || decoded[1] != nil {
+		t.Errorf("Unexpected encoding: %v, %v", decoded, err)
+	}
+}
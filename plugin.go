@@ -0,0 +1,85 @@
+package tarantool
+
+import (
+	"fmt"
+	"sync"
+
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// Request is a request of a custom type, e.g. an iproto extension of
+// a proxy or of Enterprise edition, that is sent with Connection.Do.
+type Request interface {
+	// Code returns iproto request type.
+	Code() int32
+	// Body encodes request body. It should be a map with iproto keys.
+	Body(enc *msgpack.Encoder) error
+}
+
+// RequestType describes a custom request type registered with
+// RegisterRequestType.
+type RequestType struct {
+	// Name is a name of the request type for logs and dumps.
+	Name string
+	// ReadOnly reports that requests of the type do not modify data, so
+	// pools may route them to replicas and resend them on failover.
+	ReadOnly bool
+	// DecodeKey decodes a response body key that is unknown to Response.
+	// Decoded values are stored in Response.Extra. By default, unknown
+	// keys are skipped.
+	DecodeKey func(key int, d *msgpack.Decoder) (interface{}, error)
+}
+
+var (
+	requestTypesMutex sync.RWMutex
+	requestTypes      = make(map[int32]RequestType)
+)
+
+// builtinRequests are request types supported by Connection methods.
+var builtinRequests = []int32{
+	SelectRequest, InsertRequest, ReplaceRequest, UpdateRequest,
+	DeleteRequest, CallRequest, AuthRequest, EvalRequest, UpsertRequest,
+	Call17Request, PingRequest, SubscribeRequest, IdRequest,
+}
+
+// RegisterRequestType registers a custom request type. It fails if code
+// is a type of a builtin request or is already registered. It is intended
+// to be called from init functions of packages implementing requests.
+func RegisterRequestType(code int32, typ RequestType) error {
+	for _, builtin := range builtinRequests {
+		if code == builtin {
+			return fmt.Errorf("request type %d is builtin", code)
+		}
+	}
+	requestTypesMutex.Lock()
+	defer requestTypesMutex.Unlock()
+	if registered, ok := requestTypes[code]; ok {
+		return fmt.Errorf("request type %d is already registered as %q", code, registered.Name)
+	}
+	requestTypes[code] = typ
+	return nil
+}
+
+// LookupRequestType returns registered custom request type.
+func LookupRequestType(code int32) (typ RequestType, ok bool) {
+	requestTypesMutex.RLock()
+	defer requestTypesMutex.RUnlock()
+	typ, ok = requestTypes[code]
+	return
+}
+
+func lookupDecodeKey(code int32) func(int, *msgpack.Decoder) (interface{}, error) {
+	if code == SelectRequest || code == Call17Request {
+		// fast path for most frequent requests
+		return nil
+	}
+	typ, _ := LookupRequestType(code)
+	return typ.DecodeKey
+}
+
+// Do sends a request of a custom type and returns Future. Requests of
+// unregistered types are sent as well, but keys of their responses that
+// are unknown to Response are skipped.
+func (conn *Connection) Do(req Request) *Future {
+	return conn.newFuture(req.Code(), 0).send(conn, req.Body)
+}
@@ -0,0 +1,181 @@
+package tarantool
+
+import (
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// PreparedRequest is an immutable request with body encoded ahead of
+// time. It is sent without re-encoding, only request id is written for
+// each send, so it suits hot path requests with constant bodies. It is
+// safe to send it concurrently and over several connections.
+//
+// Spaces and indexes of prepared requests are resolved once on prepare,
+// so requests should be prepared again if schema changes.
+type PreparedRequest struct {
+	code     int32
+	spaceNo  uint32
+	function string
+	body     []byte
+}
+
+// Freeze encodes body of req ahead of time.
+func Freeze(req Request) (*PreparedRequest, error) {
+	return prepare(req.Code(), 0, "", req.Body)
+}
+
+func prepare(code int32, spaceNo uint32, function string, body func(*msgpack.Encoder) error) (*PreparedRequest, error) {
+	packet, err := encodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+	return &PreparedRequest{
+		code:     code,
+		spaceNo:  spaceNo,
+		function: function,
+		body:     packet,
+	}, nil
+}
+
+// Code implements Request.
+func (req *PreparedRequest) Code() int32 {
+	return req.code
+}
+
+// Body implements Request, it writes encoded body.
+func (req *PreparedRequest) Body(enc *msgpack.Encoder) error {
+	_, err := enc.Writer().Write(req.body)
+	return err
+}
+
+// Size returns size of encoded body.
+func (req *PreparedRequest) Size() int {
+	return len(req.body)
+}
+
+// SendPrepared sends prepared request and returns Future.
+func (conn *Connection) SendPrepared(req *PreparedRequest) *Future {
+	return conn.newFutureFor(req.code, req.spaceNo, req.function).send(conn, rawBody(req.body))
+}
+
+// PrepareSelect encodes select request ahead of time, space and index are
+// resolved with loaded schema.
+func (conn *Connection) PrepareSelect(space, index interface{}, offset, limit, iterator uint32, key interface{}) (*PreparedRequest, error) {
+	return prepareSelect(conn.resolver(), space, index, offset, limit, iterator, key)
+}
+
+// PrepareInsert encodes insert request ahead of time.
+func (conn *Connection) PrepareInsert(space interface{}, tuple interface{}) (*PreparedRequest, error) {
+	return prepareInsert(conn.resolver(), space, tuple)
+}
+
+// PrepareReplace encodes replace request ahead of time.
+func (conn *Connection) PrepareReplace(space interface{}, tuple interface{}) (*PreparedRequest, error) {
+	return prepareReplace(conn.resolver(), space, tuple)
+}
+
+// PrepareDelete encodes delete request ahead of time.
+func (conn *Connection) PrepareDelete(space, index interface{}, key interface{}) (*PreparedRequest, error) {
+	return prepareDelete(conn.resolver(), space, index, key)
+}
+
+// PrepareUpdate encodes update request ahead of time.
+func (conn *Connection) PrepareUpdate(space, index interface{}, key, ops interface{}) (*PreparedRequest, error) {
+	return prepareUpdate(conn.resolver(), space, index, key, ops)
+}
+
+// PrepareUpsert encodes upsert request ahead of time.
+func (conn *Connection) PrepareUpsert(space interface{}, tuple, ops interface{}) (*PreparedRequest, error) {
+	return prepareUpsert(conn.resolver(), space, tuple, ops)
+}
+
+// PrepareSelect encodes select request ahead of time, space and index are
+// resolved with schema. Prepare methods of schema do not need a
+// connection, so requests may be encoded with imported schema, see
+// ImportSchema.
+func (schema *Schema) PrepareSelect(space, index interface{}, offset, limit, iterator uint32, key interface{}) (*PreparedRequest, error) {
+	return prepareSelect(schema, space, index, offset, limit, iterator, key)
+}
+
+func prepareSelect(r SchemaResolver, space, index interface{}, offset, limit, iterator uint32, key interface{}) (*PreparedRequest, error) {
+	spaceNo, indexNo, err := r.ResolveSpaceIndex(space, index)
+	if err != nil {
+		return nil, err
+	}
+	return prepare(SelectRequest, spaceNo, "", selectBody(spaceNo, indexNo, offset, limit, iterator, key))
+}
+
+// PrepareInsert encodes insert request ahead of time.
+func (schema *Schema) PrepareInsert(space interface{}, tuple interface{}) (*PreparedRequest, error) {
+	return prepareInsert(schema, space, tuple)
+}
+
+func prepareInsert(r SchemaResolver, space interface{}, tuple interface{}) (*PreparedRequest, error) {
+	spaceNo, _, err := r.ResolveSpaceIndex(space, nil)
+	if err != nil {
+		return nil, err
+	}
+	return prepare(InsertRequest, spaceNo, "", insertBody(spaceNo, tuple))
+}
+
+// PrepareReplace encodes replace request ahead of time.
+func (schema *Schema) PrepareReplace(space interface{}, tuple interface{}) (*PreparedRequest, error) {
+	return prepareReplace(schema, space, tuple)
+}
+
+func prepareReplace(r SchemaResolver, space interface{}, tuple interface{}) (*PreparedRequest, error) {
+	spaceNo, _, err := r.ResolveSpaceIndex(space, nil)
+	if err != nil {
+		return nil, err
+	}
+	return prepare(ReplaceRequest, spaceNo, "", insertBody(spaceNo, tuple))
+}
+
+// PrepareDelete encodes delete request ahead of time.
+func (schema *Schema) PrepareDelete(space, index interface{}, key interface{}) (*PreparedRequest, error) {
+	return prepareDelete(schema, space, index, key)
+}
+
+func prepareDelete(r SchemaResolver, space, index interface{}, key interface{}) (*PreparedRequest, error) {
+	spaceNo, indexNo, err := r.ResolveSpaceIndex(space, index)
+	if err != nil {
+		return nil, err
+	}
+	return prepare(DeleteRequest, spaceNo, "", deleteBody(spaceNo, indexNo, key))
+}
+
+// PrepareUpdate encodes update request ahead of time.
+func (schema *Schema) PrepareUpdate(space, index interface{}, key, ops interface{}) (*PreparedRequest, error) {
+	return prepareUpdate(schema, space, index, key, ops)
+}
+
+func prepareUpdate(r SchemaResolver, space, index interface{}, key, ops interface{}) (*PreparedRequest, error) {
+	spaceNo, indexNo, err := r.ResolveSpaceIndex(space, index)
+	if err != nil {
+		return nil, err
+	}
+	return prepare(UpdateRequest, spaceNo, "", updateBody(spaceNo, indexNo, key, ops))
+}
+
+// PrepareUpsert encodes upsert request ahead of time.
+func (schema *Schema) PrepareUpsert(space interface{}, tuple, ops interface{}) (*PreparedRequest, error) {
+	return prepareUpsert(schema, space, tuple, ops)
+}
+
+func prepareUpsert(r SchemaResolver, space interface{}, tuple, ops interface{}) (*PreparedRequest, error) {
+	spaceNo, _, err := r.ResolveSpaceIndex(space, nil)
+	if err != nil {
+		return nil, err
+	}
+	return prepare(UpsertRequest, spaceNo, "", upsertBody(spaceNo, tuple, ops))
+}
+
+// PrepareCall17 encodes call request ahead of time.
+func (conn *Connection) PrepareCall17(functionName string, args interface{}) (*PreparedRequest, error) {
+	requestCode, body := conn.call17Body(functionName, args)
+	return prepare(requestCode, 0, functionName, body)
+}
+
+// PrepareEval encodes eval request ahead of time.
+func (conn *Connection) PrepareEval(expr string, args interface{}) (*PreparedRequest, error) {
+	return prepare(EvalRequest, 0, "", evalBody(expr, args))
+}
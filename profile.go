@@ -0,0 +1,72 @@
+package tarantool
+
+import (
+	"context"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+)
+
+var requestTypeNames = map[int32]string{
+	SelectRequest:    "select",
+	InsertRequest:    "insert",
+	ReplaceRequest:   "replace",
+	UpdateRequest:    "update",
+	DeleteRequest:    "delete",
+	CallRequest:      "call16",
+	AuthRequest:      "auth",
+	EvalRequest:      "eval",
+	UpsertRequest:    "upsert",
+	Call17Request:    "call",
+	PingRequest:      "ping",
+	SubscribeRequest: "subscribe",
+	IdRequest:        "id",
+}
+
+// requestTypeName returns name of request type used in profiler labels.
+func requestTypeName(code int32) string {
+	if name, ok := requestTypeNames[code]; ok {
+		return name
+	}
+	if typ, ok := LookupRequestType(code); ok && typ.Name != "" {
+		return typ.Name
+	}
+	return strconv.Itoa(int(code))
+}
+
+// profile runs f, which encodes or decodes request on stage, with pprof
+// labels of request added to labels of its ctx if Opts.ProfilerLabels is
+// set and within runtime/trace region if Opts.TraceRegions is set.
+func (fut *Future) profile(conn *Connection, stage string, f func()) {
+	if conn == nil || (!conn.opts.ProfilerLabels && !conn.opts.TraceRegions) {
+		f()
+		return
+	}
+	name := requestTypeName(fut.requestCode)
+	ctx := fut.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if conn.opts.TraceRegions {
+		defer trace.StartRegion(ctx, "tarantool."+name+"."+stage).End()
+	}
+	if !conn.opts.ProfilerLabels || fut.ctx == nil {
+		// labels of goroutine are unknown, so they could not be restored
+		f()
+		return
+	}
+	space := ""
+	if fut.spaceNo != 0 {
+		space = strconv.FormatUint(uint64(fut.spaceNo), 10)
+		if schema := conn.Schema; schema != nil {
+			if s, ok := schema.SpacesById[fut.spaceNo]; ok {
+				space = s.Name
+			}
+		}
+	}
+	labels := pprof.Labels(
+		"tarantool_request", name,
+		"tarantool_space", space,
+		"tarantool_stage", stage)
+	pprof.Do(ctx, labels, func(context.Context) { f() })
+}
@@ -0,0 +1,139 @@
+package tarantool
+
+import (
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// GetProjected waits for Future and decodes only requested fields of
+// returned tuples, other fields are skipped without decoding. Fields are
+// numbered from 0, values are returned in order of fields; fields absent
+// in a tuple are nil. It is faster than Get for wide tuples.
+func (fut *Future) GetProjected(fields []uint32) ([][]interface{}, error) {
+	fut.wait()
+	if fut.err != nil {
+		return nil, fut.err
+	}
+	var res [][]interface{}
+	body := fut.resp.buf.Bytes()
+	fut.err = fut.decodeError(body, fut.resp.decodeBodyProjected(fields, &res))
+	return res, fut.err
+}
+
+func (resp *Response) decodeBodyProjected(fields []uint32, res *[][]interface{}) (err error) {
+	if resp.buf.Len() == 0 {
+		return nil
+	}
+	// positions[i] is a list of result columns of tuple field i
+	var maxField uint32
+	for _, f := range fields {
+		if f > maxField {
+			maxField = f
+		}
+	}
+	positions := make([][]int, maxField+1)
+	for i, f := range fields {
+		positions[f] = append(positions[f], i)
+	}
+
+	var l int
+	d := msgpack.NewDecoder(&resp.buf)
+	if l, err = d.DecodeMapLen(); err != nil {
+		return err
+	}
+	for ; l > 0; l-- {
+		var cd int
+		if cd, err = resp.smallInt(d); err != nil {
+			return err
+		}
+		switch cd {
+		case KeyData:
+			if *res, err = decodeProjectedTuples(d, positions, len(fields)); err != nil {
+				return err
+			}
+		case KeyError:
+			if resp.Error, err = d.DecodeString(); err != nil {
+				return err
+			}
+		default:
+			if err = d.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+	if resp.Code != OkCode {
+		resp.Code &^= ErrorCodeBit
+		err = Error{resp.Code, resp.Error}
+	}
+	return
+}
+
+func decodeProjectedTuples(d *msgpack.Decoder, positions [][]int, width int) ([][]interface{}, error) {
+	n, err := d.DecodeArrayLen()
+	if err != nil || n < 0 {
+		return nil, err
+	}
+	tuples := make([][]interface{}, n)
+	for i := range tuples {
+		m, err := d.DecodeArrayLen()
+		if err != nil {
+			return nil, err
+		}
+		tuple := make([]interface{}, width)
+		for j := 0; j < m; j++ {
+			if j >= len(positions) || len(positions[j]) == 0 {
+				if err = d.Skip(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			v, err := d.DecodeInterface()
+			if err != nil {
+				return nil, err
+			}
+			for _, pos := range positions[j] {
+				tuple[pos] = v
+			}
+		}
+		tuples[i] = tuple
+	}
+	return tuples, nil
+}
+
+const selectFieldsLua = `
+local space, index, offset, limit, iterator, key, fields = ...
+local tuples = box.space[space].index[index]:select(key, {
+    offset = offset, limit = limit, iterator = iterator,
+})
+local res = setmetatable({}, {__serialize = 'seq'})
+for i, t in ipairs(tuples) do
+    local r = setmetatable({}, {__serialize = 'seq'})
+    for j, f in ipairs(fields) do
+        local v = t[f + 1]
+        if v == nil then
+            v = box.NULL
+        end
+        r[j] = v
+    end
+    res[i] = r
+end
+return res
+`
+
+// SelectFields performs select to box space and returns only requested
+// fields of tuples (numbered from 0). Projection is performed on server
+// side with Lua, so only requested fields are sent over network.
+func (conn *Connection) SelectFields(space, index interface{}, offset, limit, iterator uint32, key interface{}, fields []uint32) ([][]interface{}, error) {
+	spaceNo, indexNo, err := conn.resolveSpaceIndex(space, index)
+	if err != nil {
+		return nil, err
+	}
+	var res [][][]interface{}
+	args := []interface{}{spaceNo, indexNo, offset, limit, iterator, key, fields}
+	if err := conn.EvalTyped(selectFieldsLua, args, &res); err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return res[0], nil
+}
@@ -0,0 +1,103 @@
+package tarantool
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// ContextValue is a value of context.Context propagated to server,
+// see Opts.ContextValues.
+type ContextValue struct {
+	// Key is a key of value in context.Context.
+	Key interface{}
+	// Name is a key of value in the map passed to server.
+	Name string
+}
+
+// contextArgs appends values of ctx listed in Opts.ContextValues to args
+// as a map. args are kept as is if ctx contains none of them.
+func (conn *Connection) contextArgs(ctx context.Context, args interface{}) (interface{}, error) {
+	var values map[string]interface{}
+	for _, cv := range conn.opts.ContextValues {
+		if v := ctx.Value(cv.Key); v != nil {
+			if values == nil {
+				values = make(map[string]interface{}, len(conn.opts.ContextValues))
+			}
+			values[cv.Name] = v
+		}
+	}
+	if values == nil {
+		return args, nil
+	}
+	if args == nil {
+		return []interface{}{values}, nil
+	}
+	if a, ok := args.([]interface{}); ok {
+		res := make([]interface{}, len(a), len(a)+1)
+		copy(res, a)
+		return append(res, values), nil
+	}
+	v := reflect.ValueOf(args)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("context values could not be appended to args of type %T", args)
+	}
+	res := make([]interface{}, v.Len(), v.Len()+1)
+	for i := range res {
+		res[i] = v.Index(i).Interface()
+	}
+	return append(res, values), nil
+}
+
+// CallContext is the same as Call, but also passes values of ctx listed in
+// Opts.ContextValues as the last argument of the function (a map from
+// ContextValue.Name to value). ctx is used only for values and to tell
+// requests of Opts.OnConnect.
+func (conn *Connection) CallContext(ctx context.Context, functionName string, args interface{}) (resp *Response, err error) {
+	return conn.CallContextAsync(ctx, functionName, args).Get()
+}
+
+// Call17Context is the same as Call17, but also passes values of ctx listed
+// in Opts.ContextValues as the last argument of the function.
+// ctx is used only for values and to tell requests of Opts.OnConnect.
+func (conn *Connection) Call17Context(ctx context.Context, functionName string, args interface{}) (resp *Response, err error) {
+	return conn.Call17ContextAsync(ctx, functionName, args).Get()
+}
+
+// EvalContext is the same as Eval, but also passes values of ctx listed in
+// Opts.ContextValues as the last argument of expression.
+// ctx is used only for values and to tell requests of Opts.OnConnect.
+func (conn *Connection) EvalContext(ctx context.Context, expr string, args interface{}) (resp *Response, err error) {
+	return conn.EvalContextAsync(ctx, expr, args).Get()
+}
+
+// CallContextAsync is an asynchronous version of CallContext.
+func (conn *Connection) CallContextAsync(ctx context.Context, functionName string, args interface{}) *Future {
+	args, err := conn.contextArgs(ctx, args)
+	if err != nil {
+		return &Future{err: err}
+	}
+	future := conn.newFutureCtx(ctx, CallRequest, functionName)
+	return future.send(conn, callBody(functionName, args))
+}
+
+// Call17ContextAsync is an asynchronous version of Call17Context.
+func (conn *Connection) Call17ContextAsync(ctx context.Context, functionName string, args interface{}) *Future {
+	args, err := conn.contextArgs(ctx, args)
+	if err != nil {
+		return &Future{err: err}
+	}
+	requestCode, body := conn.call17Body(functionName, args)
+	future := conn.newFutureCtx(ctx, requestCode, functionName)
+	return future.send(conn, body)
+}
+
+// EvalContextAsync is an asynchronous version of EvalContext.
+func (conn *Connection) EvalContextAsync(ctx context.Context, expr string, args interface{}) *Future {
+	args, err := conn.contextArgs(ctx, args)
+	if err != nil {
+		return &Future{err: err}
+	}
+	future := conn.newFutureCtx(ctx, EvalRequest, "")
+	return future.send(conn, evalBody(expr, args))
+}
@@ -0,0 +1,299 @@
+package tarantool
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// ProtocolFeature is a feature of iproto protocol, see IPROTO_ID request.
+type ProtocolFeature uint64
+
+const (
+	// StreamsFeature means support of interactive transactions in streams.
+	StreamsFeature ProtocolFeature = 0
+	// TransactionsFeature means support of transactions over iproto.
+	TransactionsFeature ProtocolFeature = 1
+	// ErrorExtensionFeature means support of MP_ERROR extension type.
+	ErrorExtensionFeature ProtocolFeature = 2
+	// WatchersFeature means support of watch requests.
+	WatchersFeature ProtocolFeature = 3
+	// PaginationFeature means support of pagination in select requests.
+	PaginationFeature ProtocolFeature = 4
+	// SpaceAndIndexNamesFeature means support of space and index names
+	// in requests.
+	SpaceAndIndexNamesFeature ProtocolFeature = 5
+	// WatchOnceFeature means support of watch once requests.
+	WatchOnceFeature ProtocolFeature = 6
+	// DMLTupleExtensionFeature means support of MP_TUPLE extension in
+	// responses of data manipulation requests.
+	DMLTupleExtensionFeature ProtocolFeature = 7
+	// CallRetTupleExtensionFeature means support of MP_TUPLE extension in
+	// results of call and eval requests.
+	CallRetTupleExtensionFeature ProtocolFeature = 8
+	// CallArgTupleExtensionFeature means support of MP_TUPLE extension in
+	// arguments of call and eval requests.
+	CallArgTupleExtensionFeature ProtocolFeature = 9
+)
+
+// String implements Stringer interface
+func (f ProtocolFeature) String() string {
+	switch f {
+	case StreamsFeature:
+		return "StreamsFeature"
+	case TransactionsFeature:
+		return "TransactionsFeature"
+	case ErrorExtensionFeature:
+		return "ErrorExtensionFeature"
+	case WatchersFeature:
+		return "WatchersFeature"
+	case PaginationFeature:
+		return "PaginationFeature"
+	case SpaceAndIndexNamesFeature:
+		return "SpaceAndIndexNamesFeature"
+	case WatchOnceFeature:
+		return "WatchOnceFeature"
+	case DMLTupleExtensionFeature:
+		return "DMLTupleExtensionFeature"
+	case CallRetTupleExtensionFeature:
+		return "CallRetTupleExtensionFeature"
+	case CallArgTupleExtensionFeature:
+		return "CallArgTupleExtensionFeature"
+	}
+	return fmt.Sprintf("Unknown feature (code %d)", uint64(f))
+}
+
+// ProtocolInfo is a protocol version and features supported by Tarantool.
+//
+// Tarantool before 2.10 does not support IPROTO_ID request, so its
+// ProtocolInfo is empty.
+type ProtocolInfo struct {
+	// Version is a version of iproto protocol.
+	Version uint64
+	// Features are protocol features supported by Tarantool.
+	Features []ProtocolFeature
+}
+
+// Has reports if feature is supported.
+func (info ProtocolInfo) Has(feature ProtocolFeature) bool {
+	for _, f := range info.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// clientProtocolVersion is a version of iproto protocol announced by client.
+const clientProtocolVersion = 1
+
+// ProtocolMismatchError is returned on connect when protocol negotiation
+// with Tarantool fails or Tarantool does not satisfy
+// Opts.RequiredProtocolInfo. Such errors are reported by Connect
+// immediately even if reconnects are enabled.
+type ProtocolMismatchError struct {
+	// ServerVersion is a Tarantool version from greeting.
+	ServerVersion string
+	// Required is a protocol info required by Opts.RequiredProtocolInfo.
+	Required ProtocolInfo
+	// Server is a protocol info reported by Tarantool.
+	Server ProtocolInfo
+	// MissingFeatures are required features not supported by Tarantool.
+	MissingFeatures []ProtocolFeature
+	// Err is an error of IPROTO_ID request, it is nil if negotiation
+	// succeeded but Tarantool does not satisfy requirements.
+	Err error
+}
+
+func (e ProtocolMismatchError) Error() string {
+	server := strings.TrimSpace(e.ServerVersion)
+	if e.Err != nil {
+		return fmt.Sprintf("protocol negotiation with %q failed: %s", server, e.Err)
+	}
+	var reasons []string
+	if e.Required.Version > e.Server.Version {
+		reasons = append(reasons, fmt.Sprintf("protocol version %d is less than required %d",
+			e.Server.Version, e.Required.Version))
+	}
+	if len(e.MissingFeatures) > 0 {
+		missing := make([]string, len(e.MissingFeatures))
+		for i, f := range e.MissingFeatures {
+			missing[i] = f.String()
+		}
+		reasons = append(reasons, "missing features: "+strings.Join(missing, ", "))
+	}
+	return fmt.Sprintf("protocol of %q does not satisfy requirements: %s",
+		server, strings.Join(reasons, "; "))
+}
+
+// checkProtocolInfo returns ProtocolMismatchError if info does not satisfy
+// Opts.RequiredProtocolInfo.
+func (conn *Connection) checkProtocolInfo(info ProtocolInfo) error {
+	required := conn.opts.RequiredProtocolInfo
+	var missing []ProtocolFeature
+	for _, f := range required.Features {
+		if !info.Has(f) {
+			missing = append(missing, f)
+		}
+	}
+	if required.Version > info.Version || len(missing) > 0 {
+		return ProtocolMismatchError{
+			ServerVersion:   conn.Greeting.Version,
+			Required:        required,
+			Server:          info,
+			MissingFeatures: missing,
+		}
+	}
+	return nil
+}
+
+// ConnectionInfo is a result of connection handshake.
+type ConnectionInfo struct {
+	// Addr is an address of Tarantool.
+	Addr string
+	// ServerVersion is a Tarantool version from greeting.
+	ServerVersion string
+	// User is a name of authenticated user, it is empty for guest.
+	User string
+	// ClientProtocolVersion is a version of iproto protocol announced
+	// by client.
+	ClientProtocolVersion uint64
+	// ServerProtocolInfo is a protocol info reported by Tarantool.
+	ServerProtocolInfo ProtocolInfo
+	// ProtocolVersion is a negotiated version of iproto protocol, the
+	// least of client and server ones.
+	ProtocolVersion uint64
+}
+
+// ConnectionInfo returns values negotiated with Tarantool on the last
+// successful connect.
+func (conn *Connection) ConnectionInfo() ConnectionInfo {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	info := ConnectionInfo{
+		Addr:                  conn.addr,
+		ServerVersion:         strings.TrimSpace(conn.Greeting.Version),
+		User:                  conn.opts.User,
+		ClientProtocolVersion: clientProtocolVersion,
+		ServerProtocolInfo:    conn.protocolInfo,
+		ProtocolVersion:       conn.protocolInfo.Version,
+	}
+	info.ServerProtocolInfo.Features = append([]ProtocolFeature(nil), info.ServerProtocolInfo.Features...)
+	if info.ProtocolVersion > clientProtocolVersion {
+		info.ProtocolVersion = clientProtocolVersion
+	}
+	return info
+}
+
+// ProtocolInfo returns protocol version and features supported by
+// Tarantool connection is established with. Server version is available
+// in Greeting.
+func (conn *Connection) ProtocolInfo() ProtocolInfo {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	info := conn.protocolInfo
+	info.Features = append([]ProtocolFeature(nil), info.Features...)
+	return info
+}
+
+// RequireFeatures returns ClientError{Code: ErrFeatureUnsupported} if any
+// of features is not supported by Tarantool, so caller could fail fast
+// instead of getting server error.
+func (conn *Connection) RequireFeatures(features ...ProtocolFeature) error {
+	info := conn.ProtocolInfo()
+	var missing []string
+	for _, f := range features {
+		if !info.Has(f) {
+			missing = append(missing, f.String())
+		}
+	}
+	if len(missing) > 0 {
+		return ClientError{ErrFeatureUnsupported,
+			fmt.Sprintf("features are not supported by Tarantool: %s", strings.Join(missing, ", "))}
+	}
+	return nil
+}
+
+func (conn *Connection) writeIdRequest(w io.Writer) (err error) {
+	request := &Future{
+		requestId:   0,
+		requestCode: IdRequest,
+	}
+	var packet smallWBuf
+	err = request.pack(&packet, msgpack.NewEncoder(&packet), func(enc *msgpack.Encoder) error {
+		enc.EncodeMapLen(2)
+		enc.EncodeUint64(KeyVersion)
+		enc.EncodeUint64(clientProtocolVersion)
+		enc.EncodeUint64(KeyFeatures)
+		if !conn.opts.TupleExtension {
+			return enc.EncodeSliceLen(0)
+		}
+		enc.EncodeSliceLen(2)
+		enc.EncodeUint64(uint64(DMLTupleExtensionFeature))
+		return enc.EncodeUint64(uint64(CallRetTupleExtensionFeature))
+	})
+	if err != nil {
+		return errors.New("id: pack error " + err.Error())
+	}
+	if err := write(w, packet.b); err != nil {
+		return errors.New("id: write error " + err.Error())
+	}
+	return
+}
+
+func (conn *Connection) readIdResponse(r io.Reader) (info ProtocolInfo, err error) {
+	respBytes, err := conn.read(r)
+	if err != nil {
+		return info, errors.New("id: read error " + err.Error())
+	}
+	resp := Response{buf: smallBuf{b: respBytes}}
+	if err = resp.decodeHeader(conn.dec); err != nil {
+		return info, errors.New("id: decode response header error " + err.Error())
+	}
+	if resp.Code != OkCode {
+		if err = resp.decodeBody(); err != nil {
+			if ter, ok := err.(Error); ok && ter.Code == ErrUnknownRequestType {
+				// IPROTO_ID is not supported before Tarantool 2.10
+				return info, nil
+			}
+		}
+		return info, ProtocolMismatchError{
+			ServerVersion: conn.Greeting.Version,
+			Required:      conn.opts.RequiredProtocolInfo,
+			Err:           fmt.Errorf("id: unexpected response: %v", err),
+		}
+	}
+	d := msgpack.NewDecoder(&resp.buf)
+	var l int
+	if l, err = d.DecodeMapLen(); err != nil {
+		return info, errors.New("id: decode response body error " + err.Error())
+	}
+	for ; l > 0; l-- {
+		var cd int
+		if cd, err = resp.smallInt(d); err != nil {
+			return info, errors.New("id: decode response body error " + err.Error())
+		}
+		switch cd {
+		case KeyVersion:
+			info.Version, err = d.DecodeUint64()
+		case KeyFeatures:
+			var n int
+			if n, err = d.DecodeSliceLen(); err == nil {
+				for ; n > 0 && err == nil; n-- {
+					var f uint64
+					f, err = d.DecodeUint64()
+					info.Features = append(info.Features, ProtocolFeature(f))
+				}
+			}
+		default:
+			err = d.Skip()
+		}
+		if err != nil {
+			return info, errors.New("id: decode response body error " + err.Error())
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,513 @@
+// Package query executes dynamic queries: filter conditions on named
+// fields, sort and pagination, e.g. coming from GraphQL or REST
+// backends. Queries are compiled into the best available request:
+//
+//   - indexed select, if conditions or sort match a TREE or HASH index,
+//     other conditions are checked on client;
+//   - crud.select of crud module, if Executor.Crud is set;
+//   - full scan of primary index with filtering on client otherwise.
+//
+// Tuples are returned as maps keyed by field names of space format.
+package query
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/conditions"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// Op is an operator of condition.
+type Op string
+
+const (
+	Eq = Op("==")
+	Ne = Op("!=")
+	Lt = Op("<")
+	Le = Op("<=")
+	Gt = Op(">")
+	Ge = Op(">=")
+)
+
+// Condition is a condition on a field value.
+type Condition struct {
+	Field string
+	Op    Op
+	Value interface{}
+}
+
+// Sort is an order of results by field.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Query is a dynamic query to space.
+type Query struct {
+	Space string
+	// Conditions are combined with AND.
+	Conditions []Condition
+	Sort       []Sort
+	Offset     uint32
+	// Limit is a maximum number of results, zero means no limit.
+	Limit uint32
+}
+
+// Plan describes how query is executed.
+type Plan struct {
+	// Crud reports if query is executed with crud.select.
+	Crud bool
+	// Index is a name of selected index.
+	Index    string
+	Iterator uint32
+	Key      []interface{}
+	// Filter are conditions checked on client.
+	Filter []Condition
+	// ClientSort reports if results are sorted on client.
+	ClientSort bool
+	// ClientPage reports if offset and limit are applied on client, so
+	// all tuples matching request are fetched.
+	ClientPage bool
+}
+
+// String returns human-readable plan.
+func (p Plan) String() string {
+	var sb strings.Builder
+	if p.Crud {
+		sb.WriteString("crud.select")
+	} else {
+		fmt.Fprintf(&sb, "select index %s iterator %s key %v", p.Index,
+			tarantool.IteratorName(p.Iterator), p.Key)
+	}
+	if len(p.Filter) > 0 {
+		fmt.Fprintf(&sb, ", filter %v", p.Filter)
+	}
+	if p.ClientSort {
+		sb.WriteString(", sort on client")
+	}
+	if p.ClientPage {
+		sb.WriteString(", paginate on client")
+	}
+	return sb.String()
+}
+
+// Executor compiles and executes queries.
+type Executor struct {
+	conn *tarantool.Connection
+	// Crud executes queries which don't match any index with crud.select,
+	// e.g. on vshard routers.
+	Crud bool
+}
+
+// NewExecutor creates executor. Spaces of queries are resolved with
+// loaded schema.
+func NewExecutor(conn *tarantool.Connection) *Executor {
+	return &Executor{conn: conn}
+}
+
+func (e *Executor) space(q *Query) (*tarantool.Space, error) {
+	if e.conn.Schema == nil {
+		return nil, fmt.Errorf("Schema is not loaded")
+	}
+	space, ok := e.conn.Schema.Spaces[q.Space]
+	if !ok {
+		return nil, fmt.Errorf("there is no space with name %s", q.Space)
+	}
+	for _, c := range q.Conditions {
+		if _, ok := space.Fields[c.Field]; !ok {
+			return nil, fmt.Errorf("there is no field %s in space %s", c.Field, space.Name)
+		}
+		switch c.Op {
+		case Eq, Ne, Lt, Le, Gt, Ge:
+		default:
+			return nil, fmt.Errorf("unknown operator %q of condition on field %s", c.Op, c.Field)
+		}
+	}
+	for _, s := range q.Sort {
+		if _, ok := space.Fields[s.Field]; !ok {
+			return nil, fmt.Errorf("there is no field %s in space %s", s.Field, space.Name)
+		}
+	}
+	return space, nil
+}
+
+// Explain returns plan of query.
+func (e *Executor) Explain(q Query) (Plan, error) {
+	space, err := e.space(&q)
+	if err != nil {
+		return Plan{}, err
+	}
+	return e.plan(space, &q), nil
+}
+
+// Execute executes query.
+func (e *Executor) Execute(q Query) ([]map[string]interface{}, error) {
+	space, err := e.space(&q)
+	if err != nil {
+		return nil, err
+	}
+	plan := e.plan(space, &q)
+
+	offset, limit := q.Offset, q.Limit
+	if plan.ClientPage {
+		offset, limit = 0, 0
+	}
+	var tuples [][]interface{}
+	if plan.Crud {
+		tuples, err = e.crudSelect(space, &q, limit)
+	} else {
+		if limit == 0 {
+			limit = math.MaxUint32
+		}
+		err = e.conn.SelectTyped(space.Id, plan.Index, offset, limit, plan.Iterator, plan.Key, &tuples)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	maps := make([]map[string]interface{}, 0, len(tuples))
+	for _, tuple := range tuples {
+		m := space.TupleMap(tuple)
+		if matches(m, plan.Filter) {
+			maps = append(maps, m)
+		}
+	}
+	if plan.ClientSort {
+		sort.SliceStable(maps, func(i, j int) bool {
+			return less(maps[i], maps[j], q.Sort)
+		})
+	}
+	if plan.ClientPage {
+		maps = page(maps, q.Offset, q.Limit)
+	}
+	return maps, nil
+}
+
+// plan selects index with the longest prefix of equality conditions,
+// followed by range condition. If no index matches conditions, index
+// matching sort is used.
+func (e *Executor) plan(space *tarantool.Space, q *Query) Plan {
+	var best *indexPlan
+	for _, index := range space.IndexesById {
+		p := planIndex(space, index, q)
+		if p != nil && (best == nil || p.better(best)) {
+			best = p
+		}
+	}
+	if best == nil && e.Crud {
+		plan := Plan{Crud: true, ClientSort: len(q.Sort) > 0}
+		plan.ClientPage = plan.ClientSort || q.Offset > 0
+		return plan
+	}
+	if best == nil {
+		best = &indexPlan{index: space.IndexesById[0], iterator: tarantool.IterAll, key: []interface{}{}}
+	}
+
+	plan := Plan{
+		Index:    best.index.Name,
+		Iterator: best.iterator,
+		Key:      best.key,
+	}
+	for i, c := range q.Conditions {
+		if !best.used[i] {
+			plan.Filter = append(plan.Filter, c)
+		}
+	}
+	plan.ClientSort = len(q.Sort) > 0 && !best.sorted
+	plan.ClientPage = plan.ClientSort || len(plan.Filter) > 0
+	return plan
+}
+
+type indexPlan struct {
+	index    *tarantool.Index
+	iterator uint32
+	key      []interface{}
+	// used are conditions applied by index
+	used   map[int]bool
+	sorted bool
+}
+
+func (p *indexPlan) better(other *indexPlan) bool {
+	if len(p.used) != len(other.used) {
+		return len(p.used) > len(other.used)
+	}
+	if p.sorted != other.sorted {
+		return p.sorted
+	}
+	if p.index.Unique != other.index.Unique {
+		return p.index.Unique
+	}
+	return p.index.Id < other.index.Id
+}
+
+var rangeIterators = map[Op]uint32{
+	Lt: tarantool.IterLt,
+	Le: tarantool.IterLe,
+	Gt: tarantool.IterGt,
+	Ge: tarantool.IterGe,
+}
+
+// planIndex returns plan of select by index or nil if index matches
+// neither conditions nor sort.
+func planIndex(space *tarantool.Space, index *tarantool.Index, q *Query) *indexPlan {
+	typ := strings.ToUpper(index.Type)
+	if typ != "TREE" && typ != "HASH" {
+		return nil
+	}
+	p := &indexPlan{index: index, iterator: tarantool.IterEq, key: []interface{}{}, used: make(map[int]bool)}
+	// equality prefix
+	part := 0
+	for ; part < len(index.Fields); part++ {
+		i := findCondition(space, q.Conditions, index.Fields[part].Id, Eq)
+		if i < 0 {
+			break
+		}
+		p.used[i] = true
+		p.key = append(p.key, q.Conditions[i].Value)
+	}
+	if typ == "HASH" {
+		if part < len(index.Fields) {
+			return nil
+		}
+		return p
+	}
+
+	// range condition or sort on the next part
+	if part < len(index.Fields) {
+		for i, c := range q.Conditions {
+			iter, ok := rangeIterators[c.Op]
+			if ok && space.Fields[c.Field].Id == index.Fields[part].Id {
+				p.used[i] = true
+				p.key = append(p.key, c.Value)
+				p.iterator = iter
+				break
+			}
+		}
+	}
+	desc := p.iterator == tarantool.IterLt || p.iterator == tarantool.IterLe
+	if len(q.Sort) == 1 && part < len(index.Fields) &&
+		space.Fields[q.Sort[0].Field].Id == index.Fields[part].Id {
+		switch {
+		case q.Sort[0].Desc == desc:
+			p.sorted = true
+		case p.iterator == tarantool.IterEq:
+			p.iterator, p.sorted = tarantool.IterReq, true
+		}
+	}
+	if len(q.Sort) == 0 {
+		p.sorted = true
+	}
+	if len(p.used) == 0 {
+		if !p.sorted || len(q.Sort) == 0 {
+			return nil
+		}
+		if p.iterator == tarantool.IterReq {
+			p.iterator = tarantool.IterLe
+		} else {
+			p.iterator = tarantool.IterAll
+		}
+	}
+	return p
+}
+
+func findCondition(space *tarantool.Space, conds []Condition, fieldNo uint32, op Op) int {
+	for i, c := range conds {
+		if c.Op == op && space.Fields[c.Field].Id == fieldNo {
+			return i
+		}
+	}
+	return -1
+}
+
+var conditionOf = map[Op]func(field string, value interface{}) conditions.Cond{
+	Eq: conditions.Eq,
+	Ne: conditions.Ne,
+	Lt: conditions.Lt,
+	Le: conditions.Le,
+	Gt: conditions.Gt,
+	Ge: conditions.Ge,
+}
+
+// crudSelect selects tuples with crud.select, conditions are serialized
+// with conditions package.
+func (e *Executor) crudSelect(space *tarantool.Space, q *Query, limit uint32) ([][]interface{}, error) {
+	conds := make([]conditions.Cond, len(q.Conditions))
+	for i, c := range q.Conditions {
+		conds[i] = conditionOf[c.Op](c.Field, c.Value)
+	}
+	crudConds, err := conditions.And(conds...).Crud()
+	if err != nil {
+		return nil, err
+	}
+	opts := map[string]interface{}{}
+	if limit > 0 {
+		opts["first"] = limit
+	}
+	var res crudResult
+	if err = e.conn.Call17Typed("crud.select", []interface{}{space.Name, crudConds, opts}, &res); err != nil {
+		return nil, err
+	}
+	if res.err != nil {
+		return nil, fmt.Errorf("crud.select: %v", res.err)
+	}
+	return res.rows, nil
+}
+
+// crudResult decodes results of crud.select: {metadata, rows} and error.
+type crudResult struct {
+	rows [][]interface{}
+	err  interface{}
+}
+
+func (r *crudResult) DecodeMsgpack(d *msgpack.Decoder) error {
+	n, err := d.DecodeSliceLen()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		switch i {
+		case 0:
+			v, err := d.DecodeInterface()
+			if err != nil {
+				return err
+			}
+			if m, ok := v.(map[interface{}]interface{}); ok {
+				rows, _ := m["rows"].([]interface{})
+				for _, row := range rows {
+					if tuple, ok := row.([]interface{}); ok {
+						r.rows = append(r.rows, tuple)
+					}
+				}
+			}
+		case 1:
+			if r.err, err = d.DecodeInterface(); err != nil {
+				return err
+			}
+		default:
+			if err = d.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func matches(m map[string]interface{}, conds []Condition) bool {
+	for _, c := range conds {
+		cmp, ok := compare(m[c.Field], c.Value)
+		if !ok {
+			return false
+		}
+		switch c.Op {
+		case Eq:
+			ok = cmp == 0
+		case Ne:
+			ok = cmp != 0
+		case Lt:
+			ok = cmp < 0
+		case Le:
+			ok = cmp <= 0
+		case Gt:
+			ok = cmp > 0
+		case Ge:
+			ok = cmp >= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func less(a, b map[string]interface{}, order []Sort) bool {
+	for _, s := range order {
+		cmp, _ := compare(a[s.Field], b[s.Field])
+		if cmp != 0 {
+			return (cmp < 0) != s.Desc
+		}
+	}
+	return false
+}
+
+// compare compares numbers, strings and booleans. It reports false if
+// values are not comparable.
+func compare(a, b interface{}) (int, bool) {
+	if fa, ok := toFloat64(a); ok {
+		fb, ok := toFloat64(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case fa < fb:
+			return -1, true
+		case fa > fb:
+			return 1, true
+		}
+		return 0, true
+	}
+	switch a := a.(type) {
+	case string:
+		b, ok := b.(string)
+		return strings.Compare(a, b), ok
+	case bool:
+		b, ok := b.(bool)
+		switch {
+		case !ok || a == b:
+			return 0, ok
+		case !a:
+			return -1, true
+		}
+		return 1, true
+	case nil:
+		if b == nil {
+			return 0, true
+		}
+		return -1, true
+	}
+	return 0, false
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func page(maps []map[string]interface{}, offset, limit uint32) []map[string]interface{} {
+	if int(offset) >= len(maps) {
+		return maps[:0]
+	}
+	maps = maps[offset:]
+	if limit > 0 && int(limit) < len(maps) {
+		maps = maps[:limit]
+	}
+	return maps
+}
@@ -0,0 +1,139 @@
+package query_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/query"
+)
+
+var server = "127.0.0.1:3013"
+var opts = Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+// schema is a schema of space with format {id, group, name, score},
+// primary TREE index on id, HASH index on name and TREE index on
+// {group, score}.
+func schema() *Schema {
+	space := &Space{
+		Id:          600,
+		Name:        "items",
+		Fields:      make(map[string]*Field),
+		FieldsById:  make(map[uint32]*Field),
+		Indexes:     make(map[string]*Index),
+		IndexesById: make(map[uint32]*Index),
+	}
+	for i, name := range []string{"id", "group", "name", "score"} {
+		field := &Field{Id: uint32(i), Name: name}
+		space.Fields[name] = field
+		space.FieldsById[field.Id] = field
+	}
+	indexes := []*Index{
+		{Id: 0, Name: "primary", Type: "TREE", Unique: true, Fields: []*IndexField{{Id: 0}}},
+		{Id: 1, Name: "name", Type: "HASH", Unique: true, Fields: []*IndexField{{Id: 2}}},
+		{Id: 2, Name: "group_score", Type: "TREE", Fields: []*IndexField{{Id: 1}, {Id: 3}}},
+	}
+	for _, index := range indexes {
+		space.Indexes[index.Name] = index
+		space.IndexesById[index.Id] = index
+	}
+	return &Schema{
+		Spaces:     map[string]*Space{space.Name: space},
+		SpacesById: map[uint32]*Space{space.Id: space},
+	}
+}
+
+func TestExplain(t *testing.T) {
+	e := query.NewExecutor(&Connection{Schema: schema()})
+	cases := []struct {
+		query query.Query
+		plan  string
+	}{
+		{
+			query.Query{Space: "items", Conditions: []query.Condition{{"name", query.Eq, "a"}}},
+			"select index name iterator EQ key [a]",
+		},
+		{
+			query.Query{Space: "items", Conditions: []query.Condition{
+				{"group", query.Eq, 1}, {"score", query.Ge, 10}, {"name", query.Ne, "b"},
+			}},
+			"select index group_score iterator GE key [1 10], filter [{name != b}], paginate on client",
+		},
+		{
+			query.Query{Space: "items", Conditions: []query.Condition{{"group", query.Eq, 1}},
+				Sort: []query.Sort{{"score", true}}, Limit: 10},
+			"select index group_score iterator REQ key [1]",
+		},
+		{
+			query.Query{Space: "items", Sort: []query.Sort{{"id", true}}},
+			"select index primary iterator LE key []",
+		},
+		{
+			query.Query{Space: "items", Conditions: []query.Condition{{"score", query.Gt, 5}},
+				Sort: []query.Sort{{"name", false}}},
+			"select index primary iterator ALL key [], filter [{score > 5}], sort on client, paginate on client",
+		},
+	}
+	for _, c := range cases {
+		plan, err := e.Explain(c.query)
+		if err != nil {
+			t.Errorf("Failed to explain %v: %s", c.query, err.Error())
+			continue
+		}
+		if plan.String() != c.plan {
+			t.Errorf("Unexpected plan of %v: %s", c.query, plan)
+		}
+	}
+
+	e.Crud = true
+	plan, err := e.Explain(query.Query{Space: "items", Conditions: []query.Condition{{"score", query.Gt, 5}}})
+	if err != nil || !plan.Crud || len(plan.Filter) != 0 {
+		t.Errorf("Unexpected crud plan: %v, %v", plan, err)
+	}
+	if _, err = e.Explain(query.Query{Space: "items", Conditions: []query.Condition{{"price", query.Gt, 5}}}); err == nil {
+		t.Errorf("Condition on unknown field is valid")
+	}
+	if _, err = e.Explain(query.Query{Space: "items", Conditions: []query.Condition{{"id", "like", 5}}}); err == nil {
+		t.Errorf("Condition with unknown operator is valid")
+	}
+}
+
+func TestExecute(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	for i := uint64(0); i < 5; i++ {
+		tuple := []interface{}{1100 + i, i % 2, "item", i, i, "", nil}
+		if _, err = conn.Replace("schematest", tuple); err != nil {
+			t.Errorf("Failed to replace: %s", err.Error())
+			return
+		}
+		defer conn.Delete("schematest", "primary", []interface{}{1100 + i})
+	}
+	e := query.NewExecutor(conn)
+	res, err := e.Execute(query.Query{
+		Space: "schematest",
+		Conditions: []query.Condition{
+			{"name1", query.Eq, uint64(0)},
+			{"name2", query.Eq, "item"},
+			{"name4", query.Ge, uint64(1)},
+		},
+		Sort:  []query.Sort{{"name0", true}},
+		Limit: 1,
+	})
+	if err != nil {
+		t.Errorf("Failed to execute: %s", err.Error())
+		return
+	}
+	if len(res) != 1 || res[0]["name0"] != uint64(1104) {
+		t.Errorf("Unexpected result: %v", res)
+	}
+}
@@ -11,7 +11,7 @@ import (
 func ExampleConnection_Queue() {
 	cfg := queue.Cfg{
 		Temporary: false,
-		Kind:      queue.FIFO,
+		Kind:      queue.FIFO_TTL,
 		Opts: queue.Opts{
 			Ttl: 10 * time.Second,
 		},
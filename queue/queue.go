@@ -25,6 +25,8 @@ type Queue interface {
 	// Put creates new task in a tube
 	Put(data interface{}) (*Task, error)
 	// PutWithOpts creates new task with options different from tube's defaults
+	// Note: if tube is created with Create, options are validated against
+	// the tube kind, see Opts.Validate.
 	PutWithOpts(data interface{}, cfg Opts) (*Task, error)
 	// Take takes 'ready' task from a tube and marks it as 'in progress'
 	// Note: if connection has a request Timeout, then 0.9 * connection.Timeout is
@@ -54,12 +56,15 @@ type Queue interface {
 	Delete(taskId uint64) error
 	// Statistic returns some statistic about queue.
 	Statistic() (interface{}, error)
+	// Stats returns decoded statistic about queue.
+	Stats() (*Statistics, error)
 }
 
 type queue struct {
 	name string
 	conn tarantool.Connector
 	cmds cmd
+	kind queueType // known only if tube is created with Create
 }
 
 type cmd struct {
@@ -106,6 +111,26 @@ type Opts struct {
 	Utube string
 }
 
+// Validate checks that options are supported by tube of the kind:
+// Pri, Ttl, Ttr and Delay require FIFO_TTL or UTUBE_TTL, Utube requires
+// UTUBE or UTUBE_TTL. Durations should not be negative.
+func (opts Opts) Validate(kind queueType) error {
+	if kind == "" {
+		kind = FIFO
+	}
+	if opts.Ttl < 0 || opts.Ttr < 0 || opts.Delay < 0 {
+		return fmt.Errorf("ttl, ttr and delay should not be negative")
+	}
+	ttl := kind == FIFO_TTL || kind == UTUBE_TTL
+	if !ttl && (opts.Pri != 0 || opts.Ttl != 0 || opts.Ttr != 0 || opts.Delay != 0) {
+		return fmt.Errorf("pri, ttl, ttr and delay are not supported by %s tube", kind)
+	}
+	if opts.Utube != "" && kind != UTUBE && kind != UTUBE_TTL {
+		return fmt.Errorf("utube is not supported by %s tube", kind)
+	}
+	return nil
+}
+
 func (opts Opts) toMap() map[string]interface{} {
 	ret := make(map[string]interface{})
 
@@ -144,8 +169,15 @@ func New(conn tarantool.Connector, name string) Queue {
 
 // Create creates a new queue with config
 func (q *queue) Create(cfg Cfg) error {
+	kind := queueType(cfg.getType())
+	if err := cfg.Opts.Validate(kind); err != nil {
+		return err
+	}
 	cmd := "local name, type, cfg = ... ; queue.create_tube(name, type, cfg)"
-	_, err := q.conn.Eval(cmd, []interface{}{q.name, cfg.getType(), cfg.toMap()})
+	_, err := q.conn.Eval(cmd, []interface{}{q.name, string(kind), cfg.toMap()})
+	if err == nil {
+		q.kind = kind
+	}
 	return err
 }
 
@@ -168,6 +200,11 @@ func (q *queue) Put(data interface{}) (*Task, error) {
 
 // Put data with options (ttl/ttr/pri/delay) to queue. Returns task.
 func (q *queue) PutWithOpts(data interface{}, cfg Opts) (*Task, error) {
+	if q.kind != "" {
+		if err := cfg.Validate(q.kind); err != nil {
+			return nil, err
+		}
+	}
 	return q.put(data, cfg.toMap())
 }
 
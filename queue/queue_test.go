@@ -818,3 +818,120 @@ func TestUtube_Put(t *testing.T) {
 		t.Fatalf("Blocking time is less than expected: actual = %.2fs, expected = 1s", end.Sub(start).Seconds())
 	}
 }
+
+func TestTtlQueue_Stats(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer conn.Close()
+
+	name := "test_queue"
+	cfg := queue.Cfg{
+		Temporary: true,
+		Kind:      queue.FIFO_TTL,
+		Opts:      queue.Opts{Ttl: 5 * time.Second},
+	}
+	q := queue.New(conn, name)
+	if err = q.Create(cfg); err != nil {
+		t.Errorf("Failed to create queue: %s", err.Error())
+		return
+	}
+
+	defer func() {
+		//Drop
+		err := q.Drop()
+		if err != nil {
+			t.Errorf("Failed drop queue: %s", err.Error())
+		}
+	}()
+
+	if _, err = q.PutWithOpts("put_data", queue.Opts{Utube: "key"}); err == nil {
+		t.Errorf("Utube option is not rejected for fifottl tube")
+	}
+
+	task, err := q.PutWithOpts("put_data", queue.Opts{Ttl: 10 * time.Second, Pri: 1})
+	if err != nil {
+		t.Errorf("Failed put to queue: %s", err.Error())
+		return
+	}
+	stats, err := task.Stats()
+	if err != nil {
+		t.Errorf("Failed to get task stats: %s", err.Error())
+		return
+	}
+	if stats == nil || stats.Status != queue.READY || stats.Ttl != 10*time.Second || stats.Pri != 1 {
+		t.Errorf("Unexpected task stats: %+v", stats)
+		return
+	}
+	if left := stats.TimeLeft(); left <= 5*time.Second || left > 10*time.Second {
+		t.Errorf("Unexpected time left: %s", left)
+	}
+}
+
+func TestOpts_Validate(t *testing.T) {
+	if err := (queue.Opts{Ttl: time.Second}).Validate(queue.FIFO); err == nil {
+		t.Errorf("Ttl is not rejected for fifo tube")
+	}
+	if err := (queue.Opts{Ttl: -time.Second}).Validate(queue.FIFO_TTL); err == nil {
+		t.Errorf("Negative ttl is not rejected")
+	}
+	if err := (queue.Opts{Utube: "key", Delay: time.Second}).Validate(queue.UTUBE_TTL); err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+}
+
+func TestTubes(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer conn.Close()
+
+	name := "test_queue"
+	q := queue.New(conn, name)
+	if err = q.Create(queue.Cfg{Temporary: true, Kind: queue.FIFO}); err != nil {
+		t.Errorf("Failed to create queue: %s", err.Error())
+		return
+	}
+
+	tubes, err := queue.ListTubes(conn)
+	if err != nil {
+		t.Errorf("Failed to list tubes: %s", err.Error())
+	} else if len(tubes) != 1 || tubes[0] != name {
+		t.Errorf("Unexpected tubes: %v", tubes)
+	}
+
+	if _, err = q.Put("put_data"); err != nil {
+		t.Errorf("Failed put to queue: %s", err.Error())
+		return
+	}
+	if _, err = q.Take(); err != nil {
+		t.Errorf("Failed take from queue: %s", err.Error())
+		return
+	}
+
+	stats, err := q.Stats()
+	if err != nil {
+		t.Errorf("Failed to get statistic: %s", err.Error())
+	} else if stats.Tasks.Taken != 1 || stats.Tasks.Total != 1 || stats.Calls["put"] != 1 {
+		t.Errorf("Unexpected statistic: %+v", stats)
+	}
+
+	if err = queue.DropTube(conn, name, false); err == nil {
+		t.Errorf("Tube with taken task is dropped without force")
+	}
+	if err = queue.DropTube(conn, name, true); err != nil {
+		t.Errorf("Failed to drop tube with force: %s", err.Error())
+	}
+}
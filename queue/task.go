@@ -2,6 +2,8 @@ package queue
 
 import (
 	"fmt"
+	"math"
+	"time"
 
 	msgpack "gopkg.in/vmihailenco/msgpack.v2"
 )
@@ -56,6 +58,105 @@ func (t *Task) Status() string {
 	return t.status
 }
 
+// TaskStats is a server-side state of a task.
+// Pri, Ttl, Ttr, Created and NextEvent are filled for FIFO_TTL and
+// UTUBE_TTL tubes only, Utube is filled for UTUBE and UTUBE_TTL tubes.
+type TaskStats struct {
+	Status    string
+	Pri       int
+	Ttl       time.Duration // task time to live since Created
+	Ttr       time.Duration // task time to execute
+	Created   time.Time
+	NextEvent time.Time // time of next status change, e.g. end of delay
+	Utube     string
+}
+
+// TimeLeft returns time left until task is expired by ttl.
+// It is zero if tube doesn't support ttl.
+func (stats *TaskStats) TimeLeft() time.Duration {
+	if stats.Created.IsZero() {
+		return 0
+	}
+	left := time.Until(stats.Created.Add(stats.Ttl))
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+type taskStats struct {
+	Status    string `msgpack:"status"`
+	Pri       int    `msgpack:"pri"`
+	Ttl       uint64 `msgpack:"ttl"`
+	Ttr       uint64 `msgpack:"ttr"`
+	Created   uint64 `msgpack:"created"`
+	NextEvent uint64 `msgpack:"next_event"`
+	Utube     string `msgpack:"utube"`
+}
+
+// Task tuples of ttl drivers keep times in microseconds.
+const taskStatsLua = `
+local name, id = ...
+local tube = queue.tube[name]
+if tube == nil then
+    error('tube ' .. name .. ' does not exist')
+end
+local t = box.space[name]:get{id}
+if t == nil then
+    return nil
+end
+local res = {status = t[2]}
+if tube.type == 'fifottl' or tube.type == 'utubettl' then
+    res.next_event = t[3]
+    res.ttl = t[4]
+    res.ttr = t[5]
+    res.pri = t[6]
+    res.created = t[7]
+end
+if tube.type == 'utube' then
+    res.utube = t[3]
+elseif tube.type == 'utubettl' then
+    res.utube = t[8]
+end
+return res
+`
+
+// microseconds converts server time to duration. Infinite ttl of queue
+// (about 1000 years) does not fit, so it is truncated.
+func microseconds(us uint64) time.Duration {
+	const max = uint64(math.MaxInt64 / int64(time.Microsecond))
+	if us > max {
+		return math.MaxInt64
+	}
+	return time.Duration(us) * time.Microsecond
+}
+
+// Stats reads server-side state of the task. It returns nil if task does
+// not exist anymore.
+// Note: it uses Eval, so user needs 'execute universe' privilege
+func (t *Task) Stats() (*TaskStats, error) {
+	var res []*taskStats
+	if err := t.q.conn.EvalTyped(taskStatsLua, []interface{}{t.q.name, t.id}, &res); err != nil {
+		return nil, err
+	}
+	if len(res) == 0 || res[0] == nil {
+		return nil, nil
+	}
+	raw := res[0]
+	stats := &TaskStats{
+		Status: raw.Status,
+		Pri:    raw.Pri,
+		Ttl:    microseconds(raw.Ttl),
+		Ttr:    microseconds(raw.Ttr),
+		Utube:  raw.Utube,
+	}
+	if raw.Created != 0 {
+		stats.Created = time.Unix(0, 0).Add(microseconds(raw.Created))
+		stats.NextEvent = time.Unix(0, 0).Add(microseconds(raw.NextEvent))
+	}
+	return stats, nil
+}
+
 // Ack signals about task completion
 func (t *Task) Ack() error {
 	return t.accept(t.q._ack(t.id))
@@ -0,0 +1,86 @@
+//go:build go1.18
+// +build go1.18
+
+package queue
+
+import (
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// Tube is a typed handle to tarantool queue's tube: task payloads are
+// encoded from and decoded into values of type T.
+type Tube[T any] struct {
+	q *queue
+}
+
+// TypedTask is a task with payload of type T.
+// Task methods (Ack, Release, Bury and so on) are available as usual.
+type TypedTask[T any] struct {
+	*Task
+	// Payload is a decoded task data.
+	Payload T
+}
+
+// NewTube creates a typed tube handle.
+func NewTube[T any](conn tarantool.Connector, name string) *Tube[T] {
+	return &Tube[T]{q: New(conn, name).(*queue)}
+}
+
+// Queue returns untyped handle of the same tube, it could be used for
+// administrative tasks: Create, Drop, Kick and so on.
+func (t *Tube[T]) Queue() Queue {
+	return t.q
+}
+
+// Put creates new task in a tube.
+func (t *Tube[T]) Put(data T) (*TypedTask[T], error) {
+	return t.call(t.q.cmds.put, data)
+}
+
+// PutWithOpts creates new task with options different from tube's defaults.
+func (t *Tube[T]) PutWithOpts(data T, cfg Opts) (*TypedTask[T], error) {
+	return t.call(t.q.cmds.put, data, cfg.toMap())
+}
+
+// Take takes 'ready' task from a tube and marks it as 'in progress'.
+// It returns nil task if there is no ready task.
+// Note: if connection has a request Timeout, then 0.9 * connection.Timeout is
+// used as a timeout.
+func (t *Tube[T]) Take() (*TypedTask[T], error) {
+	task := new(TypedTask[T])
+	var err error
+	if task.Task, err = t.q.TakeTyped(&task.Payload); err != nil || task.Task == nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// TakeTimeout takes 'ready' task from a tube and marks it as "in progress",
+// or returns nil task after "timeout" period.
+// Note: if connection has a request Timeout, and conn.Timeout * 0.9 < timeout
+// then timeout = conn.Timeout*0.9
+func (t *Tube[T]) TakeTimeout(timeout time.Duration) (*TypedTask[T], error) {
+	task := new(TypedTask[T])
+	var err error
+	if task.Task, err = t.q.TakeTypedTimeout(timeout, &task.Payload); err != nil || task.Task == nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// Peek returns task by its id.
+func (t *Tube[T]) Peek(taskId uint64) (*TypedTask[T], error) {
+	return t.call(t.q.cmds.peek, taskId)
+}
+
+func (t *Tube[T]) call(cmd string, params ...interface{}) (*TypedTask[T], error) {
+	task := new(TypedTask[T])
+	qd := queueData{q: t.q, result: &task.Payload}
+	if err := t.q.conn.CallTyped(cmd, params, &qd); err != nil || qd.task == nil {
+		return nil, err
+	}
+	task.Task = qd.task
+	return task, nil
+}
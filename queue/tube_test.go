@@ -0,0 +1,77 @@
+//go:build go1.18
+// +build go1.18
+
+package queue_test
+
+import (
+	"testing"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/queue"
+)
+
+type tubePayload struct {
+	Name  string
+	Count int
+}
+
+func TestTube(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer conn.Close()
+
+	name := "test_queue"
+	tube := queue.NewTube[tubePayload](conn, name)
+	if err = tube.Queue().Create(queue.Cfg{Temporary: true, Kind: queue.FIFO}); err != nil {
+		t.Errorf("Failed to create queue: %s", err.Error())
+		return
+	}
+	defer func() {
+		//Drop
+		err := tube.Queue().Drop()
+		if err != nil {
+			t.Errorf("Failed drop queue: %s", err.Error())
+		}
+	}()
+
+	putData := tubePayload{Name: "put_data", Count: 3}
+	task, err := tube.Put(putData)
+	if err != nil {
+		t.Errorf("Failed to put: %s", err.Error())
+		return
+	}
+	if task.Payload != putData || !task.IsReady() {
+		t.Errorf("Unexpected put task: %+v %s", task.Payload, task.Status())
+	}
+
+	peeked, err := tube.Peek(task.Id())
+	if err != nil {
+		t.Errorf("Failed to peek: %s", err.Error())
+	} else if peeked.Payload != putData {
+		t.Errorf("Unexpected peeked payload: %+v", peeked.Payload)
+	}
+
+	taken, err := tube.Take()
+	if err != nil {
+		t.Errorf("Failed to take: %s", err.Error())
+		return
+	}
+	if taken == nil || taken.Id() != task.Id() || taken.Payload != putData || !taken.IsTaken() {
+		t.Errorf("Unexpected taken task: %+v", taken)
+		return
+	}
+	if err = taken.Ack(); err != nil {
+		t.Errorf("Failed to ack: %s", err.Error())
+	}
+
+	if taken, err = tube.TakeTimeout(0); err != nil || taken != nil {
+		t.Errorf("Unexpected result of take from empty tube: %+v, %v", taken, err)
+	}
+}
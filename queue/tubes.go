@@ -0,0 +1,97 @@
+package queue
+
+import (
+	"fmt"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// TasksStatistics is a number of tube tasks broken down by task state.
+type TasksStatistics struct {
+	Taken   uint64
+	Done    uint64
+	Ready   uint64
+	Buried  uint64
+	Delayed uint64
+	Total   uint64
+}
+
+// Statistics is a statistic of a tube: tasks broken down by state and
+// number of requests broken down by request type (put, take, ack and so on).
+type Statistics struct {
+	Tasks TasksStatistics
+	Calls map[string]uint64
+}
+
+func toUint64(v interface{}) uint64 {
+	switch v := v.(type) {
+	case uint64:
+		return v
+	case int64:
+		return uint64(v)
+	}
+	return 0
+}
+
+func decodeStatistics(data interface{}) (*Statistics, error) {
+	m, ok := data.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected statistic type %T", data)
+	}
+	stats := &Statistics{Calls: make(map[string]uint64)}
+	if tasks, ok := m["tasks"].(map[interface{}]interface{}); ok {
+		stats.Tasks = TasksStatistics{
+			Taken:   toUint64(tasks["taken"]),
+			Done:    toUint64(tasks["done"]),
+			Ready:   toUint64(tasks["ready"]),
+			Buried:  toUint64(tasks["buried"]),
+			Delayed: toUint64(tasks["delayed"]),
+			Total:   toUint64(tasks["total"]),
+		}
+	}
+	if calls, ok := m["calls"].(map[interface{}]interface{}); ok {
+		for name, count := range calls {
+			if name, ok := name.(string); ok {
+				stats.Calls[name] = toUint64(count)
+			}
+		}
+	}
+	return stats, nil
+}
+
+// Stats returns decoded statistic about queue.
+func (q *queue) Stats() (*Statistics, error) {
+	stat, err := q.Statistic()
+	if err != nil || stat == nil {
+		return nil, err
+	}
+	return decodeStatistics(stat)
+}
+
+// ListTubes returns sorted names of all tubes.
+// Note: it uses Eval, so user needs 'execute universe' privilege
+func ListTubes(conn tarantool.Connector) ([]string, error) {
+	cmd := "local names = {} for name in pairs(queue.tube) do table.insert(names, name) end " +
+		"table.sort(names) return names"
+	var res [][]string
+	if err := conn.EvalTyped(cmd, []interface{}{}, &res); err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return res[0], nil
+}
+
+// DropTube destroys tube by its name. Tube with taken tasks could not be
+// dropped, unless force is true: in this case tube is truncated first.
+// Note: it uses Eval, so user needs 'execute universe' privilege
+// Note: you'd better not use this function in your application, cause it is
+// administrative task to create or delete queue.
+func DropTube(conn tarantool.Connector, name string, force bool) error {
+	cmd := "local name, force = ... local tube = queue.tube[name] " +
+		"if tube == nil then error('tube ' .. name .. ' does not exist') end " +
+		"if force then tube:truncate() end tube:drop()"
+	_, err := conn.Eval(cmd, []interface{}{name, force})
+	return err
+}
@@ -0,0 +1,179 @@
+package tarantool
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Rate is a limit of requests rate.
+type Rate struct {
+	// Limit is an allowed number of requests per second.
+	// Zero means no limit.
+	Limit float64
+	// Burst is a number of requests which could be sent at once.
+	// By default, it is Limit rounded up.
+	Burst int
+}
+
+// RateLimits configures client-side limits of requests rate.
+// Requests exceeding limits are rejected with
+// ClientError{Code: ErrRateLimited} without sending them to Tarantool.
+// Requests rejected because connection is not ready or by RateLimit
+// do not take tokens.
+type RateLimits struct {
+	// Total limits rate of all requests of connection except pings.
+	Total Rate
+	// Spaces limits rate of requests to spaces by space name.
+	Spaces map[string]Rate
+	// Functions limits rate of Call* requests by function name.
+	Functions map[string]Rate
+}
+
+type tokenBucket struct {
+	clock  Clock
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate Rate, clock Clock) *tokenBucket {
+	if rate.Limit <= 0 {
+		return nil
+	}
+	burst := float64(rate.Burst)
+	if burst <= 0 {
+		burst = math.Ceil(rate.Limit)
+	}
+	return &tokenBucket{
+		clock:  clock,
+		rate:   rate.Limit,
+		burst:  burst,
+		tokens: burst,
+		last:   clock.Now(),
+	}
+}
+
+func (b *tokenBucket) take() bool {
+	if b == nil {
+		return true
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	now := b.clock.Now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// refund returns token taken for request rejected by another limit.
+func (b *tokenBucket) refund() {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+type rateLimiter struct {
+	total     *tokenBucket
+	spaces    map[string]*tokenBucket
+	functions map[string]*tokenBucket
+	// spaceNames maps numbers of limited spaces to their names, it is
+	// replaced when schema is set, so it is read without locks
+	spaceNames atomic.Value
+}
+
+func newRateLimiter(limits *RateLimits, clock Clock) *rateLimiter {
+	limiter := &rateLimiter{
+		total:     newTokenBucket(limits.Total, clock),
+		spaces:    make(map[string]*tokenBucket),
+		functions: make(map[string]*tokenBucket),
+	}
+	for name, rate := range limits.Spaces {
+		if b := newTokenBucket(rate, clock); b != nil {
+			limiter.spaces[name] = b
+		}
+	}
+	for name, rate := range limits.Functions {
+		if b := newTokenBucket(rate, clock); b != nil {
+			limiter.functions[name] = b
+		}
+	}
+	limiter.spaceNames.Store(map[uint32]string(nil))
+	return limiter
+}
+
+// setSchema maps numbers of limited spaces of schema to their names.
+func (limiter *rateLimiter) setSchema(schema *Schema) {
+	if limiter == nil || len(limiter.spaces) == 0 {
+		return
+	}
+	names := make(map[uint32]string)
+	if schema != nil {
+		for id, space := range schema.SpacesById {
+			if _, ok := limiter.spaces[space.Name]; ok {
+				names[id] = space.Name
+			}
+		}
+	}
+	limiter.spaceNames.Store(names)
+}
+
+// checkRate reports error if request exceeds rate limits of connection.
+// Space is checked for space requests (spaceNo != 0), function is checked
+// for calls (function != ""). Tokens are taken only if request passes
+// all limits.
+func (conn *Connection) checkRate(spaceNo uint32, function string) error {
+	limiter := conn.limiter
+	if limiter == nil {
+		return nil
+	}
+	var fb, sb *tokenBucket
+	if function != "" {
+		if fb = limiter.functions[function]; !fb.take() {
+			return ClientError{ErrRateLimited, fmt.Sprintf("rate limit is exceeded for function %s", function)}
+		}
+	}
+	if spaceNo != 0 {
+		if name, ok := limiter.spaceNames.Load().(map[uint32]string)[spaceNo]; ok {
+			if sb = limiter.spaces[name]; !sb.take() {
+				fb.refund()
+				return ClientError{ErrRateLimited, fmt.Sprintf("rate limit is exceeded for space %s", name)}
+			}
+		}
+	}
+	if !limiter.total.take() {
+		fb.refund()
+		sb.refund()
+		return ClientError{ErrRateLimited, "rate limit is exceeded for connection"}
+	}
+	return nil
+}
+
+// refundRate returns tokens taken by checkRate for request rejected
+// afterwards.
+func (conn *Connection) refundRate(spaceNo uint32, function string) {
+	limiter := conn.limiter
+	if limiter == nil {
+		return
+	}
+	if function != "" {
+		limiter.functions[function].refund()
+	}
+	if spaceNo != 0 {
+		if name, ok := limiter.spaceNames.Load().(map[uint32]string)[spaceNo]; ok {
+			limiter.spaces[name].refund()
+		}
+	}
+	limiter.total.refund()
+}
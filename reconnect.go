@@ -0,0 +1,81 @@
+package tarantool
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ReconnectPolicy decides how connection is reestablished after it is
+// lost. Policies may also use NextDelay to observe failed attempts.
+type ReconnectPolicy interface {
+	// NextDelay is called after a failed connect attempt with its number
+	// (counted from 0 since connection was lost) and error. It returns
+	// pause before the next attempt, counted from start of the failed
+	// one, or false to give up and close connection.
+	NextDelay(attempt uint, err error) (time.Duration, bool)
+}
+
+// FixedReconnect reconnects with a constant pause. It is a policy used
+// when Opts.Reconnect is set.
+type FixedReconnect struct {
+	// Delay is a pause between attempts.
+	Delay time.Duration
+	// MaxAttempts is a number of attempts after which reconnection is
+	// given up, zero means unlimited attempts.
+	MaxAttempts uint
+}
+
+// NextDelay implements ReconnectPolicy.
+func (p FixedReconnect) NextDelay(attempt uint, err error) (time.Duration, bool) {
+	if p.MaxAttempts > 0 && attempt > p.MaxAttempts {
+		return 0, false
+	}
+	return p.Delay, true
+}
+
+// UnlimitedReconnect reconnects with a constant pause until connection
+// is closed by user.
+func UnlimitedReconnect(delay time.Duration) ReconnectPolicy {
+	return FixedReconnect{Delay: delay}
+}
+
+// ExponentialReconnect reconnects with exponentially growing pauses with
+// random jitter, so clients that lost connection at the same time do not
+// reconnect all at once.
+type ExponentialReconnect struct {
+	// Initial is a pause after the first failed attempt.
+	Initial time.Duration
+	// Max is a maximum pause, pauses are not limited by default.
+	Max time.Duration
+	// Multiplier is a growth factor of pauses, 2 by default.
+	Multiplier float64
+	// Jitter is a fraction of pause which is randomized: pause is chosen
+	// from [pause*(1-Jitter), pause]. Jitter should be in [0, 1].
+	Jitter float64
+	// MaxAttempts is a number of attempts after which reconnection is
+	// given up, zero means unlimited attempts.
+	MaxAttempts uint
+}
+
+// NextDelay implements ReconnectPolicy.
+func (p ExponentialReconnect) NextDelay(attempt uint, err error) (time.Duration, bool) {
+	if p.MaxAttempts > 0 && attempt > p.MaxAttempts {
+		return 0, false
+	}
+	multiplier := p.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+	delay := float64(p.Initial) * math.Pow(multiplier, float64(attempt))
+	if p.Max > 0 && delay > float64(p.Max) {
+		delay = float64(p.Max)
+	}
+	if delay > math.MaxInt64 {
+		delay = math.MaxInt64
+	}
+	if p.Jitter > 0 {
+		delay -= delay * p.Jitter * rand.Float64()
+	}
+	return time.Duration(delay), true
+}
@@ -0,0 +1,352 @@
+//go:build go1.18
+// +build go1.18
+
+// Package repo maps structs to tuples of spaces with format and provides
+// typed CRUD repositories over them:
+//
+//	type User struct {
+//		Id      uint64 `tarantool:"id"`
+//		Name    string `tarantool:"name"`
+//		Version uint64 `tarantool:"version,version"`
+//	}
+//
+//	users, err := repo.New[User](conn, "users")
+//	user, err := users.Get(uint64(1))
+//	user.Name = "bob"
+//	err = users.Update(user)
+//
+// Struct fields are mapped to format fields by tarantool tag or by name
+// case-insensitively, fields tagged "-" are not mapped. Field with
+// "version" option is a version for optimistic locking: Update and Delete
+// fail with ErrConflict if the stored version differs, Insert and Update
+// increment it. Repositories resolve spaces with loaded schema, so
+// connection should not be created with Opts.SkipSchema.
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+var (
+	// ErrNotFound is returned when tuple to update or delete is absent.
+	ErrNotFound = errors.New("tuple is not found")
+	// ErrConflict is returned when version of tuple to update or delete
+	// differs from the stored one.
+	ErrConflict = errors.New("tuple version conflict")
+)
+
+// TagName is a name of struct tag with names of format fields.
+const TagName = "tarantool"
+
+// casLua replaces or deletes tuple if its version is not changed.
+const casLua = `
+local space, key, vfield, version, tuple = ...
+return box.atomic(function()
+    local s = box.space[space]
+    local t = s:get(key)
+    if t == nil then
+        return 'not_found'
+    end
+    if t[vfield] ~= version then
+        return 'conflict'
+    end
+    if tuple == nil then
+        s:delete(key)
+    else
+        s:replace(tuple)
+    end
+    return 'ok'
+end)
+`
+
+// Repository is a typed repository of tuples of space mapped to structs T.
+type Repository[T any] struct {
+	conn  *tarantool.Connection
+	space *tarantool.Space
+	// fields are struct field indexes by tuple field numbers, -1 for
+	// unmapped tuple fields
+	fields []int
+	// pk are tuple field numbers of primary key
+	pk []uint32
+	// version is a tuple field number of version, -1 if there is no one
+	version int
+}
+
+// New creates repository of space. It returns error if T is not a struct,
+// space is not found in loaded schema or fields of T or of primary key
+// are not mapped.
+func New[T any](conn *tarantool.Connection, space string) (*Repository[T], error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("repository type %s is not a struct", typ)
+	}
+	if conn.Schema == nil {
+		return nil, fmt.Errorf("Schema is not loaded")
+	}
+	spaceDesc, ok := conn.Schema.Spaces[space]
+	if !ok {
+		return nil, fmt.Errorf("there is no space with name %s", space)
+	}
+	if len(spaceDesc.FieldsById) == 0 {
+		return nil, fmt.Errorf("space %s has no format", space)
+	}
+
+	r := &Repository[T]{conn: conn, space: spaceDesc, version: -1}
+	// tuples are extended to exact field count of space, if it is set
+	width := int(spaceDesc.FieldsCount)
+	for id := range spaceDesc.FieldsById {
+		if int(id) >= width {
+			width = int(id) + 1
+		}
+	}
+	r.fields = make([]int, width)
+	for i := range r.fields {
+		r.fields[i] = -1
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name, opts := sf.Name, ""
+		if tag, ok := sf.Tag.Lookup(TagName); ok {
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ = strings.Cut(tag, ",")
+			if name == "" {
+				name = sf.Name
+			}
+		}
+		field := lookupField(spaceDesc, name)
+		if field == nil {
+			return nil, fmt.Errorf("field %s of %s is not found in format of space %s", sf.Name, typ, space)
+		}
+		r.fields[field.Id] = i
+		if opts == "version" {
+			switch sf.Type.Kind() {
+			case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+			default:
+				return nil, fmt.Errorf("version field %s of %s is not an integer", sf.Name, typ)
+			}
+			r.version = int(field.Id)
+		}
+	}
+
+	primary, ok := spaceDesc.IndexesById[0]
+	if !ok {
+		return nil, fmt.Errorf("space %s has no primary index", space)
+	}
+	for _, part := range primary.Fields {
+		if int(part.Id) >= width || r.fields[part.Id] < 0 {
+			return nil, fmt.Errorf("primary key field %d of space %s is not mapped to %s", part.Id+1, space, typ)
+		}
+		r.pk = append(r.pk, part.Id)
+	}
+	return r, nil
+}
+
+func lookupField(space *tarantool.Space, name string) *tarantool.Field {
+	if field, ok := space.Fields[name]; ok {
+		return field
+	}
+	for _, field := range space.FieldsById {
+		if strings.EqualFold(field.Name, name) {
+			return field
+		}
+	}
+	return nil
+}
+
+// Get returns tuple with primary key or nil if it is not found.
+func (r *Repository[T]) Get(key ...interface{}) (*T, error) {
+	rows, err := r.SelectBy(uint32(0), key, 0, 1)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	return &rows[0], nil
+}
+
+// SelectBy selects tuples with key equal to key by index.
+func (r *Repository[T]) SelectBy(index interface{}, key []interface{}, offset, limit uint32) ([]T, error) {
+	if limit == 0 {
+		limit = math.MaxUint32
+	}
+	res := tuples[T]{repo: r}
+	err := r.conn.SelectTyped(r.space.Id, index, offset, limit, tarantool.IterEq, key, &res)
+	return res.rows, err
+}
+
+// Insert inserts tuple of v and updates v with the inserted tuple. Version
+// of v is set to 1.
+func (r *Repository[T]) Insert(v *T) error {
+	if r.version >= 0 {
+		setVersion(r.versionField(v), 1)
+	}
+	res := tuples[T]{repo: r}
+	if err := r.conn.InsertTyped(r.space.Id, r.tuple(v), &res); err != nil {
+		return err
+	}
+	if len(res.rows) == 1 {
+		*v = res.rows[0]
+	}
+	return nil
+}
+
+// Update replaces tuple with primary key of v with tuple of v. It returns
+// ErrNotFound if tuple is absent and ErrConflict if version of stored
+// tuple differs from version of v; on success version of v is incremented.
+func (r *Repository[T]) Update(v *T) error {
+	if r.version < 0 {
+		res := tuples[T]{repo: r}
+		ops := make([]interface{}, 0, len(r.fields))
+		tuple := r.tuple(v)
+		for no, i := range r.fields {
+			if i >= 0 && !r.isKey(uint32(no)) {
+				ops = append(ops, []interface{}{"=", no, tuple[no]})
+			}
+		}
+		if err := r.conn.UpdateTyped(r.space.Id, uint32(0), r.key(v), ops, &res); err != nil {
+			return err
+		}
+		if len(res.rows) == 0 {
+			return ErrNotFound
+		}
+		*v = res.rows[0]
+		return nil
+	}
+	field := r.versionField(v)
+	version := versionOf(field)
+	setVersion(field, version+1)
+	if err := r.cas(v, version, r.tuple(v)); err != nil {
+		setVersion(field, version)
+		return err
+	}
+	return nil
+}
+
+// Delete deletes tuple with primary key of v. It returns ErrNotFound if
+// tuple is absent and ErrConflict if version of stored tuple differs from
+// version of v.
+func (r *Repository[T]) Delete(v *T) error {
+	if r.version < 0 {
+		res := tuples[T]{repo: r}
+		if err := r.conn.DeleteTyped(r.space.Id, uint32(0), r.key(v), &res); err != nil {
+			return err
+		}
+		if len(res.rows) == 0 {
+			return ErrNotFound
+		}
+		return nil
+	}
+	return r.cas(v, versionOf(r.versionField(v)), nil)
+}
+
+func (r *Repository[T]) cas(v *T, version int64, tuple []interface{}) error {
+	var res []string
+	args := []interface{}{r.space.Name, r.key(v), r.version + 1, version, tuple}
+	if err := r.conn.EvalTyped(casLua, args, &res); err != nil {
+		return err
+	}
+	if len(res) != 1 {
+		return fmt.Errorf("unexpected result %v", res)
+	}
+	switch res[0] {
+	case "ok":
+		return nil
+	case "not_found":
+		return ErrNotFound
+	case "conflict":
+		return ErrConflict
+	}
+	return fmt.Errorf("unexpected result %s", res[0])
+}
+
+func (r *Repository[T]) isKey(no uint32) bool {
+	for _, k := range r.pk {
+		if k == no {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Repository[T]) versionField(v *T) reflect.Value {
+	return reflect.ValueOf(v).Elem().Field(r.fields[r.version])
+}
+
+func versionOf(field reflect.Value) int64 {
+	if field.CanInt() {
+		return field.Int()
+	}
+	return int64(field.Uint())
+}
+
+func setVersion(field reflect.Value, version int64) {
+	if field.CanInt() {
+		field.SetInt(version)
+	} else {
+		field.SetUint(uint64(version))
+	}
+}
+
+// tuple returns tuple of v, unmapped fields are nil.
+func (r *Repository[T]) tuple(v *T) []interface{} {
+	sv := reflect.ValueOf(v).Elem()
+	tuple := make([]interface{}, len(r.fields))
+	for no, i := range r.fields {
+		if i >= 0 {
+			tuple[no] = sv.Field(i).Interface()
+		}
+	}
+	return tuple
+}
+
+// key returns primary key of v.
+func (r *Repository[T]) key(v *T) []interface{} {
+	sv := reflect.ValueOf(v).Elem()
+	key := make([]interface{}, len(r.pk))
+	for i, no := range r.pk {
+		key[i] = sv.Field(r.fields[no]).Interface()
+	}
+	return key
+}
+
+// tuples decodes tuples into structs, unmapped fields are skipped.
+type tuples[T any] struct {
+	repo *Repository[T]
+	rows []T
+}
+
+func (t *tuples[T]) DecodeMsgpack(d *msgpack.Decoder) error {
+	n, err := d.DecodeSliceLen()
+	if err != nil || n <= 0 {
+		return err
+	}
+	t.rows = make([]T, n)
+	for i := range t.rows {
+		m, err := d.DecodeSliceLen()
+		if err != nil {
+			return err
+		}
+		sv := reflect.ValueOf(&t.rows[i]).Elem()
+		for no := 0; no < m; no++ {
+			if no < len(t.repo.fields) && t.repo.fields[no] >= 0 {
+				err = d.DecodeValue(sv.Field(t.repo.fields[no]))
+			} else {
+				err = d.Skip()
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
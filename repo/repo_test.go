@@ -0,0 +1,131 @@
+//go:build go1.18
+// +build go1.18
+
+package repo_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/repo"
+)
+
+var server = "127.0.0.1:3013"
+var opts = Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+type row struct {
+	Id      uint64 `tarantool:"name0"`
+	Group   uint64 `tarantool:"name1"`
+	Name    string `tarantool:"name2"`
+	Version uint64 `tarantool:"name3,version"`
+	Name4   uint64
+	Name5   string
+	Cached  string `tarantool:"-"`
+}
+
+type plainRow struct {
+	Id    uint64 `tarantool:"name0"`
+	Group uint64 `tarantool:"name1"`
+	Name  string `tarantool:"name2"`
+	Name3 uint64
+	Name4 uint64
+	Name5 string
+}
+
+type unknownField struct {
+	Id    uint64 `tarantool:"name0"`
+	Email string
+}
+
+func TestRepository(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	if _, err = repo.New[unknownField](conn, "schematest"); err == nil {
+		t.Errorf("Repository with unknown field is created")
+	}
+	rows, err := repo.New[row](conn, "schematest")
+	if err != nil {
+		t.Errorf("Failed to create repository: %s", err.Error())
+		return
+	}
+
+	r := &row{Id: 1001, Group: 7, Name: "first", Cached: "x"}
+	if err = rows.Insert(r); err != nil {
+		t.Errorf("Failed to insert: %s", err.Error())
+		return
+	}
+	defer conn.Delete("schematest", "primary", []interface{}{uint64(1001)})
+	if r.Version != 1 || r.Cached != "" {
+		t.Errorf("Unexpected inserted row: %v", r)
+	}
+
+	stale := *r
+	r.Name = "second"
+	if err = rows.Update(r); err != nil {
+		t.Errorf("Failed to update: %s", err.Error())
+		return
+	}
+	if r.Version != 2 {
+		t.Errorf("Version is not incremented: %v", r)
+	}
+	stale.Name = "stale"
+	if err = rows.Update(&stale); err != repo.ErrConflict {
+		t.Errorf("Unexpected error of stale update: %v", err)
+	}
+	if stale.Version != 1 {
+		t.Errorf("Version of failed update is changed: %v", stale)
+	}
+
+	got, err := rows.Get(uint64(1001))
+	if err != nil || got == nil || got.Name != "second" {
+		t.Errorf("Unexpected row: %v, %v", got, err)
+	}
+	selected, err := rows.SelectBy("secondary", []interface{}{uint64(7), "second"}, 0, 0)
+	if err != nil || len(selected) != 1 || selected[0].Id != 1001 {
+		t.Errorf("Unexpected selected rows: %v, %v", selected, err)
+	}
+
+	if err = rows.Delete(&stale); err != repo.ErrConflict {
+		t.Errorf("Unexpected error of stale delete: %v", err)
+	}
+	if err = rows.Delete(r); err != nil {
+		t.Errorf("Failed to delete: %s", err.Error())
+	}
+	if err = rows.Delete(r); err != repo.ErrNotFound {
+		t.Errorf("Unexpected error of repeated delete: %v", err)
+	}
+	if got, err = rows.Get(uint64(1001)); err != nil || got != nil {
+		t.Errorf("Row is not deleted: %v, %v", got, err)
+	}
+
+	plain, err := repo.New[plainRow](conn, "schematest")
+	if err != nil {
+		t.Errorf("Failed to create repository: %s", err.Error())
+		return
+	}
+	p := &plainRow{Id: 1002, Name: "plain"}
+	if err = plain.Update(p); err != repo.ErrNotFound {
+		t.Errorf("Unexpected error of update of absent row: %v", err)
+	}
+	if err = plain.Insert(p); err != nil {
+		t.Errorf("Failed to insert: %s", err.Error())
+		return
+	}
+	p.Name5 = "updated"
+	if err = plain.Update(p); err != nil || p.Name5 != "updated" {
+		t.Errorf("Failed to update: %v, %v", p, err)
+	}
+	if err = plain.Delete(p); err != nil {
+		t.Errorf("Failed to delete: %s", err.Error())
+	}
+}
@@ -1,7 +1,10 @@
 package tarantool
 
 import (
+	"context"
 	"errors"
+	"runtime"
+	"sync/atomic"
 	"time"
 
 	"gopkg.in/vmihailenco/msgpack.v2"
@@ -11,24 +14,34 @@ import (
 type Future struct {
 	requestId   uint32
 	requestCode int32
+	spaceNo     uint32
+	start       time.Duration
 	timeout     time.Duration
 	resp        *Response
 	err         error
 	ready       chan struct{}
 	next        *Future
+	leaked      bool
+	conn        *Connection
+	// ctx is a context of request sent with it, e.g. with EvalContext
+	ctx context.Context
+	// stream is set for futures which may receive body as stream
+	stream bool
+	// body is a streamed body of response
+	body *streamReader
+	// memory is a size of response accounted by Opts.MaxMemory
+	memory int64
 }
 
 // Ping sends empty request to Tarantool to check connection.
 func (conn *Connection) Ping() (resp *Response, err error) {
-	future := conn.newFuture(PingRequest)
+	future := conn.newFuture(PingRequest, 0)
 	return future.send(conn, func(enc *msgpack.Encoder) error { enc.EncodeMapLen(0); return nil }).Get()
 }
 
-func (req *Future) fillSearch(enc *msgpack.Encoder, spaceNo, indexNo uint32, key interface{}) error {
-	enc.EncodeUint64(KeySpaceNo)
-	enc.EncodeUint64(uint64(spaceNo))
-	enc.EncodeUint64(KeyIndexNo)
-	enc.EncodeUint64(uint64(indexNo))
+func (req *Future) fillSearch(enc *msgpack.Encoder, ref spaceRef, key interface{}) error {
+	ref.fillSpace(enc)
+	ref.fillIndex(enc)
 	enc.EncodeUint64(KeyKey)
 	return enc.Encode(key)
 }
@@ -42,9 +55,8 @@ func (req *Future) fillIterator(enc *msgpack.Encoder, offset, limit, iterator ui
 	enc.EncodeUint64(uint64(limit))
 }
 
-func (req *Future) fillInsert(enc *msgpack.Encoder, spaceNo uint32, tuple interface{}) error {
-	enc.EncodeUint64(KeySpaceNo)
-	enc.EncodeUint64(uint64(spaceNo))
+func (req *Future) fillInsert(enc *msgpack.Encoder, ref spaceRef, tuple interface{}) error {
+	ref.fillSpace(enc)
 	enc.EncodeUint64(KeyTuple)
 	return enc.Encode(tuple)
 }
@@ -214,149 +226,243 @@ func (conn *Connection) EvalTyped(expr string, args interface{}, result interfac
 
 // SelectAsync sends select request to tarantool and returns Future.
 func (conn *Connection) SelectAsync(space, index interface{}, offset, limit, iterator uint32, key interface{}) *Future {
-	future := conn.newFuture(SelectRequest)
-	spaceNo, indexNo, err := conn.Schema.resolveSpaceIndex(space, index)
+	ref, err := conn.selectRef(space, index, iterator)
+	future := conn.newFuture(SelectRequest, ref.spaceNo)
 	if err != nil {
 		return future.fail(conn, err)
 	}
-	return future.send(conn, func(enc *msgpack.Encoder) error {
-		enc.EncodeMapLen(6)
-		future.fillIterator(enc, offset, limit, iterator)
-		return future.fillSearch(enc, spaceNo, indexNo, key)
-	})
+	return future.send(conn, selectRefBody(ref, offset, limit, iterator, key))
 }
 
 // InsertAsync sends insert action to tarantool and returns Future.
 // Tarantool will reject Insert when tuple with same primary key exists.
 func (conn *Connection) InsertAsync(space interface{}, tuple interface{}) *Future {
-	future := conn.newFuture(InsertRequest)
-	spaceNo, _, err := conn.Schema.resolveSpaceIndex(space, nil)
+	ref, err := conn.resolveRef(space, nil)
+	if err == nil {
+		err = conn.validateWrite(ref.spaceNo, tuple, nil)
+	}
+	future := conn.newFuture(InsertRequest, ref.spaceNo)
 	if err != nil {
 		return future.fail(conn, err)
 	}
-	return future.send(conn, func(enc *msgpack.Encoder) error {
-		enc.EncodeMapLen(2)
-		return future.fillInsert(enc, spaceNo, tuple)
-	})
+	return future.send(conn, insertRefBody(ref, tuple))
 }
 
 // ReplaceAsync sends "insert or replace" action to tarantool and returns Future.
 // If tuple with same primary key exists, it will be replaced.
 func (conn *Connection) ReplaceAsync(space interface{}, tuple interface{}) *Future {
-	future := conn.newFuture(ReplaceRequest)
-	spaceNo, _, err := conn.Schema.resolveSpaceIndex(space, nil)
+	ref, err := conn.resolveRef(space, nil)
+	if err == nil {
+		err = conn.validateWrite(ref.spaceNo, tuple, nil)
+	}
+	future := conn.newFuture(ReplaceRequest, ref.spaceNo)
 	if err != nil {
 		return future.fail(conn, err)
 	}
-	return future.send(conn, func(enc *msgpack.Encoder) error {
-		enc.EncodeMapLen(2)
-		return future.fillInsert(enc, spaceNo, tuple)
-	})
+	return future.send(conn, insertRefBody(ref, tuple))
 }
 
 // DeleteAsync sends deletion action to tarantool and returns Future.
 // Future's result will contain array with deleted tuple.
 func (conn *Connection) DeleteAsync(space, index interface{}, key interface{}) *Future {
-	future := conn.newFuture(DeleteRequest)
-	spaceNo, indexNo, err := conn.Schema.resolveSpaceIndex(space, index)
+	ref, err := conn.resolveRef(space, index)
+	future := conn.newFuture(DeleteRequest, ref.spaceNo)
 	if err != nil {
 		return future.fail(conn, err)
 	}
-	return future.send(conn, func(enc *msgpack.Encoder) error {
-		enc.EncodeMapLen(3)
-		return future.fillSearch(enc, spaceNo, indexNo, key)
-	})
+	return future.send(conn, deleteRefBody(ref, key))
 }
 
 // Update sends deletion of a tuple by key and returns Future.
 // Future's result will contain array with updated tuple.
 func (conn *Connection) UpdateAsync(space, index interface{}, key, ops interface{}) *Future {
-	future := conn.newFuture(UpdateRequest)
-	spaceNo, indexNo, err := conn.Schema.resolveSpaceIndex(space, index)
+	ref, err := conn.resolveRef(space, index)
+	if err == nil {
+		err = conn.validateWrite(ref.spaceNo, nil, ops)
+	}
+	future := conn.newFuture(UpdateRequest, ref.spaceNo)
 	if err != nil {
 		return future.fail(conn, err)
 	}
-	return future.send(conn, func(enc *msgpack.Encoder) error {
-		enc.EncodeMapLen(4)
-		if err := future.fillSearch(enc, spaceNo, indexNo, key); err != nil {
-			return err
-		}
-		enc.EncodeUint64(KeyTuple)
-		return enc.Encode(ops)
-	})
+	return future.send(conn, updateRefBody(ref, key, ops))
 }
 
 // UpsertAsync sends "update or insert" action to tarantool and returns Future.
 // Future's sesult will not contain any tuple.
 func (conn *Connection) UpsertAsync(space interface{}, tuple interface{}, ops interface{}) *Future {
-	future := conn.newFuture(UpsertRequest)
-	spaceNo, _, err := conn.Schema.resolveSpaceIndex(space, nil)
+	ref, err := conn.resolveRef(space, nil)
+	if err == nil {
+		err = conn.validateWrite(ref.spaceNo, tuple, ops)
+	}
+	future := conn.newFuture(UpsertRequest, ref.spaceNo)
 	if err != nil {
 		return future.fail(conn, err)
 	}
-	return future.send(conn, func(enc *msgpack.Encoder) error {
-		enc.EncodeMapLen(3)
-		enc.EncodeUint64(KeySpaceNo)
-		enc.EncodeUint64(uint64(spaceNo))
-		enc.EncodeUint64(KeyTuple)
-		if err := enc.Encode(tuple); err != nil {
-			return err
-		}
-		enc.EncodeUint64(KeyDefTuple)
-		return enc.Encode(ops)
-	})
+	return future.send(conn, upsertRefBody(ref, tuple, ops))
 }
 
 // CallAsync sends a call to registered tarantool function and returns Future.
 // It uses request code for tarantool 1.6, so future's result is always array of arrays
 func (conn *Connection) CallAsync(functionName string, args interface{}) *Future {
-	future := conn.newFuture(CallRequest)
-	return future.send(conn, func(enc *msgpack.Encoder) error {
-		enc.EncodeMapLen(2)
-		enc.EncodeUint64(KeyFunctionName)
-		enc.EncodeString(functionName)
-		enc.EncodeUint64(KeyTuple)
-		return enc.Encode(args)
-	})
+	future := conn.newFutureFor(CallRequest, 0, functionName)
+	return future.send(conn, callBody(functionName, args))
 }
 
 // Call17Async sends a call to registered tarantool function and returns Future.
 // It uses request code for tarantool 1.7, so future's result will not be converted
 // (though, keep in mind, result is always array)
+//
+// Tarantool 1.6 does not support this request code, so it is emulated with
+// Eval of "return functionName(...)". In this case user needs 'execute
+// universe' privilege.
 func (conn *Connection) Call17Async(functionName string, args interface{}) *Future {
-	future := conn.newFuture(Call17Request)
-	return future.send(conn, func(enc *msgpack.Encoder) error {
-		enc.EncodeMapLen(2)
-		enc.EncodeUint64(KeyFunctionName)
-		enc.EncodeString(functionName)
-		enc.EncodeUint64(KeyTuple)
-		return enc.Encode(args)
-	})
+	requestCode, body := conn.call17Body(functionName, args)
+	future := conn.newFutureFor(requestCode, 0, functionName)
+	return future.send(conn, body)
 }
 
 // EvalAsync sends a lua expression for evaluation and returns Future.
 func (conn *Connection) EvalAsync(expr string, args interface{}) *Future {
-	future := conn.newFuture(EvalRequest)
-	return future.send(conn, func(enc *msgpack.Encoder) error {
+	future := conn.newFuture(EvalRequest, 0)
+	return future.send(conn, evalBody(expr, args))
+}
+
+//
+// private
+//
+
+// selectRef resolves space and index of select and validates its iterator
+// the same way for all kinds of select.
+func (conn *Connection) selectRef(space, index interface{}, iterator uint32) (spaceRef, error) {
+	ref, err := conn.resolveRef(space, index)
+	if err == nil {
+		err = conn.validateIterator(ref.spaceNo, ref.indexNo, iterator)
+	}
+	return ref, err
+}
+
+func selectBody(spaceNo, indexNo, offset, limit, iterator uint32, key interface{}) func(*msgpack.Encoder) error {
+	return selectRefBody(spaceRef{spaceNo: spaceNo, indexNo: indexNo}, offset, limit, iterator, key)
+}
+
+func selectRefBody(ref spaceRef, offset, limit, iterator uint32, key interface{}) func(*msgpack.Encoder) error {
+	return func(enc *msgpack.Encoder) error {
+		var req Future
+		enc.EncodeMapLen(6)
+		req.fillIterator(enc, offset, limit, iterator)
+		return req.fillSearch(enc, ref, key)
+	}
+}
+
+func insertBody(spaceNo uint32, tuple interface{}) func(*msgpack.Encoder) error {
+	return insertRefBody(spaceRef{spaceNo: spaceNo}, tuple)
+}
+
+func insertRefBody(ref spaceRef, tuple interface{}) func(*msgpack.Encoder) error {
+	return func(enc *msgpack.Encoder) error {
+		var req Future
+		enc.EncodeMapLen(2)
+		return req.fillInsert(enc, ref, tuple)
+	}
+}
+
+func deleteBody(spaceNo, indexNo uint32, key interface{}) func(*msgpack.Encoder) error {
+	return deleteRefBody(spaceRef{spaceNo: spaceNo, indexNo: indexNo}, key)
+}
+
+func deleteRefBody(ref spaceRef, key interface{}) func(*msgpack.Encoder) error {
+	return func(enc *msgpack.Encoder) error {
+		var req Future
+		enc.EncodeMapLen(3)
+		return req.fillSearch(enc, ref, key)
+	}
+}
+
+func updateBody(spaceNo, indexNo uint32, key, ops interface{}) func(*msgpack.Encoder) error {
+	return updateRefBody(spaceRef{spaceNo: spaceNo, indexNo: indexNo}, key, ops)
+}
+
+func updateRefBody(ref spaceRef, key, ops interface{}) func(*msgpack.Encoder) error {
+	return func(enc *msgpack.Encoder) error {
+		var req Future
+		enc.EncodeMapLen(4)
+		if err := req.fillSearch(enc, ref, key); err != nil {
+			return err
+		}
+		enc.EncodeUint64(KeyTuple)
+		return enc.Encode(ops)
+	}
+}
+
+func upsertBody(spaceNo uint32, tuple, ops interface{}) func(*msgpack.Encoder) error {
+	return upsertRefBody(spaceRef{spaceNo: spaceNo}, tuple, ops)
+}
+
+func upsertRefBody(ref spaceRef, tuple, ops interface{}) func(*msgpack.Encoder) error {
+	return func(enc *msgpack.Encoder) error {
+		enc.EncodeMapLen(3)
+		ref.fillSpace(enc)
+		enc.EncodeUint64(KeyTuple)
+		if err := enc.Encode(tuple); err != nil {
+			return err
+		}
+		enc.EncodeUint64(KeyDefTuple)
+		return enc.Encode(ops)
+	}
+}
+
+func evalBody(expr string, args interface{}) func(*msgpack.Encoder) error {
+	return func(enc *msgpack.Encoder) error {
 		enc.EncodeMapLen(2)
 		enc.EncodeUint64(KeyExpression)
 		enc.EncodeString(expr)
 		enc.EncodeUint64(KeyTuple)
 		return enc.Encode(args)
-	})
+	}
 }
 
-//
-// private
-//
+// encodeBody encodes request body once, so it could be compared
+// with other requests and sent several times with rawBody.
+func encodeBody(body func(*msgpack.Encoder) error) ([]byte, error) {
+	var packet smallWBuf
+	if err := body(msgpack.NewEncoder(&packet)); err != nil {
+		return nil, err
+	}
+	return packet.b, nil
+}
+
+func rawBody(b []byte) func(*msgpack.Encoder) error {
+	return func(enc *msgpack.Encoder) error {
+		_, err := enc.Writer().Write(b)
+		return err
+	}
+}
+
+func callBody(functionName string, args interface{}) func(*msgpack.Encoder) error {
+	return func(enc *msgpack.Encoder) error {
+		enc.EncodeMapLen(2)
+		enc.EncodeUint64(KeyFunctionName)
+		enc.EncodeString(functionName)
+		enc.EncodeUint64(KeyTuple)
+		return enc.Encode(args)
+	}
+}
 
 func (fut *Future) pack(h *smallWBuf, enc *msgpack.Encoder, body func(*msgpack.Encoder) error) (err error) {
 	rid := fut.requestId
 	hl := h.Len()
 	h.Write([]byte{
 		0xce, 0, 0, 0, 0, // length
-		0x82,                           // 2 element map
-		KeyCode, byte(fut.requestCode), // request code
+		0x82,    // 2 element map
+		KeyCode, // request code
+	})
+	if code := fut.requestCode; code >= 0 && code <= 0x7f {
+		h.WriteByte(byte(code))
+	} else {
+		// custom request types may be out of positive fixint range
+		h.Write([]byte{0xce, byte(code >> 24), byte(code >> 16), byte(code >> 8), byte(code)})
+	}
+	h.Write([]byte{
 		KeySync, 0xce,
 		byte(rid >> 24), byte(rid >> 16),
 		byte(rid >> 8), byte(rid),
@@ -384,6 +490,9 @@ func (fut *Future) send(conn *Connection, body func(*msgpack.Encoder) error) *Fu
 }
 
 func (fut *Future) markReady(conn *Connection) {
+	if conn.requests != nil {
+		conn.requests.add(fut, conn.sinceEpoch()-fut.start)
+	}
 	close(fut.ready)
 	if conn.rlimit != nil {
 		<-conn.rlimit
@@ -398,11 +507,24 @@ func (fut *Future) fail(conn *Connection, err error) *Future {
 	return fut
 }
 
+func (fut *Future) pending(now time.Duration) *PendingRequest {
+	return &PendingRequest{
+		RequestId:   fut.requestId,
+		RequestCode: fut.requestCode,
+		SpaceNo:     fut.spaceNo,
+		Age:         now - fut.start,
+	}
+}
+
 func (fut *Future) wait() {
 	if fut.ready == nil {
 		return
 	}
 	<-fut.ready
+	if atomic.LoadInt64(&fut.memory) > 0 {
+		fut.releaseMemory()
+		runtime.SetFinalizer(fut, nil)
+	}
 }
 
 // Get waits for Future to be filled and returns Response and error
@@ -419,7 +541,21 @@ func (fut *Future) Get() (*Response, error) {
 	if fut.err != nil {
 		return fut.resp, fut.err
 	}
-	fut.err = fut.resp.decodeBody()
+	fut.resp.decodeKey = lookupDecodeKey(fut.requestCode)
+	body := fut.resp.buf.Bytes()
+	fut.profile(fut.conn, "decode", func() {
+		err := hitFailpoint(FailpointDecode)
+		if err == nil {
+			err = fut.resp.decodeBody()
+		}
+		fut.err = fut.decodeError(body, err)
+	})
+	if fut.err == nil && fut.conn != nil && fut.conn.opts.TupleExtension {
+		fut.conn.tupleFormats.add(fut.resp.tupleFormats)
+		for i := range fut.resp.Data {
+			fut.resp.Data[i] = fut.conn.tupleFormats.bind(fut.resp.Data[i])
+		}
+	}
 	return fut.resp, fut.err
 }
 
@@ -432,7 +568,18 @@ func (fut *Future) GetTyped(result interface{}) error {
 	if fut.err != nil {
 		return fut.err
 	}
-	fut.err = fut.resp.decodeBodyTyped(result)
+	fut.resp.decodeKey = lookupDecodeKey(fut.requestCode)
+	body := fut.resp.buf.Bytes()
+	fut.profile(fut.conn, "decode", func() {
+		err := hitFailpoint(FailpointDecode)
+		if err == nil {
+			err = fut.resp.decodeBodyTyped(result)
+		}
+		fut.err = fut.decodeError(body, err)
+	})
+	if fut.err == nil && fut.conn != nil {
+		fut.conn.tupleFormats.add(fut.resp.tupleFormats)
+	}
 	return fut.err
 }
 
@@ -450,6 +597,13 @@ func (fut *Future) WaitChan() <-chan struct{} {
 	return fut.ready
 }
 
+// Sync returns request id (IPROTO_SYNC) the request was sent with.
+// It is zero if request was not sent. Futures returned by Dedup and Cache
+// get id of the shared request, so it is known only after they are ready.
+func (fut *Future) Sync() uint32 {
+	return fut.requestId
+}
+
 // Err returns error set on Future.
 // It waits for future to be set.
 // Note: it doesn't decode body, therefore decoding error are not set here.
@@ -12,7 +12,14 @@ type Response struct {
 	Error     string // error message
 	// Data contains deserialized data for untyped requests
 	Data []interface{}
-	buf  smallBuf
+	// Extra contains body keys decoded by RequestType.DecodeKey of
+	// a custom request type.
+	Extra map[int]interface{}
+	buf   smallBuf
+	// tupleFormats are formats of MP_TUPLE tuples sent with response
+	tupleFormats map[uint64]*TupleFormat
+
+	decodeKey func(key int, d *msgpack.Decoder) (interface{}, error)
 }
 
 func (resp *Response) fill(b []byte) {
@@ -90,8 +97,12 @@ func (resp *Response) decodeBody() (err error) {
 				if resp.Error, err = d.DecodeString(); err != nil {
 					return err
 				}
+			case KeyTupleFormats:
+				if resp.tupleFormats, err = decodeTupleFormats(d); err != nil {
+					return err
+				}
 			default:
-				if err = d.Skip(); err != nil {
+				if err = resp.decodeExtra(cd, d); err != nil {
 					return err
 				}
 			}
@@ -125,8 +136,12 @@ func (resp *Response) decodeBodyTyped(res interface{}) (err error) {
 				if resp.Error, err = d.DecodeString(); err != nil {
 					return err
 				}
+			case KeyTupleFormats:
+				if resp.tupleFormats, err = decodeTupleFormats(d); err != nil {
+					return err
+				}
 			default:
-				if err = d.Skip(); err != nil {
+				if err = resp.decodeExtra(cd, d); err != nil {
 					return err
 				}
 			}
@@ -139,6 +154,21 @@ func (resp *Response) decodeBodyTyped(res interface{}) (err error) {
 	return
 }
 
+func (resp *Response) decodeExtra(key int, d *msgpack.Decoder) error {
+	if resp.decodeKey == nil {
+		return d.Skip()
+	}
+	v, err := resp.decodeKey(key, d)
+	if err != nil {
+		return err
+	}
+	if resp.Extra == nil {
+		resp.Extra = make(map[int]interface{})
+	}
+	resp.Extra[key] = v
+	return nil
+}
+
 // String implements Stringer interface
 func (resp *Response) String() (str string) {
 	if resp.Code == OkCode {
@@ -11,6 +11,8 @@ type Schema struct {
 	Spaces map[string]*Space
 	// SpacesById is map from space numbers to spaces
 	SpacesById map[uint32]*Space
+
+	aliases *nameAliases
 }
 
 // Space contains information about tarantool space
@@ -30,9 +32,10 @@ type Space struct {
 }
 
 type Field struct {
-	Id   uint32
-	Name string
-	Type string
+	Id         uint32
+	Name       string
+	Type       string
+	IsNullable bool
 }
 
 // Index contains information about index
@@ -61,12 +64,14 @@ func (conn *Connection) loadSchema() (err error) {
 	var resp *Response
 
 	schema := new(Schema)
+	schema.aliases = conn.aliases
 	schema.SpacesById = make(map[uint32]*Space)
 	schema.Spaces = make(map[string]*Space)
 
 	// reload spaces
-	resp, err = conn.Select(vspaceSpId, 0, 0, maxSchemas, IterAll, []interface{}{})
-	if err != nil {
+	fut := conn.SelectAsync(vspaceSpId, 0, 0, maxSchemas, IterAll, []interface{}{})
+	conn.Flush() // do not wait for Opts.FlushInterval
+	if resp, err = fut.Get(); err != nil {
 		return err
 	}
 	for _, row := range resp.Data {
@@ -106,6 +111,9 @@ func (conn *Connection) loadSchema() (err error) {
 				if type1, ok := f["type"]; ok && type1 != nil {
 					field.Type = type1.(string)
 				}
+				if nullable, ok := f["is_nullable"].(bool); ok {
+					field.IsNullable = nullable
+				}
 				space.FieldsById[field.Id] = field
 				if field.Name != "" {
 					space.Fields[field.Name] = field
@@ -118,8 +126,9 @@ func (conn *Connection) loadSchema() (err error) {
 	}
 
 	// reload indexes
-	resp, err = conn.Select(vindexSpId, 0, 0, maxSchemas, IterAll, []interface{}{})
-	if err != nil {
+	fut = conn.SelectAsync(vindexSpId, 0, 0, maxSchemas, IterAll, []interface{}{})
+	conn.Flush()
+	if resp, err = fut.Get(); err != nil {
 		return err
 	}
 	for _, row := range resp.Data {
@@ -170,10 +179,69 @@ func (conn *Connection) loadSchema() (err error) {
 		schema.SpacesById[spaceId].IndexesById[index.Id] = index
 		schema.SpacesById[spaceId].Indexes[index.Name] = index
 	}
-	conn.Schema = schema
+	conn.setSchema(schema)
 	return nil
 }
 
+// SchemaResolver resolves spaces and indexes given by numbers or names to
+// their numbers. Schema implements it, custom resolvers may be set with
+// Opts.SchemaResolver, e.g. ones backed by a config service or by a
+// static file, see ImportSchema.
+type SchemaResolver interface {
+	ResolveSpaceIndex(space, index interface{}) (spaceNo, indexNo uint32, err error)
+}
+
+// NameResolutionError is returned when space or index given by name can't
+// be resolved.
+type NameResolutionError struct {
+	// Space is a name of space, it is empty if space is given by number.
+	Space   string
+	SpaceNo uint32
+	// Index is a name of index, it is empty if space is not resolved.
+	Index string
+	// SchemaNotLoaded is set if names can't be resolved at all, e.g.
+	// with Opts.SkipSchema.
+	SchemaNotLoaded bool
+}
+
+func (err NameResolutionError) Error() string {
+	space := err.Space
+	if space == "" {
+		space = fmt.Sprintf("with id %d", err.SpaceNo)
+	}
+	switch {
+	case err.SchemaNotLoaded && err.Index == "":
+		return fmt.Sprintf("Schema is not loaded to resolve space %s", space)
+	case err.SchemaNotLoaded:
+		return fmt.Sprintf("Schema is not loaded to resolve index %s of space %s", err.Index, space)
+	case err.Space == "" && err.Index != "":
+		return fmt.Sprintf("there is no space %s", space)
+	case err.Index == "":
+		return fmt.Sprintf("there is no space with name %s", space)
+	}
+	return fmt.Sprintf("space %s has not index with name %s", space, err.Index)
+}
+
+// resolver returns Opts.SchemaResolver or loaded schema.
+func (conn *Connection) resolver() SchemaResolver {
+	if conn.opts.SchemaResolver != nil {
+		return conn.opts.SchemaResolver
+	}
+	return conn.Schema
+}
+
+func (conn *Connection) resolveSpaceIndex(space, index interface{}) (spaceNo, indexNo uint32, err error) {
+	return conn.resolver().ResolveSpaceIndex(space, index)
+}
+
+// ResolveSpaceIndex returns numbers of space and index given as numbers
+// or names, index may be nil. Names which can't be resolved produce
+// NameResolutionError. It does not need a connection, so it may be used
+// with imported schema.
+func (schema *Schema) ResolveSpaceIndex(space, index interface{}) (spaceNo, indexNo uint32, err error) {
+	return schema.resolveSpaceIndex(space, index)
+}
+
 func (schema *Schema) resolveSpaceIndex(s interface{}, i interface{}) (spaceNo, indexNo uint32, err error) {
 	var space *Space
 	var index *Index
@@ -182,11 +250,11 @@ func (schema *Schema) resolveSpaceIndex(s interface{}, i interface{}) (spaceNo,
 	switch s := s.(type) {
 	case string:
 		if schema == nil {
-			err = fmt.Errorf("Schema is not loaded")
+			err = NameResolutionError{Space: s, SchemaNotLoaded: true}
 			return
 		}
-		if space, ok = schema.Spaces[s]; !ok {
-			err = fmt.Errorf("there is no space with name %s", s)
+		if space, ok = schema.lookupSpace(s); !ok {
+			err = NameResolutionError{Space: s}
 			return
 		}
 		spaceNo = space.Id
@@ -222,17 +290,17 @@ func (schema *Schema) resolveSpaceIndex(s interface{}, i interface{}) (spaceNo,
 		switch i := i.(type) {
 		case string:
 			if schema == nil {
-				err = fmt.Errorf("Schema is not loaded")
+				err = NameResolutionError{SpaceNo: spaceNo, Index: i, SchemaNotLoaded: true}
 				return
 			}
 			if space == nil {
 				if space, ok = schema.SpacesById[spaceNo]; !ok {
-					err = fmt.Errorf("there is no space with id %d", spaceNo)
+					err = NameResolutionError{SpaceNo: spaceNo, Index: i}
 					return
 				}
 			}
-			if index, ok = space.Indexes[i]; !ok {
-				err = fmt.Errorf("space %s has not index with name %s", space.Name, i)
+			if index, ok = schema.lookupIndex(space, i); !ok {
+				err = NameResolutionError{Space: space.Name, SpaceNo: spaceNo, Index: i}
 				return
 			}
 			indexNo = index.Id
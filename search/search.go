@@ -0,0 +1,204 @@
+// Package search calls search functions of Lua modules (fulltext indexes,
+// search caches) and decodes ranked hits with scores into typed documents:
+//
+//	s := search.New(conn, "catalog.search")
+//	hits, err := s.Search(search.Query{Text: "red shoes", Limit: 10},
+//		func() interface{} { return &Product{} })
+//	for _, hit := range hits {
+//		product := hit.Doc.(*Product)
+//	}
+//
+// Search function is called with Call17 as fn(text, opts), where opts is
+// a map with keys limit, offset, fields, filter and extra options of
+// Query. It should return an array of hits, each hit is either an array
+// {score, doc} or a map {score = score, doc = doc}.
+package search
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+	"gopkg.in/vmihailenco/msgpack.v2/codes"
+)
+
+// Query is a search query.
+type Query struct {
+	// Text is a search string.
+	Text string
+	// Limit is a maximum number of hits, zero means the module default.
+	Limit uint32
+	// Offset is a number of the best hits to skip.
+	Offset uint32
+	// Fields restricts search to the fields, if set.
+	Fields []string
+	// Filter is passed to the module as is, e.g. exact matches of fields.
+	Filter map[string]interface{}
+	// Options are extra module specific options.
+	Options map[string]interface{}
+}
+
+// EncodeMsgpack encodes query as arguments of search function.
+func (q Query) EncodeMsgpack(e *msgpack.Encoder) error {
+	opts := make(map[string]interface{}, len(q.Options)+4)
+	for k, v := range q.Options {
+		opts[k] = v
+	}
+	if q.Limit > 0 {
+		opts["limit"] = q.Limit
+	}
+	if q.Offset > 0 {
+		opts["offset"] = q.Offset
+	}
+	if len(q.Fields) > 0 {
+		opts["fields"] = q.Fields
+	}
+	if len(q.Filter) > 0 {
+		opts["filter"] = q.Filter
+	}
+	if err := e.EncodeSliceLen(2); err != nil {
+		return err
+	}
+	if err := e.EncodeString(q.Text); err != nil {
+		return err
+	}
+	return e.Encode(opts)
+}
+
+// Hit is a found document with its score.
+type Hit struct {
+	Score float64
+	// Doc is a document created by Searcher.Search factory, or decoded
+	// value if factory is nil.
+	Doc interface{}
+}
+
+// Searcher calls search function of Lua module.
+type Searcher struct {
+	conn     tarantool.Connector
+	function string
+	// MinScore drops hits with lower score, if set.
+	MinScore float64
+}
+
+// New creates Searcher calling function.
+func New(conn tarantool.Connector, function string) *Searcher {
+	return &Searcher{conn: conn, function: function}
+}
+
+// Search calls search function and returns hits ordered by descending
+// score. Documents are decoded into values returned by newDoc, it should
+// return pointers, e.g. to structs.
+func (s *Searcher) Search(q Query, newDoc func() interface{}) ([]Hit, error) {
+	res := hitsResult{newDoc: newDoc}
+	if err := s.conn.Call17Typed(s.function, q, &res); err != nil {
+		return nil, err
+	}
+	hits := res.hits[:0]
+	for _, hit := range res.hits {
+		if hit.Score >= s.MinScore {
+			hits = append(hits, hit)
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	return hits, nil
+}
+
+// hitsResult decodes results of search function: array of hits is the
+// first result, the rest are skipped.
+type hitsResult struct {
+	newDoc func() interface{}
+	hits   []Hit
+}
+
+func (r *hitsResult) DecodeMsgpack(d *msgpack.Decoder) error {
+	n, err := d.DecodeSliceLen()
+	if err != nil {
+		return err
+	}
+	if n <= 0 {
+		return nil
+	}
+	if err = r.decodeHits(d); err != nil {
+		return err
+	}
+	for ; n > 1; n-- {
+		if err = d.Skip(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *hitsResult) decodeHits(d *msgpack.Decoder) error {
+	n, err := d.DecodeSliceLen()
+	if err != nil || n <= 0 {
+		return err
+	}
+	r.hits = make([]Hit, n)
+	for i := range r.hits {
+		if r.newDoc != nil {
+			r.hits[i].Doc = r.newDoc()
+		}
+		if err = decodeHit(d, &r.hits[i]); err != nil {
+			return fmt.Errorf("hit %d: %s", i, err)
+		}
+	}
+	return nil
+}
+
+func decodeHit(d *msgpack.Decoder, hit *Hit) error {
+	code, err := d.PeekCode()
+	if err != nil {
+		return err
+	}
+	if !isMap(code) {
+		n, err := d.DecodeSliceLen()
+		if err != nil {
+			return err
+		}
+		if n != 2 {
+			return fmt.Errorf("expected {score, doc}, got %d values", n)
+		}
+		if hit.Score, err = d.DecodeFloat64(); err != nil {
+			return err
+		}
+		return decodeDoc(d, hit)
+	}
+	n, err := d.DecodeMapLen()
+	if err != nil {
+		return err
+	}
+	for ; n > 0; n-- {
+		key, err := d.DecodeString()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "score":
+			hit.Score, err = d.DecodeFloat64()
+		case "doc":
+			err = decodeDoc(d, hit)
+		default:
+			err = d.Skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isMap(code byte) bool {
+	return codes.IsFixedMap(code) || code == codes.Map16 || code == codes.Map32
+}
+
+func decodeDoc(d *msgpack.Decoder, hit *Hit) error {
+	if hit.Doc == nil {
+		return d.Decode(&hit.Doc)
+	}
+	return d.Decode(hit.Doc)
+}
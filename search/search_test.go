@@ -0,0 +1,98 @@
+package search_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/search"
+)
+
+var server = "127.0.0.1:3013"
+var opts = Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+// initLua defines toy search function scoring documents by number of
+// query words in their titles.
+const initLua = `
+local docs = {
+    {1, 'red shoes'},
+    {2, 'blue shoes'},
+    {3, 'red red hat'},
+}
+rawset(_G, 'go_tarantool_search_test', function(text, opts)
+    local hits = {}
+    for _, doc in ipairs(docs) do
+        local score = 0
+        for word in text:gmatch('%S+') do
+            for _ in doc[2]:gmatch(word) do
+                score = score + 1
+            end
+        end
+        if score > 0 then
+            if opts.map then
+                table.insert(hits, {score = score, doc = doc})
+            else
+                table.insert(hits, {score, doc})
+            end
+        end
+    end
+    return hits
+end)
+`
+
+type doc struct {
+	Id    uint
+	Title string
+}
+
+func TestSearch(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	if _, err = conn.Eval(initLua, []interface{}{}); err != nil {
+		t.Errorf("Failed to define search function: %s", err.Error())
+		return
+	}
+	s := search.New(conn, "go_tarantool_search_test")
+	newDoc := func() interface{} { return &doc{} }
+
+	for _, asMap := range []bool{false, true} {
+		q := search.Query{Text: "red shoes", Options: map[string]interface{}{"map": asMap}}
+		hits, err := s.Search(q, newDoc)
+		if err != nil {
+			t.Errorf("Failed to search: %s", err.Error())
+			return
+		}
+		if len(hits) != 3 {
+			t.Errorf("Unexpected hits: %v", hits)
+			return
+		}
+		if hits[0].Score != 2 || hits[2].Score != 1 {
+			t.Errorf("Hits are not ranked: %v", hits)
+		}
+		if d := hits[2].Doc.(*doc); d.Id != 2 || d.Title != "blue shoes" {
+			t.Errorf("Unexpected document: %v", d)
+		}
+	}
+
+	s.MinScore = 2
+	hits, err := s.Search(search.Query{Text: "red"}, nil)
+	if err != nil {
+		t.Errorf("Failed to search: %s", err.Error())
+		return
+	}
+	if len(hits) != 1 || hits[0].Score != 2 {
+		t.Errorf("Unexpected hits with min score: %v", hits)
+	}
+	if _, ok := hits[0].Doc.([]interface{}); !ok {
+		t.Errorf("Unexpected document without factory: %T", hits[0].Doc)
+	}
+}
@@ -0,0 +1,84 @@
+package tarantool
+
+import "fmt"
+
+// SessionInfo describes server session of connection (box.session).
+type SessionInfo struct {
+	// Id is a session id, it changes after reconnect.
+	Id uint64
+	// User is a name of session user.
+	User string
+	// Peer is a client address as seen by server.
+	Peer string
+}
+
+const sessionInfoExpr = "return box.session.id(), box.session.user(), box.session.peer()"
+
+// SessionInfo returns information about server session of connection.
+// It is fetched with Eval on the first call after connection is
+// established, so it is empty if user has no 'execute universe'
+// privilege or if Eval fails.
+func (conn *Connection) SessionInfo() SessionInfo {
+	conn.mutex.Lock()
+	if conn.session != nil {
+		info := *conn.session
+		conn.mutex.Unlock()
+		return info
+	}
+	c := conn.c
+	conn.mutex.Unlock()
+
+	var info SessionInfo
+	resp, err := conn.Eval(sessionInfoExpr, []interface{}{})
+	if err == nil {
+		info = decodeSessionInfo(resp.Data)
+	} else if _, ok := err.(Error); !ok {
+		// client errors, e.g. timeouts, are not cached
+		return info
+	}
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	if c != nil && conn.c == c {
+		conn.session = &info
+	}
+	return info
+}
+
+func decodeSessionInfo(data []interface{}) (info SessionInfo) {
+	if len(data) > 0 {
+		switch id := data[0].(type) {
+		case uint64:
+			info.Id = id
+		case int64:
+			info.Id = uint64(id)
+		}
+	}
+	if len(data) > 1 {
+		info.User, _ = data[1].(string)
+	}
+	if len(data) > 2 {
+		info.Peer, _ = data[2].(string)
+	}
+	return info
+}
+
+const suExpr = "local user, expr, args = ... " +
+	"return box.session.su(user, loadstring(expr), unpack(args))"
+
+// EvalAs evaluates lua expression on behalf of the user with
+// box.session.su. args should be an array.
+// Note: session user needs privileges to switch to the user (e.g. admin).
+func (conn *Connection) EvalAs(user string, expr string, args interface{}) (resp *Response, err error) {
+	return conn.Eval(suExpr, []interface{}{user, expr, args})
+}
+
+// CallAs calls global lua function on behalf of the user with
+// box.session.su. args should be an array. functionName should be a
+// lua name like "box.info" or "obj:method", other names are rejected.
+// Note: session user needs privileges to switch to the user (e.g. admin).
+func (conn *Connection) CallAs(user string, functionName string, args interface{}) (resp *Response, err error) {
+	if !luaFunctionName.MatchString(functionName) {
+		return nil, fmt.Errorf("invalid function name %q", functionName)
+	}
+	return conn.EvalAs(user, "return "+functionName+"(...)", args)
+}
@@ -0,0 +1,190 @@
+package tarantool
+
+import (
+	"container/heap"
+	"math"
+	"math/bits"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultHeavyRequests is a number of the largest packets remembered when
+// Opts.HeavyRequests is not set.
+const defaultHeavyRequests = 10
+
+// SizeKey identifies a kind of requests sizes are tracked for.
+type SizeKey struct {
+	RequestCode int32
+	// SpaceNo is a space of request, it is zero for requests without space.
+	SpaceNo uint32
+}
+
+// SizeHistogram is a distribution of packet sizes in bytes.
+type SizeHistogram struct {
+	// Count is a number of packets.
+	Count uint64
+	// Sum is a total size of packets.
+	Sum uint64
+	// Max is a size of the largest packet.
+	Max uint64
+	// Buckets are numbers of packets by size: Buckets[i] is a number of
+	// packets with size in [2^(i-1), 2^i).
+	Buckets [33]uint64
+}
+
+func (h *SizeHistogram) add(size int) {
+	s := uint64(size)
+	h.Count++
+	h.Sum += s
+	if s > h.Max {
+		h.Max = s
+	}
+	b := len(h.Buckets) - 1
+	if s <= math.MaxUint32 {
+		b = bits.Len32(uint32(s))
+	}
+	h.Buckets[b]++
+}
+
+// Percentile returns upper bound of bucket which contains p-th percentile
+// (0 < p <= 100) of sizes.
+func (h SizeHistogram) Percentile(p float64) uint64 {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := uint64(float64(h.Count)*p/100 + 0.5)
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, n := range h.Buckets {
+		seen += n
+		if seen >= rank {
+			if i == 0 {
+				return 0
+			}
+			bound := uint64(1)<<uint(i) - 1
+			if bound > h.Max {
+				bound = h.Max
+			}
+			return bound
+		}
+	}
+	return h.Max
+}
+
+// HeavyPacket describes one of the largest requests or responses.
+type HeavyPacket struct {
+	SizeKey
+	// Direction is TrafficOut for requests and TrafficIn for responses.
+	Direction TrafficDirection
+	// RequestId is an id of request.
+	RequestId uint32
+	// Size is a packet size in bytes.
+	Size int
+	// Time is a time packet was sent or received.
+	Time time.Time
+}
+
+// SizeStats is a statistics of request and response sizes.
+type SizeStats struct {
+	// Requests are sizes of encoded requests.
+	Requests map[SizeKey]SizeHistogram
+	// Responses are sizes of received responses.
+	Responses map[SizeKey]SizeHistogram
+	// Heaviest are the largest requests and responses seen, from the
+	// largest one.
+	Heaviest []HeavyPacket
+}
+
+type heavyHeap []HeavyPacket
+
+func (h heavyHeap) Len() int            { return len(h) }
+func (h heavyHeap) Less(i, j int) bool  { return h[i].Size < h[j].Size }
+func (h heavyHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *heavyHeap) Push(x interface{}) { *h = append(*h, x.(HeavyPacket)) }
+func (h *heavyHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// sizeTracker collects SizeStats. It keeps the heaviest packets in a
+// min-heap bounded by k, so tracking costs O(log k) per packet.
+type sizeTracker struct {
+	mutex     sync.Mutex
+	k         int
+	requests  map[SizeKey]*SizeHistogram
+	responses map[SizeKey]*SizeHistogram
+	heaviest  heavyHeap
+}
+
+func newSizeTracker(k int) *sizeTracker {
+	if k <= 0 {
+		k = defaultHeavyRequests
+	}
+	return &sizeTracker{
+		k:         k,
+		requests:  make(map[SizeKey]*SizeHistogram),
+		responses: make(map[SizeKey]*SizeHistogram),
+	}
+}
+
+func (t *sizeTracker) add(dir TrafficDirection, fut *Future, size int, now time.Time) {
+	if t == nil {
+		return
+	}
+	key := SizeKey{RequestCode: fut.requestCode, SpaceNo: fut.spaceNo}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	hists := t.requests
+	if dir == TrafficIn {
+		hists = t.responses
+	}
+	h := hists[key]
+	if h == nil {
+		h = &SizeHistogram{}
+		hists[key] = h
+	}
+	h.add(size)
+
+	if len(t.heaviest) == t.k && t.heaviest[0].Size >= size {
+		return
+	}
+	packet := HeavyPacket{
+		SizeKey:   key,
+		Direction: dir,
+		RequestId: fut.requestId,
+		Size:      size,
+		Time:      now,
+	}
+	if len(t.heaviest) == t.k {
+		t.heaviest[0] = packet
+		heap.Fix(&t.heaviest, 0)
+	} else {
+		heap.Push(&t.heaviest, packet)
+	}
+}
+
+func (t *sizeTracker) stats() (stats SizeStats) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	stats.Requests = make(map[SizeKey]SizeHistogram, len(t.requests))
+	for key, h := range t.requests {
+		stats.Requests[key] = *h
+	}
+	stats.Responses = make(map[SizeKey]SizeHistogram, len(t.responses))
+	for key, h := range t.responses {
+		stats.Responses[key] = *h
+	}
+	stats.Heaviest = append([]HeavyPacket(nil), t.heaviest...)
+	sort.Slice(stats.Heaviest, func(i, j int) bool {
+		return stats.Heaviest[i].Size > stats.Heaviest[j].Size
+	})
+	return
+}
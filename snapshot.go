@@ -0,0 +1,171 @@
+package tarantool
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// SchemaSnapshot is a serializable copy of schema. It is encoded with
+// encoding/json or msgpack and loaded back with ImportSchema, so requests
+// can be resolved and encoded without a live connection, e.g. in tools,
+// tests and code generators.
+type SchemaSnapshot struct {
+	Version uint            `json:"version" msgpack:"version"`
+	Spaces  []SpaceSnapshot `json:"spaces" msgpack:"spaces"`
+}
+
+// SpaceSnapshot is a serializable copy of space.
+type SpaceSnapshot struct {
+	Id          uint32          `json:"id" msgpack:"id"`
+	Name        string          `json:"name" msgpack:"name"`
+	Engine      string          `json:"engine" msgpack:"engine"`
+	Temporary   bool            `json:"temporary,omitempty" msgpack:"temporary"`
+	FieldsCount uint32          `json:"field_count,omitempty" msgpack:"field_count"`
+	Fields      []FieldSnapshot `json:"format,omitempty" msgpack:"format"`
+	Indexes     []IndexSnapshot `json:"indexes,omitempty" msgpack:"indexes"`
+}
+
+// FieldSnapshot is a serializable copy of field of space format.
+type FieldSnapshot struct {
+	Id         uint32 `json:"id" msgpack:"id"`
+	Name       string `json:"name" msgpack:"name"`
+	Type       string `json:"type,omitempty" msgpack:"type"`
+	IsNullable bool   `json:"is_nullable,omitempty" msgpack:"is_nullable"`
+}
+
+// IndexSnapshot is a serializable copy of index.
+type IndexSnapshot struct {
+	Id     uint32              `json:"id" msgpack:"id"`
+	Name   string              `json:"name" msgpack:"name"`
+	Type   string              `json:"type" msgpack:"type"`
+	Unique bool                `json:"unique" msgpack:"unique"`
+	Parts  []IndexPartSnapshot `json:"parts" msgpack:"parts"`
+}
+
+// IndexPartSnapshot is a serializable copy of index part.
+type IndexPartSnapshot struct {
+	Field uint32 `json:"field" msgpack:"field"`
+	Type  string `json:"type" msgpack:"type"`
+}
+
+// Export returns snapshot of schema with spaces, fields and indexes
+// ordered by numbers. Aliases of names are not exported.
+func (schema *Schema) Export() *SchemaSnapshot {
+	snapshot := &SchemaSnapshot{Version: schema.Version}
+	for _, space := range schema.SpacesById {
+		s := SpaceSnapshot{
+			Id:          space.Id,
+			Name:        space.Name,
+			Engine:      space.Engine,
+			Temporary:   space.Temporary,
+			FieldsCount: space.FieldsCount,
+		}
+		for _, field := range space.FieldsById {
+			s.Fields = append(s.Fields, FieldSnapshot{
+				Id:         field.Id,
+				Name:       field.Name,
+				Type:       field.Type,
+				IsNullable: field.IsNullable,
+			})
+		}
+		sort.Slice(s.Fields, func(i, j int) bool { return s.Fields[i].Id < s.Fields[j].Id })
+		for _, index := range space.IndexesById {
+			idx := IndexSnapshot{
+				Id:     index.Id,
+				Name:   index.Name,
+				Type:   index.Type,
+				Unique: index.Unique,
+			}
+			for _, part := range index.Fields {
+				idx.Parts = append(idx.Parts, IndexPartSnapshot{Field: part.Id, Type: part.Type})
+			}
+			s.Indexes = append(s.Indexes, idx)
+		}
+		sort.Slice(s.Indexes, func(i, j int) bool { return s.Indexes[i].Id < s.Indexes[j].Id })
+		snapshot.Spaces = append(snapshot.Spaces, s)
+	}
+	sort.Slice(snapshot.Spaces, func(i, j int) bool { return snapshot.Spaces[i].Id < snapshot.Spaces[j].Id })
+	return snapshot
+}
+
+// ImportSchema loads schema from snapshot encoded with JSON or msgpack.
+// It returns error if snapshot has duplicate numbers or names of spaces,
+// fields or indexes.
+func ImportSchema(data []byte) (*Schema, error) {
+	var snapshot SchemaSnapshot
+	var err error
+	// JSON object starts with '{', while msgpack map starts with a map
+	// prefix, so they are not confused
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(trimmed, &snapshot)
+	} else {
+		err = msgpack.Unmarshal(data, &snapshot)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode schema snapshot: %s", err)
+	}
+	return snapshot.Schema()
+}
+
+// Schema builds schema from snapshot. It returns error if snapshot has
+// duplicate numbers or names of spaces, fields or indexes.
+func (snapshot *SchemaSnapshot) Schema() (*Schema, error) {
+	schema := &Schema{
+		Version:    snapshot.Version,
+		Spaces:     make(map[string]*Space, len(snapshot.Spaces)),
+		SpacesById: make(map[uint32]*Space, len(snapshot.Spaces)),
+	}
+	for _, s := range snapshot.Spaces {
+		if _, ok := schema.SpacesById[s.Id]; ok {
+			return nil, fmt.Errorf("duplicate space id %d", s.Id)
+		}
+		if _, ok := schema.Spaces[s.Name]; ok {
+			return nil, fmt.Errorf("duplicate space name %s", s.Name)
+		}
+		space := &Space{
+			Id:          s.Id,
+			Name:        s.Name,
+			Engine:      s.Engine,
+			Temporary:   s.Temporary,
+			FieldsCount: s.FieldsCount,
+			Fields:      make(map[string]*Field),
+			FieldsById:  make(map[uint32]*Field),
+			Indexes:     make(map[string]*Index),
+			IndexesById: make(map[uint32]*Index),
+		}
+		for _, f := range s.Fields {
+			if _, ok := space.FieldsById[f.Id]; ok {
+				return nil, fmt.Errorf("duplicate field %d of space %s", f.Id, s.Name)
+			}
+			field := &Field{Id: f.Id, Name: f.Name, Type: f.Type, IsNullable: f.IsNullable}
+			space.FieldsById[field.Id] = field
+			if field.Name != "" {
+				if _, ok := space.Fields[field.Name]; ok {
+					return nil, fmt.Errorf("duplicate field %s of space %s", f.Name, s.Name)
+				}
+				space.Fields[field.Name] = field
+			}
+		}
+		for _, i := range s.Indexes {
+			if _, ok := space.IndexesById[i.Id]; ok {
+				return nil, fmt.Errorf("duplicate index %d of space %s", i.Id, s.Name)
+			}
+			if _, ok := space.Indexes[i.Name]; ok {
+				return nil, fmt.Errorf("duplicate index %s of space %s", i.Name, s.Name)
+			}
+			index := &Index{Id: i.Id, Name: i.Name, Type: i.Type, Unique: i.Unique}
+			for _, part := range i.Parts {
+				index.Fields = append(index.Fields, &IndexField{Id: part.Field, Type: part.Type})
+			}
+			space.IndexesById[index.Id] = index
+			space.Indexes[index.Name] = index
+		}
+		schema.SpacesById[space.Id] = space
+		schema.Spaces[space.Name] = space
+	}
+	return schema, nil
+}
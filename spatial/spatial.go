@@ -0,0 +1,176 @@
+// Package spatial provides points and rectangles for RTREE indexes and
+// helpers for spatial selects:
+//
+//	idx := spatial.New(conn, "places", "spatial")
+//	var nearest []Place
+//	err := idx.Nearest(spatial.Point{55.75, 37.62}, 10, &nearest)
+//
+// Points and rectangles are encoded as arrays of coordinates, so they
+// can be used both as keys and as indexed fields of tuples.
+package spatial
+
+import (
+	"fmt"
+
+	"github.com/tarantool/go-tarantool"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// Shape is a key of RTREE index: Point or Rect.
+type Shape interface {
+	Key() ([]interface{}, error)
+}
+
+// Point is a point with coordinates, its dimension is the number of
+// coordinates.
+type Point []float64
+
+// Key returns RTREE key of the point.
+func (p Point) Key() ([]interface{}, error) {
+	if len(p) == 0 {
+		return nil, fmt.Errorf("point has no coordinates")
+	}
+	return tarantool.RTreePoint(p...), nil
+}
+
+// EncodeMsgpack encodes point as array of coordinates.
+func (p Point) EncodeMsgpack(e *msgpack.Encoder) error {
+	return encodeCoords(e, p)
+}
+
+// DecodeMsgpack decodes point from array of coordinates.
+func (p *Point) DecodeMsgpack(d *msgpack.Decoder) (err error) {
+	*p, err = decodeCoords(d)
+	return
+}
+
+// Rect is a rectangle (a box in multidimensional case) with the lowest
+// corner Min and the highest corner Max.
+type Rect struct {
+	Min Point
+	Max Point
+}
+
+// NewRect returns rectangle with corners min and max. It returns error if
+// dimensions of corners differ or some coordinate of min is greater than
+// the one of max.
+func NewRect(min, max Point) (Rect, error) {
+	r := Rect{Min: min, Max: max}
+	return r, r.validate()
+}
+
+func (r Rect) validate() error {
+	if len(r.Min) != len(r.Max) || len(r.Min) == 0 {
+		return fmt.Errorf("rectangle corners have dimensions %d and %d", len(r.Min), len(r.Max))
+	}
+	for i := range r.Min {
+		if r.Min[i] > r.Max[i] {
+			return fmt.Errorf("rectangle corner %v is above corner %v", r.Min, r.Max)
+		}
+	}
+	return nil
+}
+
+// Key returns RTREE key of the rectangle.
+func (r Rect) Key() ([]interface{}, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return tarantool.RTreeRect(r.Min, r.Max)
+}
+
+// EncodeMsgpack encodes rectangle as array of coordinates of the lowest
+// corner followed by coordinates of the highest corner.
+func (r Rect) EncodeMsgpack(e *msgpack.Encoder) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+	return encodeCoords(e, append(append(Point{}, r.Min...), r.Max...))
+}
+
+// DecodeMsgpack decodes rectangle from array of 2*dimension coordinates.
+func (r *Rect) DecodeMsgpack(d *msgpack.Decoder) error {
+	coords, err := decodeCoords(d)
+	if err != nil {
+		return err
+	}
+	if len(coords)%2 != 0 {
+		return fmt.Errorf("rectangle has odd number of coordinates %d", len(coords))
+	}
+	dim := len(coords) / 2
+	r.Min, r.Max = coords[:dim:dim], coords[dim:]
+	return nil
+}
+
+func encodeCoords(e *msgpack.Encoder, coords []float64) error {
+	if err := e.EncodeSliceLen(len(coords)); err != nil {
+		return err
+	}
+	for _, c := range coords {
+		if err := e.EncodeFloat64(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeCoords(d *msgpack.Decoder) (Point, error) {
+	l, err := d.DecodeSliceLen()
+	if err != nil {
+		return nil, err
+	}
+	coords := make(Point, l)
+	for i := range coords {
+		if coords[i], err = d.DecodeFloat64(); err != nil {
+			return nil, err
+		}
+	}
+	return coords, nil
+}
+
+// Index is a handle of RTREE index.
+type Index struct {
+	conn  tarantool.Connector
+	space interface{}
+	index interface{}
+}
+
+// New creates handle of RTREE index of space. Space and index are
+// numbers or names as in tarantool.Connector.Select.
+func New(conn tarantool.Connector, space, index interface{}) *Index {
+	return &Index{conn: conn, space: space, index: index}
+}
+
+// Select selects tuples with spatial iterator into result.
+func (idx *Index) Select(iterator uint32, shape Shape, offset, limit uint32, result interface{}) error {
+	key, err := shape.Key()
+	if err != nil {
+		return err
+	}
+	return idx.conn.SelectTyped(idx.space, idx.index, offset, limit, iterator, key, result)
+}
+
+// Nearest selects up to limit tuples nearest to point, ordered by
+// distance (NEIGHBOR iterator). The distance metric is set by index
+// option distance.
+func (idx *Index) Nearest(point Point, limit uint32, result interface{}) error {
+	return idx.Select(tarantool.IterNeighbor, point, 0, limit, result)
+}
+
+// Containing selects up to limit tuples with rectangles containing shape
+// (GE iterator).
+func (idx *Index) Containing(shape Shape, limit uint32, result interface{}) error {
+	return idx.Select(tarantool.IterGe, shape, 0, limit, result)
+}
+
+// Within selects up to limit tuples with rectangles or points within
+// rect (LE iterator).
+func (idx *Index) Within(rect Rect, limit uint32, result interface{}) error {
+	return idx.Select(tarantool.IterLe, rect, 0, limit, result)
+}
+
+// Overlapping selects up to limit tuples with rectangles overlapping rect
+// (OVERLAPS iterator).
+func (idx *Index) Overlapping(rect Rect, limit uint32, result interface{}) error {
+	return idx.Select(tarantool.IterOverlaps, rect, 0, limit, result)
+}
@@ -0,0 +1,125 @@
+package spatial_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/spatial"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+var server = "127.0.0.1:3013"
+var opts = Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+const spaceName = "test_spatial"
+
+const initLua = `
+local space = ...
+local s = box.schema.space.create(space, {if_not_exists = true})
+s:create_index('primary', {parts = {1, 'unsigned'}, if_not_exists = true})
+s:create_index('spatial', {type = 'rtree', unique = false, parts = {2, 'array'}, if_not_exists = true})
+s:truncate()
+`
+
+type place struct {
+	Id    uint
+	Point spatial.Point
+}
+
+func (p *place) DecodeMsgpack(d *msgpack.Decoder) error {
+	if _, err := d.DecodeSliceLen(); err != nil {
+		return err
+	}
+	var err error
+	if p.Id, err = d.DecodeUint(); err != nil {
+		return err
+	}
+	return d.Decode(&p.Point)
+}
+
+func TestShapes(t *testing.T) {
+	if _, err := spatial.NewRect(spatial.Point{1, 2}, spatial.Point{3}); err == nil {
+		t.Errorf("Rectangle with different dimensions of corners is valid")
+	}
+	if _, err := spatial.NewRect(spatial.Point{1, 5}, spatial.Point{3, 4}); err == nil {
+		t.Errorf("Rectangle with swapped corners is valid")
+	}
+	rect, err := spatial.NewRect(spatial.Point{1, 2}, spatial.Point{3, 4})
+	if err != nil {
+		t.Errorf("Failed to create rectangle: %s", err.Error())
+		return
+	}
+	key, err := rect.Key()
+	if err != nil || !reflect.DeepEqual(key, []interface{}{1.0, 2.0, 3.0, 4.0}) {
+		t.Errorf("Unexpected rectangle key: %v, %v", key, err)
+	}
+	if _, err = (spatial.Point{}).Key(); err == nil {
+		t.Errorf("Point without coordinates is valid")
+	}
+
+	buf, err := msgpack.Marshal(rect)
+	if err != nil {
+		t.Errorf("Failed to encode rectangle: %s", err.Error())
+		return
+	}
+	var decoded spatial.Rect
+	if err = msgpack.Unmarshal(buf, &decoded); err != nil || !reflect.DeepEqual(decoded, rect) {
+		t.Errorf("Unexpected decoded rectangle: %v, %v", decoded, err)
+	}
+	buf, err = msgpack.Marshal(spatial.Point{5, 6})
+	if err != nil {
+		t.Errorf("Failed to encode point: %s", err.Error())
+		return
+	}
+	var point spatial.Point
+	if err = msgpack.Unmarshal(buf, &point); err != nil || !reflect.DeepEqual(point, spatial.Point{5, 6}) {
+		t.Errorf("Unexpected decoded point: %v, %v", point, err)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	if _, err = conn.Eval(initLua, []interface{}{spaceName}); err != nil {
+		t.Errorf("Failed to create space: %s", err.Error())
+		return
+	}
+	points := []spatial.Point{{0, 0}, {1, 1}, {5, 5}, {10, 10}}
+	for i, p := range points {
+		if _, err = conn.Insert(spaceName, []interface{}{uint(i), p}); err != nil {
+			t.Errorf("Failed to insert: %s", err.Error())
+			return
+		}
+	}
+	idx := spatial.New(conn, spaceName, "spatial")
+
+	var nearest []place
+	if err = idx.Nearest(spatial.Point{4, 4}, 2, &nearest); err != nil {
+		t.Errorf("Failed to select nearest: %s", err.Error())
+		return
+	}
+	if len(nearest) != 2 || nearest[0].Id != 2 || nearest[1].Id != 1 {
+		t.Errorf("Unexpected nearest points: %v", nearest)
+	}
+
+	rect, _ := spatial.NewRect(spatial.Point{-1, -1}, spatial.Point{2, 2})
+	var within []place
+	if err = idx.Within(rect, 10, &within); err != nil {
+		t.Errorf("Failed to select within: %s", err.Error())
+		return
+	}
+	if len(within) != 2 {
+		t.Errorf("Unexpected points within rectangle: %v", within)
+	}
+}
@@ -0,0 +1,92 @@
+package tarantool
+
+import (
+	"sync"
+	"time"
+)
+
+// RequestStats is a statistics of completed requests of one kind.
+type RequestStats struct {
+	// Count is a number of completed requests.
+	Count uint64
+	// Errors is a number of requests completed with an error, both
+	// client and server ones.
+	Errors uint64
+	// Latency is a total time requests waited for response.
+	Latency time.Duration
+	// MaxLatency is the longest time a request waited for response.
+	MaxLatency time.Duration
+}
+
+// AvgLatency returns average time requests waited for response.
+func (s RequestStats) AvgLatency() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Latency / time.Duration(s.Count)
+}
+
+type requestTracker struct {
+	mutex    sync.Mutex
+	requests map[SizeKey]*RequestStats
+}
+
+func (t *requestTracker) add(fut *Future, latency time.Duration) {
+	if t == nil {
+		return
+	}
+	key := SizeKey{RequestCode: fut.requestCode, SpaceNo: fut.spaceNo}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.requests == nil {
+		t.requests = make(map[SizeKey]*RequestStats)
+	}
+	s := t.requests[key]
+	if s == nil {
+		s = &RequestStats{}
+		t.requests[key] = s
+	}
+	s.Count++
+	if fut.err != nil || (fut.resp != nil && fut.resp.Code != OkCode) {
+		s.Errors++
+	}
+	s.Latency += latency
+	if latency > s.MaxLatency {
+		s.MaxLatency = latency
+	}
+}
+
+func (t *requestTracker) stats() map[SizeKey]RequestStats {
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	requests := make(map[SizeKey]RequestStats, len(t.requests))
+	for key, s := range t.requests {
+		requests[key] = *s
+	}
+	return requests
+}
+
+// Stats is a statistics of connection.
+type Stats struct {
+	// Requests are numbers and latencies of completed requests by request
+	// code and space, they are tracked only if Opts.TrackRequests is set.
+	Requests map[SizeKey]RequestStats
+	// Sizes are sizes of requests and responses, they are tracked only
+	// if Opts.TrackSizes is set.
+	Sizes SizeStats
+	// Memory is a size of buffered requests and responses in bytes, it is
+	// tracked only if Opts.MaxMemory is set.
+	Memory int64
+}
+
+// Stats returns statistics of connection.
+func (conn *Connection) Stats() Stats {
+	return Stats{
+		Requests: conn.requests.stats(),
+		Sizes:    conn.sizes.stats(),
+		Memory:   conn.memory.usage(),
+	}
+}
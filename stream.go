@@ -0,0 +1,327 @@
+package tarantool
+
+import (
+	"bufio"
+	"io"
+	"sync"
+
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// streamHeaderPeek is a maximum size of response header which is decoded
+// before streaming the body. Responses with larger headers are buffered.
+const streamHeaderPeek = 256
+
+// streamReader reads streamed response body from connection. Reader
+// goroutine of connection waits until the body is closed or is not read
+// for Opts.Timeout and then skips its unread part.
+type streamReader struct {
+	r     *bufio.Reader
+	n     int
+	done  chan struct{}
+	once  sync.Once
+	mutex sync.Mutex
+	// err is returned by reads after the body is discarded
+	err error
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+	if s.n <= 0 {
+		return 0, io.EOF
+	}
+	if len(p) > s.n {
+		p = p[:s.n]
+	}
+	n, err := s.r.Read(p)
+	s.n -= n
+	return n, err
+}
+
+func (s *streamReader) ReadByte() (byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+	if s.n <= 0 {
+		return 0, io.EOF
+	}
+	b, err := s.r.ReadByte()
+	if err == nil {
+		s.n--
+	}
+	return b, err
+}
+
+func (s *streamReader) UnreadByte() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	err := s.r.UnreadByte()
+	if err == nil {
+		s.n++
+	}
+	return err
+}
+
+// remaining returns size of unread part of body.
+func (s *streamReader) remaining() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.n
+}
+
+// discard skips unread part of body, further reads fail with err.
+func (s *streamReader) discard(err error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.err = err
+	_, derr := s.r.Discard(s.n)
+	s.n = 0
+	return derr
+}
+
+func (s *streamReader) close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// readStream passes body of response to streaming request to its future
+// and waits until it is read. If body is not read for Opts.Timeout, e.g.
+// stream is dropped without Close, it is discarded and the stream fails.
+// It reports false if response should be read as usual.
+func (conn *Connection) readStream(r *bufio.Reader, length int) (bool, error) {
+	resp, hlen, ok := conn.peekHeader(r, length)
+	if !ok {
+		return false, nil
+	}
+	fut := conn.fetchStreamFuture(resp.RequestId)
+	if fut == nil {
+		return false, nil
+	}
+	// header is buffered by Peek, so Discard never fails
+	r.Discard(hlen)
+	body := &streamReader{r: r, n: length - hlen, done: make(chan struct{})}
+	conn.sizes.add(TrafficIn, fut, length, conn.opts.Clock.Now())
+	fut.resp = resp
+	fut.body = body
+	fut.markReady(conn)
+
+	if conn.opts.Timeout <= 0 {
+		select {
+		case <-body.done:
+		case <-conn.control:
+			return true, ClientError{ErrConnectionClosed, "using closed connection"}
+		}
+		return true, body.discard(nil)
+	}
+
+	timer := conn.opts.Clock.NewTimer(conn.opts.Timeout)
+	defer timer.Stop()
+	for progress := body.remaining(); ; {
+		select {
+		case <-body.done:
+			return true, body.discard(nil)
+		case <-conn.control:
+			return true, ClientError{ErrConnectionClosed, "using closed connection"}
+		case <-timer.C():
+			if n := body.remaining(); n != progress {
+				progress = n
+				timer.Reset(conn.opts.Timeout)
+				continue
+			}
+			return true, body.discard(ClientError{ErrTimeouted, "stream is not read in time"})
+		}
+	}
+}
+
+// peekHeader decodes header of response without reading it. It returns
+// response with empty body and length of header.
+func (conn *Connection) peekHeader(r *bufio.Reader, length int) (*Response, int, bool) {
+	n := length
+	if n > streamHeaderPeek {
+		n = streamHeaderPeek
+	}
+	head, err := r.Peek(n)
+	if err != nil {
+		return nil, 0, false
+	}
+	resp := &Response{buf: smallBuf{b: head}}
+	if resp.decodeHeader(conn.dec) != nil {
+		return nil, 0, false
+	}
+	hlen := resp.buf.p
+	resp.buf = smallBuf{}
+	return resp, hlen, true
+}
+
+func (conn *Connection) fetchStreamFuture(reqid uint32) (fut *Future) {
+	bucket := conn.futureBucket(reqid)
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+	for fut = bucket.first; fut != nil; fut = fut.next {
+		if fut.requestId == reqid {
+			if !fut.stream {
+				return nil
+			}
+			return bucket.fetch(reqid)
+		}
+	}
+	return nil
+}
+
+// TupleStream decodes tuples of response one by one. If response is
+// larger than Opts.StreamThreshold, tuples are decoded directly from the
+// socket, and other responses of the connection are not read until the
+// stream is read to the end or closed. So the stream must be closed, and
+// other requests of the connection should not be waited for while
+// reading it. A stream that is not read for Opts.Timeout is discarded
+// and fails with ErrTimeouted.
+type TupleStream struct {
+	fut     *Future
+	d       *msgpack.Decoder
+	started bool
+	closed  bool
+	keys    int
+	tuples  int
+	errMsg  string
+	err     error
+}
+
+// SelectStream performs select to box space and returns stream of
+// selected tuples.
+func (conn *Connection) SelectStream(space, index interface{}, offset, limit, iterator uint32, key interface{}) *TupleStream {
+	ref, err := conn.selectRef(space, index, iterator)
+	future := conn.newFuture(SelectRequest, ref.spaceNo)
+	if err != nil {
+		return &TupleStream{fut: future.fail(conn, err)}
+	}
+	future.stream = true
+	return &TupleStream{fut: future.send(conn, selectRefBody(ref, offset, limit, iterator, key))}
+}
+
+// Call17Stream calls registered tarantool function and returns stream of
+// values it returned.
+func (conn *Connection) Call17Stream(functionName string, args interface{}) *TupleStream {
+	requestCode, body := conn.call17Body(functionName, args)
+	future := conn.newFutureFor(requestCode, 0, functionName)
+	future.stream = true
+	return &TupleStream{fut: future.send(conn, body)}
+}
+
+// Next decodes the next tuple into result. It returns false when there
+// are no more tuples or an error happened, see Err.
+func (s *TupleStream) Next(result interface{}) bool {
+	if s.err != nil || s.closed {
+		return false
+	}
+	if !s.started {
+		s.started = true
+		if !s.start() {
+			return false
+		}
+	}
+	if s.tuples == 0 {
+		s.finish()
+		return false
+	}
+	s.tuples--
+	if err := s.d.Decode(result); err != nil {
+		s.err = err
+		s.Close()
+		return false
+	}
+	return true
+}
+
+// Err returns error of request or of decoding.
+func (s *TupleStream) Err() error {
+	return s.err
+}
+
+// Close releases the stream, the rest of tuples are skipped. It waits for
+// response if it was not received yet.
+func (s *TupleStream) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.fut.wait()
+	if s.fut.body != nil {
+		s.fut.body.close()
+	}
+	return nil
+}
+
+func (s *TupleStream) start() bool {
+	fut := s.fut
+	fut.wait()
+	if fut.err != nil {
+		s.err = fut.err
+		s.Close()
+		return false
+	}
+	if fut.body != nil {
+		s.d = msgpack.NewDecoder(fut.body)
+	} else {
+		if fut.resp.buf.Len() == 0 {
+			s.finish()
+			return false
+		}
+		s.d = msgpack.NewDecoder(&fut.resp.buf)
+	}
+	var err error
+	if s.keys, err = s.d.DecodeMapLen(); err != nil {
+		s.err = err
+		s.Close()
+		return false
+	}
+	for s.keys > 0 {
+		s.keys--
+		cd, err := s.d.DecodeInt()
+		if err == nil {
+			if cd == KeyData {
+				if s.tuples, err = s.d.DecodeSliceLen(); err == nil {
+					return true
+				}
+			} else {
+				err = s.skipKey(cd)
+			}
+		}
+		if err != nil {
+			s.err = err
+			s.Close()
+			return false
+		}
+	}
+	s.finish()
+	return false
+}
+
+func (s *TupleStream) skipKey(cd int) (err error) {
+	if cd == KeyError {
+		s.errMsg, err = s.d.DecodeString()
+		return
+	}
+	return s.d.Skip()
+}
+
+// finish reads keys after tuples and closes the stream.
+func (s *TupleStream) finish() {
+	for ; s.keys > 0 && s.err == nil; s.keys-- {
+		var cd int
+		if cd, s.err = s.d.DecodeInt(); s.err == nil {
+			s.err = s.skipKey(cd)
+		}
+	}
+	if resp := s.fut.resp; s.err == nil && resp.Code != OkCode {
+		s.err = Error{resp.Code &^ ErrorCodeBit, s.errMsg}
+	}
+	s.Close()
+}
@@ -0,0 +1,196 @@
+// Package tail delivers tuples inserted into a space to a Go channel.
+//
+// Tailer polls the space by an index with increasing keys (e.g. primary key
+// filled by a sequence), so it is suitable for append-only spaces used as
+// lightweight event queues. Delivery is at-least-once: a key of the last
+// acknowledged tuple is saved by Checkpointer, and tuples after it are
+// delivered again after restart.
+package tail
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/kvstore"
+)
+
+// Checkpointer stores key of the last acknowledged tuple.
+type Checkpointer interface {
+	// Load returns saved key, nil key means that nothing is saved.
+	Load() ([]interface{}, error)
+	// Save saves key.
+	Save(key []interface{}) error
+}
+
+// MemoryCheckpoint keeps checkpoint in memory, so tuples are delivered
+// from the beginning of space after process restart.
+type MemoryCheckpoint struct {
+	mutex sync.Mutex
+	key   []interface{}
+}
+
+// Load returns saved key.
+func (c *MemoryCheckpoint) Load() ([]interface{}, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.key, nil
+}
+
+// Save saves key.
+func (c *MemoryCheckpoint) Save(key []interface{}) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.key = key
+	return nil
+}
+
+// StoreCheckpoint keeps checkpoint in kvstore.Store under the name.
+type StoreCheckpoint struct {
+	Store *kvstore.Store
+	Name  string
+}
+
+// Load returns saved key.
+func (c StoreCheckpoint) Load() ([]interface{}, error) {
+	var key []interface{}
+	if _, err := c.Store.Get(c.Name, &key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Save saves key.
+func (c StoreCheckpoint) Save(key []interface{}) error {
+	return c.Store.Set(c.Name, key, 0)
+}
+
+// Opts is a configuration of Tailer.
+type Opts struct {
+	// Index is a name of index with increasing keys, primary index is used
+	// by default.
+	Index string
+	// BatchSize is a limit of tuples selected by one request, 100 by default.
+	BatchSize uint32
+	// PollInterval is a pause between requests when there are no new
+	// tuples, 100ms by default.
+	PollInterval time.Duration
+	// Checkpoint stores position of tailer. By default, MemoryCheckpoint is
+	// used.
+	Checkpoint Checkpointer
+	// OnError is called on request errors, requests are retried after
+	// PollInterval.
+	OnError func(err error)
+}
+
+// Event is a tuple inserted into space.
+type Event struct {
+	Tuple []interface{}
+	// Key is a key of tuple in tailed index.
+	Key []interface{}
+	t   *Tailer
+}
+
+// Ack saves key of event as a checkpoint. Events should be acknowledged in
+// order of delivery.
+func (e Event) Ack() error {
+	return e.t.opts.Checkpoint.Save(e.Key)
+}
+
+// Tailer delivers tuples of a space.
+type Tailer struct {
+	conn   *tarantool.Connection
+	space  *tarantool.Space
+	fields []uint32
+	opts   Opts
+}
+
+// New creates Tailer of the space. Space and index should be present in
+// loaded schema of connection.
+func New(conn *tarantool.Connection, space string, opts Opts) (*Tailer, error) {
+	if conn.Schema == nil {
+		return nil, fmt.Errorf("Schema is not loaded")
+	}
+	s, ok := conn.Schema.Spaces[space]
+	if !ok {
+		return nil, fmt.Errorf("there is no space with name %s", space)
+	}
+	index := s.IndexesById[0]
+	if opts.Index != "" {
+		index = s.Indexes[opts.Index]
+	}
+	if index == nil {
+		return nil, fmt.Errorf("space %s has not index with name %s", space, opts.Index)
+	}
+	if opts.BatchSize == 0 {
+		opts.BatchSize = 100
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 100 * time.Millisecond
+	}
+	if opts.Checkpoint == nil {
+		opts.Checkpoint = new(MemoryCheckpoint)
+	}
+	opts.Index = index.Name
+	t := &Tailer{conn: conn, space: s, opts: opts}
+	for _, f := range index.Fields {
+		t.fields = append(t.fields, f.Id)
+	}
+	return t, nil
+}
+
+func (t *Tailer) key(tuple []interface{}) []interface{} {
+	key := make([]interface{}, len(t.fields))
+	for i, f := range t.fields {
+		if int(f) < len(tuple) {
+			key[i] = tuple[f]
+		}
+	}
+	return key
+}
+
+// Run delivers tuples to out starting after the checkpoint until ctx is
+// done.
+func (t *Tailer) Run(ctx context.Context, out chan<- Event) error {
+	last, err := t.opts.Checkpoint.Load()
+	if err != nil {
+		return err
+	}
+	for {
+		iter, key := uint32(tarantool.IterGt), interface{}(last)
+		if last == nil {
+			iter, key = tarantool.IterAll, []interface{}{}
+		}
+		resp, err := t.conn.Select(t.space.Id, t.opts.Index, 0, t.opts.BatchSize, iter, key)
+		if err != nil && t.opts.OnError != nil {
+			t.opts.OnError(err)
+		}
+		if err == nil {
+			for _, row := range resp.Data {
+				tuple, ok := row.([]interface{})
+				if !ok {
+					continue
+				}
+				ev := Event{Tuple: tuple, Key: t.key(tuple), t: t}
+				select {
+				case out <- ev:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				last = ev.Key
+			}
+			if uint32(len(resp.Data)) == t.opts.BatchSize {
+				continue
+			}
+		}
+		timer := time.NewTimer(t.opts.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
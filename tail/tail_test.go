@@ -0,0 +1,68 @@
+package tail_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/tail"
+)
+
+var server = "127.0.0.1:3013"
+var opts = Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+func TestTailer(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	checkpoint := new(tail.MemoryCheckpoint)
+	checkpoint.Save([]interface{}{uint64(3000)})
+	tailer, err := tail.New(conn, "test", tail.Opts{
+		BatchSize:    1,
+		PollInterval: 10 * time.Millisecond,
+		Checkpoint:   checkpoint,
+	})
+	if err != nil {
+		t.Errorf("Failed to create tailer: %s", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan tail.Event)
+	go tailer.Run(ctx, events)
+
+	for _, id := range []uint{3001, 3002} {
+		defer conn.Delete("test", "primary", []interface{}{id})
+		if _, err = conn.Replace("test", []interface{}{id, "tail"}); err != nil {
+			t.Errorf("Failed to replace: %s", err.Error())
+			return
+		}
+	}
+	for _, id := range []uint64{3001, 3002} {
+		select {
+		case ev := <-events:
+			if ev.Tuple[0] != id || ev.Key[0] != id {
+				t.Errorf("Unexpected event: %v", ev.Tuple)
+			}
+			if err = ev.Ack(); err != nil {
+				t.Errorf("Failed to ack: %s", err.Error())
+			}
+		case <-time.After(time.Second):
+			t.Errorf("Tuple %d is not delivered", id)
+			return
+		}
+	}
+	if key, _ := checkpoint.Load(); len(key) != 1 || key[0] != uint64(3002) {
+		t.Errorf("Unexpected checkpoint: %v", key)
+	}
+}
@@ -1,7 +1,16 @@
 package tarantool_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
 	"strings"
 	"sync"
 	"testing"
@@ -382,6 +391,60 @@ func BenchmarkClientParallelMassiveUntyped(b *testing.B) {
 	close(limit)
 }
 
+func benchmarkClientFutureInFlight(b *testing.B, concurrency uint32) {
+	const inFlight = 100 * 1024
+
+	connOpts := opts
+	connOpts.Timeout = 30 * time.Second
+	connOpts.Concurrency = concurrency
+	conn, err := Connect(server, connOpts)
+	if err != nil {
+		b.Errorf("No connection available")
+		return
+	}
+	defer conn.Close()
+
+	_, err = conn.Replace(spaceNo, []interface{}{uint(1111), "hello", "world"})
+	if err != nil {
+		b.Errorf("No connection available")
+	}
+
+	fs := make([]*Future, inFlight)
+	b.ResetTimer()
+	for i := 0; i < b.N; i += inFlight {
+		var wg sync.WaitGroup
+		for g := 0; g < 64; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for j := g; j < inFlight; j += 64 {
+					fs[j] = conn.SelectAsync(spaceNo, indexNo, 0, 1, IterEq, IntKey{1111})
+				}
+			}(g)
+		}
+		wg.Wait()
+		for j := 0; j < inFlight; j++ {
+			if err = fs[j].Err(); err != nil {
+				b.Error(err)
+			}
+		}
+	}
+}
+
+// BenchmarkClientFutureInFlight shows how sharding of futures registry
+// (see Opts.Concurrency) affects connection with 100k+ requests in flight.
+func BenchmarkClientFutureInFlight(b *testing.B) {
+	b.Run("Concurrency=1", func(b *testing.B) {
+		benchmarkClientFutureInFlight(b, 1)
+	})
+	b.Run("Concurrency=default", func(b *testing.B) {
+		benchmarkClientFutureInFlight(b, 0)
+	})
+	b.Run("Concurrency=256", func(b *testing.B) {
+		benchmarkClientFutureInFlight(b, 256)
+	})
+}
+
 ///////////////////
 
 func TestClient(t *testing.T) {
@@ -1005,3 +1068,1983 @@ func TestComplexStructs(t *testing.T) {
 		return
 	}
 }
+
+func TestConnectionReconnect(t *testing.T) {
+	var err error
+	var conn *Connection
+
+	conn, err = Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer conn.Close()
+
+	if state := conn.State(); state != StateConnected {
+		t.Errorf("Unexpected state after Connect: %s", state)
+	}
+	localAddr := conn.LocalAddr()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = conn.Reconnect(ctx); err != nil {
+		t.Errorf("Failed to Reconnect: %s", err.Error())
+		return
+	}
+	if state := conn.State(); state != StateConnected {
+		t.Errorf("Unexpected state after Reconnect: %s", state)
+	}
+	if conn.LocalAddr() == localAddr {
+		t.Errorf("Socket is not reestablished after Reconnect")
+	}
+	if _, err = conn.Ping(); err != nil {
+		t.Errorf("Failed to Ping after Reconnect: %s", err.Error())
+	}
+
+	conn.Close()
+	if state := conn.State(); state != StateClosed {
+		t.Errorf("Unexpected state after Close: %s", state)
+	}
+	if err = conn.Reconnect(ctx); err == nil {
+		t.Errorf("Reconnect of closed connection succeeded")
+	}
+}
+
+func TestConnectionPendingStats(t *testing.T) {
+	var err error
+	var conn *Connection
+
+	conn, err = Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer conn.Close()
+
+	stats := conn.PendingStats()
+	if stats.Count != 0 || stats.Oldest != nil {
+		t.Errorf("Unexpected pending requests on idle connection: %d", stats.Count)
+	}
+
+	fut := conn.EvalAsync("require('fiber').sleep(0.2)", []interface{}{})
+	conn.SelectAsync(spaceNo, indexNo, 0, 1, IterEq, []interface{}{uint(1)})
+	stats = conn.PendingStats()
+	if stats.ByCode[EvalRequest] != 1 {
+		t.Errorf("Eval request is not pending: %v", stats.ByCode)
+	}
+	if stats.Oldest == nil || stats.Oldest.RequestCode != EvalRequest {
+		t.Errorf("Eval request is not the oldest one: %v", stats.Oldest)
+	}
+	if _, err = fut.Get(); err != nil {
+		t.Errorf("Failed to Eval: %s", err.Error())
+	}
+	if stats = conn.PendingStats(); stats.Count != 0 {
+		t.Errorf("Unexpected pending requests after responses: %d", stats.Count)
+	}
+}
+
+func TestConnectionFlush(t *testing.T) {
+	var err error
+	var conn *Connection
+
+	connOpts := opts
+	connOpts.FlushInterval = time.Hour
+	conn, err = Connect(server, connOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer conn.Close()
+
+	// Request would be timeouted without explicit flush.
+	fut := conn.SelectAsync(spaceNo, indexNo, 0, 1, IterEq, []interface{}{uint(1)})
+	conn.Flush()
+	if _, err = fut.Get(); err != nil {
+		t.Errorf("Failed to Select: %s", err.Error())
+	}
+
+	connOpts = opts
+	connOpts.FlushInterval = 50 * time.Millisecond
+	conn2, err := Connect(server, connOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn2.Close()
+	if _, err = conn2.Select(spaceNo, indexNo, 0, 1, IterEq, []interface{}{uint(1)}); err != nil {
+		t.Errorf("Failed to Select with FlushInterval: %s", err.Error())
+	}
+}
+
+func TestConnGroup(t *testing.T) {
+	group, err := ConnectGroup(server, 3, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if group == nil {
+		t.Errorf("group is nil after ConnectGroup")
+		return
+	}
+	defer group.Close()
+
+	addrs := make(map[string]bool)
+	for _, conn := range group.Conns() {
+		addrs[conn.LocalAddr()] = true
+		if conn.Schema == nil {
+			t.Errorf("Schema is not shared between connections of group")
+		}
+	}
+	if len(addrs) != 3 {
+		t.Errorf("Expected 3 sockets, got %d", len(addrs))
+	}
+
+	for i := 0; i < 6; i++ {
+		if _, err = group.Select(spaceName, indexName, 0, 1, IterEq, []interface{}{uint(1)}); err != nil {
+			t.Errorf("Failed to Select: %s", err.Error())
+		}
+	}
+
+	group.Close()
+	if group.ConnectedNow() {
+		t.Errorf("Group is connected after Close")
+	}
+}
+
+func TestDedup(t *testing.T) {
+	var err error
+	var conn *Connection
+
+	conn, err = Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer conn.Close()
+
+	_, err = conn.Replace(spaceNo, []interface{}{uint(1111), "hello", "world"})
+	if err != nil {
+		t.Errorf("Failed to Replace: %s", err.Error())
+	}
+
+	dedup := NewDedup(conn)
+	var fs [10]*Future
+	for i := range fs {
+		fs[i] = dedup.SelectAsync(spaceNo, indexNo, 0, 1, IterEq, []interface{}{uint(1111)})
+	}
+	if stats := conn.PendingStats(); stats.ByCode[SelectRequest] > 1 {
+		t.Errorf("Identical selects are not deduplicated: %d", stats.ByCode[SelectRequest])
+	}
+	for i := range fs {
+		var tpl []Tuple
+		if i%2 == 0 {
+			err = fs[i].GetTyped(&tpl)
+		} else {
+			var resp *Response
+			if resp, err = fs[i].Get(); err == nil && len(resp.Data) == 1 {
+				tpl = append(tpl, Tuple{Id: 1111})
+			}
+		}
+		if err != nil {
+			t.Errorf("Failed to Select: %s", err.Error())
+		}
+		if len(tpl) != 1 || tpl[0].Id != 1111 {
+			t.Errorf("Unexpected result of deduplicated Select: %v", tpl)
+		}
+	}
+
+	var res []interface{}
+	if err = dedup.Call17Typed("simple_incr", []interface{}{1}, &res); err != nil {
+		t.Errorf("Failed to Call17: %s", err.Error())
+	} else if len(res) != 1 {
+		t.Errorf("Unexpected result of deduplicated Call17: %v", res)
+	}
+}
+
+func TestCache(t *testing.T) {
+	var err error
+	var conn *Connection
+
+	conn, err = Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer conn.Close()
+
+	cache := NewCache(conn, CacheOpts{TTL: time.Minute, MaxEntries: 2})
+	_, err = cache.Replace(spaceNo, []interface{}{uint(1111), "hello", "world"})
+	if err != nil {
+		t.Errorf("Failed to Replace: %s", err.Error())
+	}
+
+	var tpl []Tuple
+	for i := 0; i < 2; i++ {
+		err = cache.SelectTyped(spaceNo, indexNo, 0, 1, IterEq, []interface{}{uint(1111)}, &tpl)
+		if err != nil {
+			t.Errorf("Failed to SelectTyped: %s", err.Error())
+		}
+		if len(tpl) != 1 || tpl[0].Msg != "hello" {
+			t.Errorf("Unexpected result of cached Select: %v", tpl)
+		}
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Select response is not cached: %d", cache.Len())
+	}
+
+	_, err = cache.Replace(spaceNo, []interface{}{uint(1111), "bye", "world"})
+	if err != nil {
+		t.Errorf("Failed to Replace: %s", err.Error())
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Cache is not invalidated by write: %d", cache.Len())
+	}
+	err = cache.SelectTyped(spaceNo, indexNo, 0, 1, IterEq, []interface{}{uint(1111)}, &tpl)
+	if err != nil {
+		t.Errorf("Failed to SelectTyped: %s", err.Error())
+	}
+	if len(tpl) != 1 || tpl[0].Msg != "bye" {
+		t.Errorf("Stale result of cached Select: %v", tpl)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err = cache.Call17("simple_incr", []interface{}{i}); err != nil {
+			t.Errorf("Failed to Call17: %s", err.Error())
+		}
+	}
+	if cache.Len() != 2 {
+		t.Errorf("MaxEntries is not respected: %d", cache.Len())
+	}
+	cache.InvalidateCall("simple_incr")
+	if cache.Len() != 0 {
+		t.Errorf("Calls are not invalidated: %d", cache.Len())
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	clockOpts := opts
+	clockOpts.Clock = clock
+	conn, err := Connect(server, clockOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	cache := NewCache(conn, CacheOpts{TTL: time.Minute})
+	random := func() interface{} {
+		resp, err := cache.Call17("math.random", []interface{}{})
+		if err != nil || len(resp.Data) != 1 {
+			t.Errorf("Failed to Call17: %v", err)
+			return nil
+		}
+		return resp.Data[0]
+	}
+	first := random()
+	clock.Advance(30 * time.Second)
+	if v := random(); v != first {
+		t.Errorf("Response is expired before TTL: %v, %v", v, first)
+	}
+	clock.Advance(time.Minute)
+	if v := random(); v == first {
+		t.Errorf("Response is not expired after TTL of clock: %v", v)
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	var err error
+	var conn *Connection
+
+	connOpts := opts
+	connOpts.RateLimits = &RateLimits{
+		Spaces:    map[string]Rate{spaceName: {Limit: 1, Burst: 2}},
+		Functions: map[string]Rate{"simple_incr": {Limit: 0.001}},
+	}
+	conn, err = Connect(server, connOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer conn.Close()
+
+	for i := 0; i < 2; i++ {
+		if _, err = conn.Select(spaceName, indexName, 0, 1, IterEq, []interface{}{uint(1)}); err != nil {
+			t.Errorf("Failed to Select within burst: %s", err.Error())
+		}
+	}
+	_, err = conn.Select(spaceName, indexName, 0, 1, IterEq, []interface{}{uint(1)})
+	if cliErr, ok := err.(ClientError); !ok || cliErr.Code != ErrRateLimited {
+		t.Errorf("Expected ErrRateLimited for space but got: %v", err)
+	}
+	if _, err = conn.Select("schematest", "primary", 0, 1, IterEq, []interface{}{uint(1)}); err != nil {
+		t.Errorf("Failed to Select from not limited space: %s", err.Error())
+	}
+
+	if _, err = conn.Call17("simple_incr", []interface{}{1}); err != nil {
+		t.Errorf("Failed to Call17: %s", err.Error())
+	}
+	_, err = conn.Call17("simple_incr", []interface{}{1})
+	if cliErr, ok := err.(ClientError); !ok || cliErr.Code != ErrRateLimited {
+		t.Errorf("Expected ErrRateLimited for function but got: %v", err)
+	}
+	if _, err = conn.Ping(); err != nil {
+		t.Errorf("Ping should not be rate limited: %s", err.Error())
+	}
+}
+
+func TestRateLimitsRefund(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	connOpts := opts
+	connOpts.Clock = clock
+	connOpts.RateLimits = &RateLimits{
+		Total:     Rate{Limit: 0.001, Burst: 1},
+		Functions: map[string]Rate{"simple_incr": {Limit: 0.0001, Burst: 2}},
+	}
+	conn, err := Connect(server, connOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	if _, err = conn.Call17("simple_incr", []interface{}{1}); err != nil {
+		t.Errorf("Failed to Call17: %s", err.Error())
+	}
+	_, err = conn.Call17("simple_incr", []interface{}{1})
+	if cliErr, ok := err.(ClientError); !ok || cliErr.Code != ErrRateLimited {
+		t.Errorf("Expected ErrRateLimited for connection but got: %v", err)
+	}
+	// total limit is restored, token of function is refunded on rejection
+	clock.Advance(1000 * time.Second)
+	if _, err = conn.Call17("simple_incr", []interface{}{1}); err != nil {
+		t.Errorf("Function token is not refunded: %s", err.Error())
+	}
+}
+
+func TestRateLimitsClosed(t *testing.T) {
+	connOpts := opts
+	connOpts.RateLimits = &RateLimits{
+		Total: Rate{Limit: 0.001, Burst: 1},
+	}
+	conn, err := Connect(server, connOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	conn.Close()
+
+	// requests to closed connection do not take tokens
+	for i := 0; i < 2; i++ {
+		_, err = conn.Call17("simple_incr", []interface{}{1})
+		if cliErr, ok := err.(ClientError); !ok || cliErr.Code != ErrConnectionClosed {
+			t.Errorf("Expected ErrConnectionClosed but got: %v", err)
+		}
+	}
+}
+
+func TestProtocolInfo(t *testing.T) {
+	var err error
+	var conn *Connection
+
+	conn, err = Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer conn.Close()
+
+	info := conn.ProtocolInfo()
+	if GreetingVersionAtLeast(conn.Greeting.Version, 2, 10, 0) {
+		if info.Version == 0 || !info.Has(WatchersFeature) {
+			t.Errorf("Unexpected protocol info: %+v", info)
+		}
+		if err = conn.RequireFeatures(WatchersFeature); err != nil {
+			t.Errorf("Unexpected error for supported feature: %s", err.Error())
+		}
+	} else if info.Version != 0 || len(info.Features) != 0 {
+		t.Errorf("Unexpected protocol info for old Tarantool: %+v", info)
+	}
+
+	err = conn.RequireFeatures(ProtocolFeature(1000))
+	if cliErr, ok := err.(ClientError); !ok || cliErr.Code != ErrFeatureUnsupported {
+		t.Errorf("Expected ErrFeatureUnsupported but got: %v", err)
+	}
+}
+
+type fakeTimer struct {
+	clock    *fakeClock
+	c        chan time.Time
+	deadline time.Time
+	active   bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	active := t.active
+	t.active = false
+	return active
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	active := t.active
+	t.deadline, t.active = t.clock.now.Add(d), true
+	return active
+}
+
+// fakeClock moves forward only by Advance calls.
+type fakeClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1), deadline: c.now.Add(d), active: true}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		if t.active && !t.deadline.After(c.now) {
+			t.active = false
+			select {
+			case t.c <- c.now:
+			default:
+			}
+		}
+	}
+}
+
+func TestClientClock(t *testing.T) {
+	var err error
+	var conn *Connection
+
+	clock := &fakeClock{now: time.Now()}
+	clockOpts := opts
+	clockOpts.Clock = clock
+	conn, err = Connect(server, clockOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer conn.Close()
+
+	// Socket deadlines use real time, so request should be shorter than
+	// opts.Timeout.
+	fut := conn.EvalAsync("require('fiber').sleep(...)", []interface{}{0.3})
+	select {
+	case <-fut.WaitChan():
+		t.Errorf("Request is finished before timeout")
+		return
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	clock.Advance(opts.Timeout * 2)
+	select {
+	case <-fut.WaitChan():
+	case <-time.After(time.Second):
+		t.Errorf("Request is not timed out after clock is advanced")
+		return
+	}
+	_, err = fut.Get()
+	if cliErr, ok := err.(ClientError); !ok || cliErr.Code != ErrTimeouted {
+		t.Errorf("Expected ErrTimeouted but got: %v", err)
+	}
+}
+
+func TestClientSyncGenerator(t *testing.T) {
+	var err error
+	var conn *Connection
+
+	var mutex sync.Mutex
+	next := uint32(1000)
+	syncOpts := opts
+	syncOpts.SyncGenerator = SyncGeneratorFunc(func() uint32 {
+		mutex.Lock()
+		defer mutex.Unlock()
+		next += 2
+		return next
+	})
+	conn, err = Connect(server, syncOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer conn.Close()
+
+	fut := conn.EvalAsync("return 1", []interface{}{})
+	resp, err := fut.Get()
+	if err != nil {
+		t.Errorf("Failed to Eval: %s", err.Error())
+		return
+	}
+	if fut.Sync() <= 1000 || fut.Sync()%2 != 0 {
+		t.Errorf("Unexpected sync %d", fut.Sync())
+	}
+	if resp.RequestId != fut.Sync() {
+		t.Errorf("Response sync %d doesn't match request sync %d", resp.RequestId, fut.Sync())
+	}
+}
+
+type traceKey struct{}
+
+func TestClientContextValues(t *testing.T) {
+	var err error
+	var conn *Connection
+
+	ctxOpts := opts
+	ctxOpts.ContextValues = []ContextValue{{Key: traceKey{}, Name: "trace_id"}}
+	conn, err = Connect(server, ctxOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer conn.Close()
+
+	const expr = "local a, ctx = ... return a, ctx and ctx.trace_id"
+	ctx := context.WithValue(context.Background(), traceKey{}, "abc")
+	resp, err := conn.EvalContext(ctx, expr, []interface{}{1})
+	if err != nil {
+		t.Errorf("Failed to EvalContext: %s", err.Error())
+		return
+	}
+	if len(resp.Data) != 2 || resp.Data[1] != "abc" {
+		t.Errorf("Unexpected response: %v", resp.Data)
+	}
+
+	// Arguments are kept as is without context values.
+	resp, err = conn.EvalContext(context.Background(), expr, []interface{}{1})
+	if err != nil {
+		t.Errorf("Failed to EvalContext: %s", err.Error())
+		return
+	}
+	if len(resp.Data) != 2 || resp.Data[1] != nil {
+		t.Errorf("Unexpected response without context values: %v", resp.Data)
+	}
+
+	if _, err = conn.Call17Context(ctx, "simple_incr", 1); err == nil {
+		t.Errorf("Expected error for non-slice args")
+	}
+}
+
+func TestClientSelectMaps(t *testing.T) {
+	var err error
+	var conn *Connection
+
+	conn, err = Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer conn.Close()
+
+	// _vspace has format: id, owner, name, ...
+	maps, err := conn.SelectMaps("_vspace", "primary", 0, 1, IterEq, []interface{}{uint(spaceNo)})
+	if err != nil {
+		t.Errorf("Failed to SelectMaps: %s", err.Error())
+		return
+	}
+	if len(maps) != 1 || maps[0]["id"] != uint64(spaceNo) || maps[0]["name"] != spaceName {
+		t.Errorf("Unexpected maps: %v", maps)
+	}
+
+	m, err := conn.GetMap("_vspace", "primary", []interface{}{uint(1000000)})
+	if err != nil || m != nil {
+		t.Errorf("Unexpected result for missing tuple: %v, %v", m, err)
+	}
+
+	space := &Space{FieldsById: map[uint32]*Field{0: {Id: 0, Name: "id"}}}
+	m = space.TupleMap([]interface{}{1, "a"})
+	if len(m) != 2 || m["id"] != 1 || m["2"] != "a" {
+		t.Errorf("Unexpected TupleMap: %v", m)
+	}
+}
+
+func TestResponseJSON(t *testing.T) {
+	space := &Space{FieldsById: map[uint32]*Field{
+		0: {Id: 0, Name: "id"},
+		1: {Id: 1, Name: "meta"},
+	}}
+	when := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	resp := &Response{Data: []interface{}{
+		[]interface{}{uint64(1), map[interface{}]interface{}{"a": int64(1)}, when},
+	}}
+
+	b, err := resp.JSON(space, JSONOpts{})
+	if err != nil {
+		t.Errorf("Failed to render JSON: %s", err.Error())
+	} else if s := string(b); s != `[{"3":"2020-01-02T03:04:05Z","id":1,"meta":{"a":1}}]` {
+		t.Errorf("Unexpected JSON: %s", s)
+	}
+
+	b, err = resp.JSON(nil, JSONOpts{
+		TimeFormat: "2006-01-02",
+		Format: func(v interface{}) (interface{}, bool) {
+			if u, ok := v.(uint64); ok {
+				return fmt.Sprintf("#%d", u), true
+			}
+			return nil, false
+		},
+	})
+	if err != nil {
+		t.Errorf("Failed to render JSON: %s", err.Error())
+	} else if s := string(b); s != `[["#1",{"a":1},"2020-01-02"]]` {
+		t.Errorf("Unexpected JSON: %s", s)
+	}
+}
+
+func TestClientSessionInfo(t *testing.T) {
+	var err error
+	var conn *Connection
+
+	conn, err = Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	defer conn.Close()
+
+	info := conn.SessionInfo()
+	if info.Id == 0 || info.User != "test" || info.Peer == "" {
+		t.Errorf("Unexpected session info: %+v", info)
+	}
+
+	resp, err := conn.Eval("return box.session.id()", []interface{}{})
+	if err != nil {
+		t.Errorf("Failed to Eval: %s", err.Error())
+	} else if len(resp.Data) != 1 || resp.Data[0] != info.Id {
+		t.Errorf("Session id %d doesn't match %v", info.Id, resp.Data)
+	}
+
+	if _, err = conn.CallAs("test", "box.session.user() os.exit", []interface{}{}); err == nil {
+		t.Errorf("CallAs accepted invalid function name")
+	}
+}
+
+type lockedBuffer struct {
+	mutex sync.Mutex
+	buf   []byte
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+func (b *lockedBuffer) Bytes() []byte {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]byte(nil), b.buf...)
+}
+
+func TestClientTrafficDump(t *testing.T) {
+	var err error
+	var conn *Connection
+
+	dump := new(lockedBuffer)
+	dumpOpts := opts
+	dumpOpts.TrafficDump = dump
+	dumpOpts.SkipSchema = true
+	conn, err = Connect(server, dumpOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	if conn == nil {
+		t.Errorf("conn is nil after Connect")
+		return
+	}
+	if _, err = conn.Ping(); err != nil {
+		t.Errorf("Failed to Ping: %s", err.Error())
+	}
+	conn.Close()
+
+	r := bytes.NewReader(dump.Bytes())
+	var dirs []TrafficDirection
+	for {
+		rec, err := ReadTrafficRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Errorf("Failed to read record: %s", err.Error())
+			return
+		}
+		packets, err := rec.Packets()
+		if err != nil || len(packets) != 1 {
+			t.Errorf("Unexpected packets: %v, %v", packets, err)
+		}
+		dirs = append(dirs, rec.Direction)
+	}
+	if len(dirs) != 2 || dirs[0] != TrafficOut || dirs[1] != TrafficIn {
+		t.Errorf("Unexpected records: %q", dirs)
+	}
+}
+
+// nopRequest is IPROTO_NOP, it is not supported by Connection methods.
+type nopRequest struct{}
+
+const nopRequestCode = 12
+
+func (nopRequest) Code() int32 {
+	return nopRequestCode
+}
+
+func (nopRequest) Body(enc *msgpack.Encoder) error {
+	return enc.EncodeMapLen(0)
+}
+
+func TestClientCustomRequest(t *testing.T) {
+	if err := RegisterRequestType(SelectRequest, RequestType{Name: "SELECT"}); err == nil {
+		t.Errorf("Builtin request type is registered")
+	}
+	if err := RegisterRequestType(nopRequestCode, RequestType{Name: "NOP", ReadOnly: true}); err != nil {
+		t.Errorf("Failed to register request type: %s", err.Error())
+	}
+	if err := RegisterRequestType(nopRequestCode, RequestType{Name: "NOP"}); err == nil {
+		t.Errorf("Request type is registered twice")
+	}
+	if typ, ok := LookupRequestType(nopRequestCode); !ok || typ.Name != "NOP" || !typ.ReadOnly {
+		t.Errorf("Unexpected registered type: %+v", typ)
+	}
+
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	resp, err := conn.Do(nopRequest{}).Get()
+	if err != nil {
+		t.Errorf("Failed to Do: %s", err.Error())
+	} else if resp.Code != OkCode {
+		t.Errorf("Unexpected response code: %d", resp.Code)
+	}
+}
+
+func TestClientCloseGraceful(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+
+	fut := conn.EvalAsync("require('fiber').sleep(0.2) return 42", []interface{}{})
+	done := make(chan error)
+	go func() {
+		done <- conn.CloseGraceful(context.Background())
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if state := conn.State(); state != StateShutdownPending {
+		t.Errorf("Unexpected state: %s", state)
+	}
+	if _, err = conn.Ping(); err == nil {
+		t.Errorf("New request is not rejected while shutting down")
+	}
+	if err = <-done; err != nil {
+		t.Errorf("Failed to close gracefully: %s", err.Error())
+	}
+	if resp, err := fut.Get(); err != nil {
+		t.Errorf("Request in flight is aborted: %s", err.Error())
+	} else if len(resp.Data) != 1 {
+		t.Errorf("Unexpected response: %v", resp.Data)
+	}
+	if !conn.ClosedNow() {
+		t.Errorf("Connection is not closed")
+	}
+
+	// Requests in flight are aborted when ctx is done.
+	conn, err = Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	fut = conn.EvalAsync("require('fiber').sleep(0.3)", []interface{}{})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err = conn.CloseGraceful(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err = fut.Get(); err == nil {
+		t.Errorf("Request in flight is not aborted")
+	}
+}
+
+func TestClientDecodeError(t *testing.T) {
+	var decodeErrors []DecodeError
+	var mutex sync.Mutex
+	decodeOpts := opts
+	decodeOpts.OnDecodeError = func(conn *Connection, err DecodeError) {
+		mutex.Lock()
+		decodeErrors = append(decodeErrors, err)
+		mutex.Unlock()
+	}
+	conn, err := Connect(server, decodeOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	var res []int
+	err = conn.EvalTyped("return 'not a number'", []interface{}{}, &res)
+	decerr, ok := err.(DecodeError)
+	if !ok {
+		t.Errorf("Expected DecodeError, got %v", err)
+	} else if decerr.RequestCode != EvalRequest || len(decerr.Body) == 0 {
+		t.Errorf("Unexpected DecodeError: %+v", decerr)
+	}
+	mutex.Lock()
+	if len(decodeErrors) != 1 {
+		t.Errorf("OnDecodeError is called %d times", len(decodeErrors))
+	}
+	mutex.Unlock()
+	if _, err = conn.Ping(); err != nil {
+		t.Errorf("Connection is failed by request decode error: %s", err.Error())
+	}
+
+	// Errors returned by Tarantool are not decode errors.
+	if _, err = conn.Eval("error('boom')", []interface{}{}); err == nil {
+		t.Errorf("Expected error")
+	} else if _, ok = err.(Error); !ok {
+		t.Errorf("Expected Error, got %T", err)
+	}
+
+	decodeOpts.DecodeErrorPolicy = DecodeFailConnection
+	conn2, err := Connect(server, decodeOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn2.Close()
+	if err = conn2.EvalTyped("return 'not a number'", []interface{}{}, &res); err == nil {
+		t.Errorf("Expected DecodeError")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if !conn2.ClosedNow() {
+		t.Errorf("Connection is not failed by decode error")
+	}
+}
+
+func TestIndexCompare(t *testing.T) {
+	index := &Index{
+		Name: "secondary",
+		Fields: []*IndexField{
+			{Id: 2, Type: "string"},
+			{Id: 0, Type: "unsigned"},
+		},
+	}
+	a := []interface{}{uint64(2), "x", "b"}
+	b := []interface{}{uint64(1), "y", "b"}
+	if key, err := index.ExtractKey(a); err != nil || len(key) != 2 || key[0] != "b" || key[1] != uint64(2) {
+		t.Errorf("Unexpected key: %v, %v", key, err)
+	}
+	if c, err := index.Compare(a, b); err != nil || c != 1 {
+		t.Errorf("Unexpected comparison: %d, %v", c, err)
+	}
+	if _, err := index.ExtractKey([]interface{}{uint64(1)}); err == nil {
+		t.Errorf("Short tuple is not rejected")
+	}
+
+	cases := []struct {
+		a, b interface{}
+		c    int
+	}{
+		{int64(-1), uint64(1), -1},
+		{uint64(math.MaxUint64), int64(math.MaxInt64), 1},
+		{1.5, uint64(1), 1},
+		{nil, false, -1},
+		{true, uint64(0), -1},
+		{"a", []byte("a"), -1},
+		{"ab", "b", -1},
+	}
+	for _, tc := range cases {
+		if c, err := index.CompareKeys([]interface{}{tc.a}, []interface{}{tc.b}); err != nil || c != tc.c {
+			t.Errorf("Compare %v and %v: %d, %v, expected %d", tc.a, tc.b, c, err, tc.c)
+		}
+	}
+	// Partial keys are compared by common parts.
+	if c, _ := index.CompareKeys([]interface{}{"b"}, []interface{}{"b", uint64(5)}); c != 0 {
+		t.Errorf("Partial keys are not equal: %d", c)
+	}
+}
+
+func TestSpaceValidateTuple(t *testing.T) {
+	space := &Space{
+		Name:        "users",
+		FieldsCount: 3,
+		FieldsById: map[uint32]*Field{
+			0: {Id: 0, Name: "id", Type: "unsigned"},
+			1: {Id: 1, Name: "name", Type: "string"},
+		},
+	}
+	if err := space.ValidateTuple([]interface{}{uint64(1), "alice", nil}); err != nil {
+		t.Errorf("Valid tuple is rejected: %s", err.Error())
+	}
+	if err := space.ValidateTuple([]interface{}{int64(-1), "alice", nil}); err == nil {
+		t.Errorf("Negative unsigned is not rejected")
+	}
+	if err := space.ValidateTuple([]interface{}{uint64(1), 2, nil}); err == nil {
+		t.Errorf("Number in string field is not rejected")
+	}
+	if err := space.ValidateTuple([]interface{}{uint64(1), "alice"}); err == nil {
+		t.Errorf("Tuple with wrong field count is not rejected")
+	}
+
+	prices := &Space{
+		Name: "prices",
+		FieldsById: map[uint32]*Field{
+			0: {Id: 0, Name: "price", Type: "double"},
+		},
+	}
+	if err := prices.ValidateTuple([]interface{}{1.5}); err != nil {
+		t.Errorf("Valid double is rejected: %s", err.Error())
+	}
+	if err := prices.ValidateTuple([]interface{}{2}); err == nil {
+		t.Errorf("Integer in double field is not rejected")
+	}
+	if err := prices.ValidateOps([]interface{}{[]interface{}{"=", "price", uint64(2)}}); err == nil {
+		t.Errorf("Assignment of integer to double field is not rejected")
+	}
+}
+
+func TestSpaceValidateNullability(t *testing.T) {
+	space := &Space{
+		Name: "users",
+		FieldsById: map[uint32]*Field{
+			0: {Id: 0, Name: "id", Type: "unsigned"},
+			1: {Id: 1, Name: "name", Type: "string"},
+			2: {Id: 2, Name: "email", Type: "string", IsNullable: true},
+		},
+	}
+	if err := space.ValidateTuple([]interface{}{uint64(1), "alice"}); err != nil {
+		t.Errorf("Tuple without nullable field is rejected: %s", err.Error())
+	}
+	err := space.ValidateTuple([]interface{}{uint64(1), nil, nil})
+	var verr TupleValidationError
+	if !errors.As(err, &verr) || verr.FieldNo != 2 || verr.Field != "name" {
+		t.Errorf("Unexpected error of nil in not nullable field: %v", err)
+	}
+	if err = space.ValidateTuple([]interface{}{uint64(1)}); err == nil {
+		t.Errorf("Tuple without not nullable field is not rejected")
+	}
+
+	if err = space.ValidateOps([]interface{}{
+		[]interface{}{"=", "name", "bob"},
+		[]interface{}{"+", 0, 1},
+		[]interface{}{":", 1, 0, 1, "x"},
+	}); err != nil {
+		t.Errorf("Valid operations are rejected: %s", err.Error())
+	}
+	if err = space.ValidateOps([]interface{}{[]interface{}{"=", 1, nil}}); err == nil {
+		t.Errorf("Assignment of nil to not nullable field is not rejected")
+	}
+	if err = space.ValidateOps([]interface{}{[]interface{}{"+", "name", 1}}); err == nil {
+		t.Errorf("Arithmetic on string field is not rejected")
+	}
+	if err = space.ValidateOps([]interface{}{[]interface{}{"+", "id", "1"}}); err == nil {
+		t.Errorf("Arithmetic with string argument is not rejected")
+	}
+}
+
+func TestClientValidateTuples(t *testing.T) {
+	validateOpts := opts
+	validateOpts.ValidateTuples = true
+	conn, err := Connect(server, validateOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	_, err = conn.Insert("schematest", []interface{}{uint(1010), "not a number"})
+	var verr TupleValidationError
+	if !errors.As(err, &verr) {
+		t.Errorf("Invalid tuple is not rejected: %v", err)
+	}
+	_, err = conn.Update("schematest", "primary", []interface{}{uint(1010)},
+		[]interface{}{[]interface{}{"=", 1, "not a number"}})
+	if !errors.As(err, &verr) {
+		t.Errorf("Invalid update is not rejected: %v", err)
+	}
+}
+
+func TestMergeTuples(t *testing.T) {
+	index := &Index{Name: "primary", Fields: []*IndexField{{Id: 0, Type: "unsigned"}}}
+	a := [][]interface{}{{uint64(1)}, {uint64(4)}, {uint64(6)}}
+	b := [][]interface{}{{uint64(2)}, {uint64(3)}, {uint64(7)}}
+	c := [][]interface{}{{uint64(5)}}
+	merged, err := MergeTuples(index, MergeOpts{Offset: 1, Limit: 5}, a, b, c)
+	if err != nil {
+		t.Fatalf("Failed to merge: %s", err.Error())
+	}
+	var keys []uint64
+	for _, tuple := range merged {
+		keys = append(keys, tuple[0].(uint64))
+	}
+	if fmt.Sprint(keys) != "[2 3 4 5 6]" {
+		t.Errorf("Unexpected merge: %v", keys)
+	}
+
+	desc := [][]interface{}{{uint64(3)}, {uint64(1)}}
+	merged, err = MergeTuples(index, MergeOpts{Desc: true}, desc, [][]interface{}{{uint64(2)}})
+	if err != nil || len(merged) != 3 || merged[0][0] != uint64(3) || merged[2][0] != uint64(1) {
+		t.Errorf("Unexpected descending merge: %v, %v", merged, err)
+	}
+
+	if _, err = MergeTuples(index, MergeOpts{}, a, [][]interface{}{{}}); err == nil {
+		t.Errorf("Tuple without key is not rejected")
+	}
+}
+
+func TestClientSelectProjected(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	// _vspace has format: id, owner, name, ...
+	fields := []uint32{2, 0, 100}
+	key := []interface{}{uint(spaceNo)}
+	tuples, err := conn.SelectAsync("_vspace", "primary", 0, 1, IterEq, key).GetProjected(fields)
+	if err != nil {
+		t.Errorf("Failed to GetProjected: %s", err.Error())
+	} else if len(tuples) != 1 || len(tuples[0]) != 3 || tuples[0][0] != spaceName ||
+		tuples[0][1] != uint64(spaceNo) || tuples[0][2] != nil {
+		t.Errorf("Unexpected projected tuples: %v", tuples)
+	}
+
+	tuples, err = conn.SelectFields("_vspace", "primary", 0, 1, IterEq, key, fields)
+	if err != nil {
+		t.Errorf("Failed to SelectFields: %s", err.Error())
+	} else if len(tuples) != 1 || len(tuples[0]) != 3 || tuples[0][0] != spaceName || tuples[0][2] != nil {
+		t.Errorf("Unexpected selected fields: %v", tuples)
+	}
+}
+
+func TestIndexKey(t *testing.T) {
+	index := &Index{
+		Name: "secondary",
+		Type: "tree",
+		Fields: []*IndexField{
+			{Id: 1, Type: "unsigned"},
+			{Id: 2, Type: "string"},
+		},
+	}
+	key, err := index.Key(5, "name")
+	if err != nil || len(key) != 2 || key[0] != uint64(5) || key[1] != "name" {
+		t.Errorf("Unexpected key: %v, %v", key, err)
+	}
+	if key, err = index.Key("7"); err != nil || len(key) != 1 || key[0] != uint64(7) {
+		t.Errorf("Unexpected partial key: %v, %v", key, err)
+	}
+	if _, err = index.Key(-1); err == nil {
+		t.Errorf("Negative unsigned part is not rejected")
+	}
+	if _, err = index.Key(1, "a", "b"); err == nil {
+		t.Errorf("Extra part is not rejected")
+	}
+	index.Type = "hash"
+	if _, err = index.Key(1); err == nil {
+		t.Errorf("Partial key of hash index is not rejected")
+	}
+
+	doubleIndex := &Index{
+		Name:   "double",
+		Fields: []*IndexField{{Id: 0, Type: "double"}, {Id: 1, Type: "number"}},
+	}
+	if key, err = doubleIndex.Key(3, 4); err != nil || key[0] != float64(3) || key[1] != 4 {
+		t.Errorf("Unexpected key of double index: %v, %v", key, err)
+	}
+	if key, err = doubleIndex.Key(uint64(5)); err != nil || key[0] != float64(5) {
+		t.Errorf("Unexpected key of double index: %v, %v", key, err)
+	}
+	if key, err = doubleIndex.Key(float32(1.5)); err != nil || key[0] != float64(1.5) {
+		t.Errorf("Unexpected key of double index: %v, %v", key, err)
+	}
+
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+	if key, err = conn.Key(spaceName, "primary", 1); err != nil || len(key) != 1 || key[0] != uint64(1) {
+		t.Errorf("Unexpected key of schema index: %v, %v", key, err)
+	}
+}
+
+func TestClientAliases(t *testing.T) {
+	aliasOpts := opts
+	aliasOpts.SpaceAliases = map[string]string{"test_v1": spaceName}
+	aliasOpts.CaseInsensitiveNames = true
+	conn, err := Connect(server, aliasOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	if _, err = conn.Select("test_v1", "primary", 0, 1, IterAll, []interface{}{}); err != nil {
+		t.Errorf("Failed to select by space alias: %s", err.Error())
+	}
+	if _, err = conn.Select("TEST", "PRIMARY", 0, 1, IterAll, []interface{}{}); err != nil {
+		t.Errorf("Failed to select by name in another case: %s", err.Error())
+	}
+
+	conn.SetSpaceAlias("users", spaceName)
+	conn.SetIndexAlias(spaceName, "pk", "primary")
+	if _, err = conn.Select("users", "pk", 0, 1, IterAll, []interface{}{}); err != nil {
+		t.Errorf("Failed to select by runtime aliases: %s", err.Error())
+	}
+	conn.SetSpaceAlias("users", "")
+	if _, err = conn.Select("users", "pk", 0, 1, IterAll, []interface{}{}); err == nil {
+		t.Errorf("Removed alias is resolved")
+	}
+}
+
+func TestReconnectPolicy(t *testing.T) {
+	fixed := FixedReconnect{Delay: time.Second, MaxAttempts: 2}
+	for attempt := uint(0); attempt <= 2; attempt++ {
+		if delay, ok := fixed.NextDelay(attempt, nil); !ok || delay != time.Second {
+			t.Errorf("Unexpected fixed delay %d: %v, %v", attempt, delay, ok)
+		}
+	}
+	if _, ok := fixed.NextDelay(3, nil); ok {
+		t.Errorf("Fixed policy should give up after MaxAttempts")
+	}
+	if _, ok := UnlimitedReconnect(time.Second).NextDelay(1000000, nil); !ok {
+		t.Errorf("Unlimited policy gave up")
+	}
+
+	exp := ExponentialReconnect{Initial: 100 * time.Millisecond, Max: time.Second}
+	expected := []time.Duration{100, 200, 400, 800, 1000, 1000}
+	for i, expDelay := range expected {
+		delay, ok := exp.NextDelay(uint(i), nil)
+		if !ok || delay != expDelay*time.Millisecond {
+			t.Errorf("Unexpected exponential delay %d: %v, %v", i, delay, ok)
+		}
+	}
+	if delay, _ := exp.NextDelay(10000, nil); delay != time.Second {
+		t.Errorf("Exponential delay is not capped: %v", delay)
+	}
+
+	exp.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		delay, _ := exp.NextDelay(3, nil)
+		if delay < 400*time.Millisecond || delay > 800*time.Millisecond {
+			t.Errorf("Delay %v is out of jitter bounds", delay)
+		}
+	}
+}
+
+func TestProtocolMismatchError(t *testing.T) {
+	err := ProtocolMismatchError{
+		ServerVersion:   "Tarantool 2.8.4 (Binary) 00000000-0000-0000-0000-000000000000  ",
+		Required:        ProtocolInfo{Version: 1, Features: []ProtocolFeature{WatchersFeature}},
+		MissingFeatures: []ProtocolFeature{WatchersFeature},
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "Tarantool 2.8.4") ||
+		!strings.Contains(msg, "version 0 is less than required 1") ||
+		!strings.Contains(msg, "missing features: WatchersFeature") {
+		t.Errorf("Unexpected error message: %s", msg)
+	}
+}
+
+func TestClientRequiredProtocolInfo(t *testing.T) {
+	unknown := ProtocolFeature(1000)
+	reqOpts := opts
+	reqOpts.RequiredProtocolInfo = ProtocolInfo{Features: []ProtocolFeature{unknown}}
+	conn, err := Connect(server, reqOpts)
+	if err == nil {
+		conn.Close()
+		t.Errorf("Connect should fail for unsupported feature")
+		return
+	}
+	mismatch, ok := err.(ProtocolMismatchError)
+	if !ok {
+		t.Errorf("Unexpected error type %T: %s", err, err)
+		return
+	}
+	if len(mismatch.MissingFeatures) != 1 || mismatch.MissingFeatures[0] != unknown ||
+		mismatch.ServerVersion == "" {
+		t.Errorf("Unexpected error: %+v", mismatch)
+	}
+
+	conn, err = Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+	info := conn.ConnectionInfo()
+	if info.Addr != server || !strings.HasPrefix(info.ServerVersion, "Tarantool") ||
+		info.ProtocolVersion > info.ClientProtocolVersion ||
+		info.ProtocolVersion > info.ServerProtocolInfo.Version {
+		t.Errorf("Unexpected connection info: %+v", info)
+	}
+}
+
+func TestClientSizeStats(t *testing.T) {
+	sizeOpts := opts
+	sizeOpts.TrackSizes = true
+	sizeOpts.HeavyRequests = 2
+	// schema responses would be the heaviest ones
+	sizeOpts.SkipSchema = true
+	conn, err := Connect(server, sizeOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	huge := strings.Repeat("x", 10000)
+	if _, err = conn.Replace(spaceNo, []interface{}{uint(30), huge}); err != nil {
+		t.Errorf("Failed to Replace: %s", err.Error())
+		return
+	}
+	defer conn.Delete(spaceNo, 0, []interface{}{uint(30)})
+	for i := 0; i < 3; i++ {
+		if _, err = conn.Ping(); err != nil {
+			t.Errorf("Failed to Ping: %s", err.Error())
+			return
+		}
+	}
+
+	sizes := conn.Stats().Sizes
+	replace := sizes.Requests[SizeKey{RequestCode: ReplaceRequest, SpaceNo: spaceNo}]
+	if replace.Count != 1 || replace.Max < 10000 || replace.Percentile(50) < 10000 {
+		t.Errorf("Unexpected replace sizes: %+v", replace)
+	}
+	if ping := sizes.Requests[SizeKey{RequestCode: PingRequest}]; ping.Count < 3 {
+		t.Errorf("Unexpected ping sizes: %+v", ping)
+	}
+	if len(sizes.Heaviest) != 2 {
+		t.Errorf("Unexpected number of heaviest packets: %d", len(sizes.Heaviest))
+		return
+	}
+	for _, packet := range sizes.Heaviest {
+		if packet.RequestCode != ReplaceRequest || packet.Size < 10000 {
+			t.Errorf("Unexpected heavy packet: %+v", packet)
+		}
+	}
+	if sizes.Heaviest[0].Size < sizes.Heaviest[1].Size {
+		t.Errorf("Heaviest packets are not sorted")
+	}
+}
+
+func TestClientProfilerLabels(t *testing.T) {
+	profOpts := opts
+	profOpts.ProfilerLabels = true
+	profOpts.TraceRegions = true
+	conn, err := Connect(server, profOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	var buf bytes.Buffer
+	if err = trace.Start(&buf); err != nil {
+		t.Skipf("Tracing is not available: %s", err.Error())
+	}
+	resp, err := conn.Select(spaceName, "primary", 0, 1, IterAll, []interface{}{})
+	var tuples [][]interface{}
+	if err == nil {
+		err = conn.SelectTyped(spaceName, "primary", 0, 1, IterAll, []interface{}{}, &tuples)
+	}
+	if err == nil {
+		// labels of request are added to labels of ctx
+		pprof.Do(context.Background(), pprof.Labels("app", "test"), func(ctx context.Context) {
+			_, err = conn.EvalContext(ctx, "return 1", []interface{}{})
+		})
+	}
+	trace.Stop()
+	if err != nil {
+		t.Errorf("Failed to Select: %s", err.Error())
+		return
+	}
+	if len(resp.Data) != len(tuples) {
+		t.Errorf("Unexpected number of tuples: %d and %d", len(resp.Data), len(tuples))
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("tarantool.select.decode")) {
+		t.Errorf("No trace region for decode")
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("tarantool.eval.encode")) {
+		t.Errorf("No trace region for request with ctx")
+	}
+}
+
+func TestClientSelectStream(t *testing.T) {
+	streamOpts := opts
+	streamOpts.StreamThreshold = 64
+	conn, err := Connect(server, streamOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	value := strings.Repeat("v", 100)
+	for i := 100; i < 150; i++ {
+		if _, err = conn.Replace(spaceNo, []interface{}{uint(i), value}); err != nil {
+			t.Errorf("Failed to Replace: %s", err.Error())
+			return
+		}
+	}
+	defer func() {
+		for i := 100; i < 150; i++ {
+			conn.Delete(spaceNo, 0, []interface{}{uint(i)})
+		}
+	}()
+
+	stream := conn.SelectStream(spaceNo, 0, 0, 50, IterGe, []interface{}{uint(100)})
+	count := 0
+	for {
+		// decoding into non-empty slice of interfaces is not supported
+		var tuple []interface{}
+		if !stream.Next(&tuple) {
+			break
+		}
+		if len(tuple) != 2 || tuple[1] != value {
+			t.Errorf("Unexpected tuple: %v", tuple)
+		}
+		count++
+	}
+	if err = stream.Err(); err != nil {
+		t.Errorf("Failed to stream: %s", err.Error())
+	}
+	if count != 50 {
+		t.Errorf("Unexpected number of tuples: %d", count)
+	}
+
+	// the rest of response is skipped on close
+	var tuple []interface{}
+	stream = conn.SelectStream(spaceNo, 0, 0, 50, IterGe, []interface{}{uint(100)})
+	if !stream.Next(&tuple) {
+		t.Errorf("Failed to stream: %v", stream.Err())
+	}
+	stream.Close()
+	if _, err = conn.Ping(); err != nil {
+		t.Errorf("Failed to Ping after stream: %s", err.Error())
+	}
+
+	// stream which is not read is discarded after timeout
+	stream = conn.SelectStream(spaceNo, 0, 0, 50, IterGe, []interface{}{uint(100)})
+	if !stream.Next(&tuple) {
+		t.Errorf("Failed to stream: %v", stream.Err())
+	}
+	time.Sleep(3 * streamOpts.Timeout)
+	if _, err = conn.Ping(); err != nil {
+		t.Errorf("Failed to Ping after dropped stream: %s", err.Error())
+	}
+	if stream.Next(&tuple) {
+		t.Errorf("Discarded stream is read")
+	} else if cerr, ok := stream.Err().(ClientError); !ok || cerr.Code != ErrTimeouted {
+		t.Errorf("Unexpected error of discarded stream: %v", stream.Err())
+	}
+	stream.Close()
+
+	stream = conn.SelectStream(spaceNo, "nonexistent", 0, 1, IterAll, []interface{}{})
+	tuple = nil
+	if stream.Next(&tuple) || stream.Err() == nil {
+		t.Errorf("Stream of bad request should fail")
+	}
+	stream.Close()
+}
+
+func TestClientMaxMemory(t *testing.T) {
+	memOpts := opts
+	memOpts.MaxMemory = 4096
+	// schema responses are larger than the limit
+	memOpts.SkipSchema = true
+	conn, err := Connect(server, memOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	huge := strings.Repeat("x", 8192)
+	_, err = conn.Replace(spaceNo, []interface{}{uint(31), huge})
+	if cerr, ok := err.(ClientError); !ok || cerr.Code != ErrMemoryLimit || !cerr.Temporary() {
+		t.Errorf("Unexpected error for huge request: %v", err)
+	}
+
+	big := strings.Repeat("x", 3000)
+	for i := uint(31); i < 33; i++ {
+		if _, err = conn.Replace(spaceNo, []interface{}{i, big}); err != nil {
+			t.Errorf("Failed to Replace: %s", err.Error())
+			return
+		}
+		defer conn.Delete(spaceNo, 0, []interface{}{i})
+	}
+	_, err = conn.Select(spaceNo, 0, 0, 2, IterGe, []interface{}{uint(31)})
+	if cerr, ok := err.(ClientError); !ok || cerr.Code != ErrMemoryLimit {
+		t.Errorf("Unexpected error for huge response: %v", err)
+	}
+
+	// connection works after skipped response
+	resp, err := conn.Select(spaceNo, 0, 0, 1, IterEq, []interface{}{uint(31)})
+	if err != nil || len(resp.Data) != 1 {
+		t.Errorf("Failed to Select: %v", err)
+	}
+	if memory := conn.Stats().Memory; memory != 0 {
+		t.Errorf("Memory is not released: %d", memory)
+	}
+}
+
+func TestClientMaxMemoryUnreadFutures(t *testing.T) {
+	memOpts := opts
+	memOpts.MaxMemory = 4096
+	memOpts.SkipSchema = true
+	conn, err := Connect(server, memOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	// unread responses hold the memory, so responses that do not fit
+	// into the limit together are skipped
+	big := strings.Repeat("x", 1000)
+	futs := make([]*Future, 0, 16)
+	for i := 0; i < 16; i++ {
+		futs = append(futs, conn.EvalAsync("return ...", []interface{}{big}))
+	}
+	for _, fut := range futs {
+		<-fut.WaitChan()
+	}
+	if memory := conn.Stats().Memory; memory == 0 || memory > memOpts.MaxMemory {
+		t.Errorf("Unexpected memory of unread responses: %d", memory)
+	}
+	var skipped int
+	for _, fut := range futs {
+		if _, err = fut.Get(); err != nil {
+			if cerr, ok := err.(ClientError); !ok || cerr.Code != ErrMemoryLimit {
+				t.Errorf("Unexpected error of skipped response: %v", err)
+			}
+			skipped++
+		}
+	}
+	if skipped == 0 || skipped == len(futs) {
+		t.Errorf("Unexpected number of skipped responses: %d", skipped)
+	}
+	if memory := conn.Stats().Memory; memory != 0 {
+		t.Errorf("Memory of read responses is not released: %d", memory)
+	}
+
+	// memory of dropped futures is released by garbage collector
+	for i := 0; i < 2; i++ {
+		<-conn.EvalAsync("return ...", []interface{}{big}).WaitChan()
+	}
+	for i := 0; i < 100 && conn.Stats().Memory != 0; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if memory := conn.Stats().Memory; memory != 0 {
+		t.Errorf("Memory of dropped responses is not released: %d", memory)
+	}
+	if _, err = conn.Eval("return ...", []interface{}{big}); err != nil {
+		t.Errorf("Failed to Eval after unread responses: %s", err.Error())
+	}
+}
+
+func TestClientPreparedRequest(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	if _, err = conn.PrepareSelect("nonexistent", "primary", 0, 1, IterEq, []interface{}{uint(1)}); err == nil {
+		t.Errorf("Request to unknown space is prepared")
+	}
+
+	replace, err := conn.PrepareReplace(spaceName, []interface{}{uint(40), "prepared"})
+	if err != nil {
+		t.Errorf("Failed to prepare replace: %s", err.Error())
+		return
+	}
+	defer conn.Delete(spaceNo, 0, []interface{}{uint(40)})
+	sel, err := conn.PrepareSelect(spaceName, "primary", 0, 1, IterEq, []interface{}{uint(40)})
+	if err != nil {
+		t.Errorf("Failed to prepare select: %s", err.Error())
+		return
+	}
+	if _, err = conn.SendPrepared(replace).Get(); err != nil {
+		t.Errorf("Failed to replace: %s", err.Error())
+		return
+	}
+
+	// prepared request is sent several times and concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var tuples [][]interface{}
+			if err := conn.SendPrepared(sel).GetTyped(&tuples); err != nil {
+				t.Errorf("Failed to select: %s", err.Error())
+			} else if len(tuples) != 1 || tuples[0][1] != "prepared" {
+				t.Errorf("Unexpected tuples: %v", tuples)
+			}
+		}()
+	}
+	wg.Wait()
+
+	frozen, err := Freeze(nopRequest{})
+	if err != nil {
+		t.Errorf("Failed to freeze: %s", err.Error())
+		return
+	}
+	if _, err = conn.Do(frozen).Get(); err != nil {
+		t.Errorf("Failed to send frozen request: %s", err.Error())
+	}
+}
+
+func TestIndexValidateIterator(t *testing.T) {
+	tree := &Index{Name: "primary", Type: "TREE"}
+	if err := tree.ValidateIterator(IterLe); err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+	err := tree.ValidateIterator(IterBitsAllSet)
+	if err == nil || !strings.Contains(err.Error(), "BITS_ALL_SET is not supported by TREE index primary") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	hash := &Index{Name: "hash", Type: "hash"}
+	if err = hash.ValidateIterator(IterLt); err == nil {
+		t.Errorf("LT iterator is valid for HASH index")
+	}
+	rtree := &Index{Name: "spatial", Type: "RTREE"}
+	if err = rtree.ValidateIterator(IterNeighbor); err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+	if err = (&Index{Type: "custom"}).ValidateIterator(IterNeighbor); err != nil {
+		t.Errorf("Iterator of unknown index type is validated: %s", err.Error())
+	}
+
+	if key := RTreePoint(1, 2); len(key) != 2 || key[0] != 1.0 || key[1] != 2.0 {
+		t.Errorf("Unexpected point key: %v", key)
+	}
+	key, err := RTreeRect([]float64{1, 2}, []float64{3, 4})
+	if err != nil || len(key) != 4 || key[2] != 3.0 {
+		t.Errorf("Unexpected rectangle key: %v, %v", key, err)
+	}
+	if _, err = RTreeRect([]float64{1, 2}, []float64{3}); err == nil {
+		t.Errorf("Rectangle with different dimensions of corners is valid")
+	}
+}
+
+func TestClientValidateIterators(t *testing.T) {
+	iterOpts := opts
+	iterOpts.ValidateIterators = true
+	conn, err := Connect(server, iterOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	_, err = conn.Select(spaceName, "primary", 0, 1, IterBitsAnySet, []interface{}{uint(1)})
+	if err == nil || !strings.Contains(err.Error(), "is not supported by TREE index") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err = conn.Select(spaceName, "primary", 0, 1, IterGe, []interface{}{uint(1)}); err != nil {
+		t.Errorf("Failed to Select: %s", err.Error())
+	}
+	_, err = NewDedup(conn).Select(spaceName, "primary", 0, 1, IterBitsAnySet, []interface{}{uint(1)})
+	if err == nil || !strings.Contains(err.Error(), "is not supported by TREE index") {
+		t.Errorf("Unexpected error of deduplicated select: %v", err)
+	}
+	_, err = NewCache(conn, CacheOpts{}).Select(spaceName, "primary", 0, 1, IterBitsAnySet, []interface{}{uint(1)})
+	if err == nil || !strings.Contains(err.Error(), "is not supported by TREE index") {
+		t.Errorf("Unexpected error of cached select: %v", err)
+	}
+	stream := conn.SelectStream(spaceName, "primary", 0, 1, IterBitsAnySet, []interface{}{uint(1)})
+	var tuple []interface{}
+	if stream.Next(&tuple) || stream.Err() == nil || !strings.Contains(stream.Err().Error(), "is not supported by TREE index") {
+		t.Errorf("Unexpected error of streamed select: %v", stream.Err())
+	}
+}
+
+func TestClientOnConnect(t *testing.T) {
+	var states []ConnState
+	held := make(chan *Future, 1)
+	hookOpts := opts
+	hookOpts.OnConnect = func(ctx context.Context, conn *Connection) error {
+		states = append(states, conn.State())
+		// methods locking connection are available in the hook
+		if conn.ConnectionInfo().ServerVersion == "" {
+			return errors.New("server version is unknown")
+		}
+		// request of another goroutine waits for the hook
+		go func() {
+			held <- conn.EvalAsync("return box.session.storage.on_connect", []interface{}{})
+		}()
+		_, err := conn.EvalContext(ctx, "box.session.storage.on_connect = ...", []interface{}{42})
+		return err
+	}
+	conn, err := Connect(server, hookOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	if len(states) != 1 || states[0] != StateInitializing {
+		t.Errorf("Unexpected states of connection in hook: %v", states)
+	}
+	var heldRes []int
+	if err = (<-held).GetTyped(&heldRes); err != nil {
+		t.Errorf("Failed to Eval held request: %s", err.Error())
+	} else if len(heldRes) != 1 || heldRes[0] != 42 {
+		t.Errorf("Request is sent before hook is done: %v", heldRes)
+	}
+	if !conn.ConnectedNow() {
+		t.Errorf("Connection is not connected after hook")
+	}
+	var res []int
+	if err = conn.EvalTyped("return box.session.storage.on_connect", []interface{}{}, &res); err != nil {
+		t.Errorf("Failed to Eval: %s", err.Error())
+	} else if len(res) != 1 || res[0] != 42 {
+		t.Errorf("Session is not set up by hook: %v", res)
+	}
+
+	if err = conn.Reconnect(context.Background()); err != nil {
+		t.Errorf("Failed to reconnect: %s", err.Error())
+	}
+	if len(states) != 2 {
+		t.Errorf("Hook is not called on reconnect: %v", states)
+	}
+
+	hookOpts.OnConnect = func(ctx context.Context, conn *Connection) error {
+		return errors.New("warmup failed")
+	}
+	if _, err = Connect(server, hookOpts); err == nil || !strings.Contains(err.Error(), "warmup failed") {
+		t.Errorf("Unexpected error of failed hook: %v", err)
+	}
+}
+
+func TestSchemaExportImport(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	snapshot := conn.Schema.Export()
+	jsonData, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Errorf("Failed to encode snapshot to JSON: %s", err.Error())
+		return
+	}
+	msgpackData, err := msgpack.Marshal(snapshot)
+	if err != nil {
+		t.Errorf("Failed to encode snapshot to msgpack: %s", err.Error())
+		return
+	}
+	for name, data := range map[string][]byte{"json": jsonData, "msgpack": msgpackData} {
+		schema, err := ImportSchema(data)
+		if err != nil {
+			t.Errorf("Failed to import %s snapshot: %s", name, err.Error())
+			continue
+		}
+		space := schema.Spaces["schematest"]
+		if space == nil || space.Id != 514 || space.FieldsCount != 7 || len(space.FieldsById) != 6 {
+			t.Errorf("Unexpected space in %s snapshot: %v", name, space)
+			continue
+		}
+		if field := space.Fields["name2"]; field == nil || field.Id != 2 || field.Type != "string" {
+			t.Errorf("Unexpected field in %s snapshot: %v", name, field)
+		}
+		if index := space.Indexes["secondary"]; index == nil || index.Id != 3 || len(index.Fields) != 2 || index.Fields[1].Id != 2 {
+			t.Errorf("Unexpected index in %s snapshot: %v", name, index)
+		}
+		spaceNo, indexNo, err := schema.ResolveSpaceIndex("schematest", "secondary")
+		if err != nil || spaceNo != 514 || indexNo != 3 {
+			t.Errorf("Unexpected resolve with %s snapshot: %d, %d, %v", name, spaceNo, indexNo, err)
+		}
+		req, err := schema.PrepareSelect("schematest", "primary", 0, 1, IterEq, []interface{}{uint(1)})
+		if err != nil {
+			t.Errorf("Failed to prepare select with %s snapshot: %s", name, err.Error())
+			continue
+		}
+		if _, err = conn.SendPrepared(req).Get(); err != nil {
+			t.Errorf("Failed to send select prepared with %s snapshot: %s", name, err.Error())
+		}
+	}
+}
+
+func TestImportSchemaDuplicates(t *testing.T) {
+	data := []byte(`{"spaces": [{"id": 512, "name": "a"}, {"id": 513, "name": "a"}]}`)
+	if _, err := ImportSchema(data); err == nil {
+		t.Errorf("Snapshot with duplicate space names is imported")
+	}
+	data = []byte(`{"spaces": [{"id": 512, "name": "a", "indexes": [{"id": 0, "name": "pk", "type": "TREE", "parts": [{"field": 0, "type": "unsigned"}]}]}]}`)
+	schema, err := ImportSchema(data)
+	if err != nil {
+		t.Errorf("Failed to import snapshot: %s", err.Error())
+		return
+	}
+	if _, err = schema.PrepareDelete("a", "pk", []interface{}{uint(1)}); err != nil {
+		t.Errorf("Failed to prepare delete: %s", err.Error())
+	}
+	if _, _, err = schema.ResolveSpaceIndex("b", nil); err == nil {
+		t.Errorf("Unknown space is resolved")
+	}
+}
+
+func TestNameResolutionError(t *testing.T) {
+	var schema *Schema
+	_, _, err := schema.ResolveSpaceIndex("schematest", nil)
+	var nerr NameResolutionError
+	if !errors.As(err, &nerr) || !nerr.SchemaNotLoaded || nerr.Space != "schematest" {
+		t.Errorf("Unexpected error without schema: %v", err)
+	}
+	if _, _, err = schema.ResolveSpaceIndex(514, 3); err != nil {
+		t.Errorf("Numbers are not resolved without schema: %s", err.Error())
+	}
+
+	schema, err = ImportSchema([]byte(`{"spaces": [{"id": 512, "name": "a"}]}`))
+	if err != nil {
+		t.Errorf("Failed to import snapshot: %s", err.Error())
+		return
+	}
+	_, _, err = schema.ResolveSpaceIndex(512, "pk")
+	if !errors.As(err, &nerr) || nerr.SchemaNotLoaded || nerr.Space != "a" || nerr.Index != "pk" {
+		t.Errorf("Unexpected error of unknown index: %v", err)
+	}
+	_, _, err = schema.ResolveSpaceIndex(513, "pk")
+	if !errors.As(err, &nerr) || nerr.SpaceNo != 513 || nerr.Space != "" {
+		t.Errorf("Unexpected error of unknown space: %v", err)
+	}
+}
+
+type staticResolver map[string]uint32
+
+func (r staticResolver) ResolveSpaceIndex(space, index interface{}) (uint32, uint32, error) {
+	name, _ := space.(string)
+	spaceNo, ok := r[name]
+	if !ok {
+		return 0, 0, NameResolutionError{Space: name}
+	}
+	indexNo, _ := index.(uint32)
+	return spaceNo, indexNo, nil
+}
+
+func TestClientSchemaResolver(t *testing.T) {
+	resolverOpts := opts
+	resolverOpts.SchemaResolver = staticResolver{"static": 514}
+	conn, err := Connect(server, resolverOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	if conn.Schema != nil {
+		t.Errorf("Schema is loaded with custom resolver")
+	}
+	if _, err = conn.Select("static", uint32(0), 0, 1, IterAll, []interface{}{}); err != nil {
+		t.Errorf("Failed to select with custom resolver: %s", err.Error())
+	}
+	if _, err = conn.SelectFields("static", uint32(0), 0, 1, IterAll, []interface{}{}, []uint32{0}); err != nil {
+		t.Errorf("Failed to select fields with custom resolver: %s", err.Error())
+	}
+	var nerr NameResolutionError
+	if _, err = conn.Select("schematest", uint32(0), 0, 1, IterAll, []interface{}{}); !errors.As(err, &nerr) {
+		t.Errorf("Unexpected error of unknown space: %v", err)
+	}
+
+	skipOpts := opts
+	skipOpts.SkipSchema = true
+	conn2, err := Connect(server, skipOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn2.Close()
+	_, err = conn2.Select("schematest", "primary", 0, 1, IterAll, []interface{}{})
+	if conn2.ProtocolInfo().Has(SpaceAndIndexNamesFeature) {
+		// names are resolved by server
+		if err != nil {
+			t.Errorf("Failed to select by names with SkipSchema: %s", err.Error())
+		}
+	} else if !errors.As(err, &nerr) || !nerr.SchemaNotLoaded {
+		t.Errorf("Unexpected error with SkipSchema: %v", err)
+	}
+}
+
+func TestClientAuthenticate(t *testing.T) {
+	guestOpts := opts
+	guestOpts.User, guestOpts.Pass = "", ""
+	guestOpts.SkipSchema = true
+	conn, err := Connect(server, guestOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	if err = conn.Authenticate(NewAuthRequest("test", "wrong")); err == nil {
+		t.Errorf("Authenticated with wrong password")
+	} else if terr, ok := err.(Error); !ok || terr.Code != ErrPasswordMismatch {
+		t.Errorf("Unexpected error of wrong password: %v", err)
+	}
+	if user := conn.ConnectionInfo().User; user != "" {
+		t.Errorf("Unexpected user after failed auth: %s", user)
+	}
+
+	if err = conn.Authenticate(NewAuthRequest("test", "test")); err != nil {
+		t.Errorf("Failed to authenticate: %s", err.Error())
+		return
+	}
+	if user := conn.ConnectionInfo().User; user != "test" {
+		t.Errorf("Unexpected user after auth: %s", user)
+	}
+	resp, err := conn.Eval("return box.session.user()", []interface{}{})
+	if err != nil || len(resp.Data) != 1 || resp.Data[0] != "test" {
+		t.Errorf("Unexpected session user: %v, %v", resp, err)
+	}
+}
+
+func TestExtTupleDecode(t *testing.T) {
+	payload, err := msgpack.Marshal(ExtTuple{FormatId: 5, Fields: []interface{}{uint64(1), "alice"}})
+	if err != nil {
+		t.Errorf("Failed to encode tuple: %s", err.Error())
+		return
+	}
+	var v interface{}
+	if err = msgpack.Unmarshal(payload, &v); err != nil {
+		t.Errorf("Failed to decode tuple: %s", err.Error())
+		return
+	}
+	tuple, ok := v.(ExtTuple)
+	if !ok || tuple.FormatId != 5 || len(tuple.Fields) != 2 || tuple.Fields[1] != "alice" {
+		t.Errorf("Unexpected decoded tuple: %#v", v)
+		return
+	}
+	if _, ok = tuple.Get("name"); ok {
+		t.Errorf("Field is found without format")
+	}
+	tuple.Format = &TupleFormat{Id: 5, Fields: []*Field{{Id: 0, Name: "id"}, {Id: 1, Name: "name"}}}
+	if name, ok := tuple.Get("name"); !ok || name != "alice" {
+		t.Errorf("Unexpected field by name: %v, %v", name, ok)
+	}
+}
+
+func TestClientTupleExtension(t *testing.T) {
+	extOpts := opts
+	extOpts.TupleExtension = true
+	conn, err := Connect(server, extOpts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+	if !conn.ProtocolInfo().Has(CallRetTupleExtensionFeature) {
+		t.Skip("MP_TUPLE extension is not supported")
+	}
+
+	if _, err = conn.Replace("schematest", []interface{}{uint64(1020), uint64(1), "ext", uint64(2), uint64(3), "tuple", nil}); err != nil {
+		t.Errorf("Failed to replace: %s", err.Error())
+		return
+	}
+	resp, err := conn.Eval("return box.space.schematest:get(...)", []interface{}{uint64(1020)})
+	if err != nil {
+		t.Errorf("Failed to eval: %s", err.Error())
+		return
+	}
+	if len(resp.Data) != 1 {
+		t.Errorf("Unexpected response: %v", resp.Data)
+		return
+	}
+	tuple, ok := resp.Data[0].(ExtTuple)
+	if !ok {
+		t.Errorf("Tuple is not decoded as ExtTuple: %#v", resp.Data[0])
+		return
+	}
+	if name, ok := tuple.Get("name2"); !ok || name != "ext" {
+		t.Errorf("Unexpected field by name: %v, %v", name, ok)
+	}
+	if format, ok := conn.TupleFormat(tuple.FormatId); !ok || len(format.Fields) == 0 {
+		t.Errorf("Tuple format is not cached")
+	}
+}
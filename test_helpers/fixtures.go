@@ -0,0 +1,286 @@
+// Package test_helpers contains helpers for tests which use Tarantool
+// instances: fixture loading, waiting for instance state and so on.
+package test_helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+// Fixtures describes test data of a fixture file.
+//
+// Fixture file is a JSON document:
+//
+//	{
+//	    "spaces": [{
+//	        "name": "users",
+//	        "format": [
+//	            {"name": "id", "type": "unsigned"},
+//	            {"name": "name", "type": "string"}
+//	        ],
+//	        "indexes": [{"name": "primary", "parts": ["id"]}],
+//	        "records": [[1, "Alice"], [2, "Bob"]]
+//	    }]
+//	}
+type Fixtures struct {
+	Spaces []SpaceFixture `json:"spaces"`
+}
+
+// SpaceFixture describes a space and records to put into it.
+// Format and Indexes are used only if the space does not exist yet.
+type SpaceFixture struct {
+	Name    string          `json:"name"`
+	Engine  string          `json:"engine"`
+	Format  []FieldFixture  `json:"format"`
+	Indexes []IndexFixture  `json:"indexes"`
+	Records [][]interface{} `json:"records"`
+}
+
+// FieldFixture describes a field of space format.
+type FieldFixture struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// IndexFixture describes an index, Parts are names of format fields.
+// Type defaults to "tree", Unique defaults to true.
+type IndexFixture struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Unique *bool    `json:"unique"`
+	Parts  []string `json:"parts"`
+}
+
+// ReadFixtures reads fixture file.
+func ReadFixtures(path string) (*Fixtures, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	fixtures := new(Fixtures)
+	if err = dec.Decode(fixtures); err != nil {
+		return nil, fmt.Errorf("failed to parse fixtures %s: %s", path, err)
+	}
+	return fixtures, nil
+}
+
+// LoadFixtures creates missing spaces described in fixture file and
+// replaces records into them. Record fields are converted according to
+// the space format: e.g. JSON numbers become uint64 for "unsigned" fields
+// and float64 for "double" fields.
+// Note: it uses Eval, so user needs 'execute universe' privilege.
+func LoadFixtures(conn tarantool.Connector, path string) error {
+	fixtures, err := ReadFixtures(path)
+	if err != nil {
+		return err
+	}
+	for i := range fixtures.Spaces {
+		space := &fixtures.Spaces[i]
+		types, err := ensureSpace(conn, space)
+		if err != nil {
+			return err
+		}
+		records, err := space.tuples(types)
+		if err != nil {
+			return err
+		}
+		for _, tuple := range records {
+			if _, err = conn.Call17("box.space."+space.Name+":replace", []interface{}{tuple}); err != nil {
+				return fmt.Errorf("failed to load record into space %s: %s", space.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CleanupFixtures deletes records described in fixture file by their
+// primary keys. Spaces are kept.
+// Note: it uses Eval, so user needs 'execute universe' privilege.
+func CleanupFixtures(conn tarantool.Connector, path string) error {
+	fixtures, err := ReadFixtures(path)
+	if err != nil {
+		return err
+	}
+	for i := range fixtures.Spaces {
+		space := &fixtures.Spaces[i]
+		if len(space.Records) == 0 {
+			continue
+		}
+		var types [][]string
+		if err = conn.EvalTyped(spaceTypesLua, []interface{}{space.Name}, &types); err != nil {
+			return fmt.Errorf("failed to get format of space %s: %s", space.Name, err)
+		}
+		if len(types) == 0 {
+			// Space does not exist, nothing to cleanup.
+			continue
+		}
+		records, err := space.tuples(types[0])
+		if err != nil {
+			return err
+		}
+		if _, err = conn.Eval(cleanupFixturesLua, []interface{}{space.Name, records}); err != nil {
+			return fmt.Errorf("failed to cleanup space %s: %s", space.Name, err)
+		}
+	}
+	return nil
+}
+
+const spaceTypesLua = `
+local space = box.space[...]
+if space == nil then
+    return
+end
+local types = {}
+for i, field in ipairs(space:format()) do
+    types[i] = field.type
+end
+return types
+`
+
+const ensureSpaceLua = `
+local name, opts, indexes = ...
+local space = box.space[name]
+if space == nil then
+    space = box.schema.space.create(name, opts)
+    for _, index in ipairs(indexes) do
+        space:create_index(index[1], index[2])
+    end
+end
+local types = {}
+for i, field in ipairs(space:format()) do
+    types[i] = field.type
+end
+return types
+`
+
+const cleanupFixturesLua = `
+local name, records = ...
+local space = box.space[name]
+if space == nil or space.index[0] == nil then
+    return
+end
+for _, record in ipairs(records) do
+    local key = {}
+    for i, part in ipairs(space.index[0].parts) do
+        key[i] = record[part.fieldno]
+    end
+    space:delete(key)
+end
+`
+
+// ensureSpace creates space if it is missing and returns types of
+// its format fields.
+func ensureSpace(conn tarantool.Connector, space *SpaceFixture) ([]string, error) {
+	opts := map[string]interface{}{}
+	if space.Engine != "" {
+		opts["engine"] = space.Engine
+	}
+	fieldNo := make(map[string]int)
+	if len(space.Format) > 0 {
+		format := make([]map[string]string, len(space.Format))
+		for i, field := range space.Format {
+			format[i] = map[string]string{"name": field.Name, "type": field.Type}
+			fieldNo[field.Name] = i
+		}
+		opts["format"] = format
+	}
+	indexes := make([]interface{}, 0, len(space.Indexes))
+	for _, index := range space.Indexes {
+		parts := make([]interface{}, 0, 2*len(index.Parts))
+		for _, part := range index.Parts {
+			no, ok := fieldNo[part]
+			if !ok {
+				return nil, fmt.Errorf("index %s of space %s: unknown field %s", index.Name, space.Name, part)
+			}
+			parts = append(parts, no+1, space.Format[no].Type)
+		}
+		indexOpts := map[string]interface{}{"parts": parts}
+		if index.Type != "" {
+			indexOpts["type"] = index.Type
+		}
+		if index.Unique != nil {
+			indexOpts["unique"] = *index.Unique
+		}
+		indexes = append(indexes, []interface{}{index.Name, indexOpts})
+	}
+
+	var types [][]string
+	err := conn.EvalTyped(ensureSpaceLua, []interface{}{space.Name, opts, indexes}, &types)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create space %s: %s", space.Name, err)
+	}
+	if len(types) == 0 {
+		return nil, nil
+	}
+	return types[0], nil
+}
+
+// tuples converts records of fixture according to types of space fields.
+func (space *SpaceFixture) tuples(types []string) ([][]interface{}, error) {
+	tuples := make([][]interface{}, len(space.Records))
+	for i, record := range space.Records {
+		tuple := make([]interface{}, len(record))
+		for j, value := range record {
+			fieldType := ""
+			if j < len(types) {
+				fieldType = types[j]
+			}
+			var err error
+			if tuple[j], err = coerce(value, fieldType); err != nil {
+				return nil, fmt.Errorf("space %s, record %d, field %d: %s", space.Name, i+1, j+1, err)
+			}
+		}
+		tuples[i] = tuple
+	}
+	return tuples, nil
+}
+
+// coerce converts decoded JSON value to the type of space field.
+func coerce(value interface{}, fieldType string) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		switch fieldType {
+		case "unsigned":
+			var u uint64
+			if _, err := fmt.Sscan(v.String(), &u); err != nil {
+				return nil, fmt.Errorf("%s is not unsigned", v)
+			}
+			return u, nil
+		case "integer":
+			return v.Int64()
+		case "double":
+			return v.Float64()
+		case "string":
+			return v.String(), nil
+		}
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i := range v {
+			var err error
+			if res[i], err = coerce(v[i], ""); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k := range v {
+			var err error
+			if res[k], err = coerce(v[k], ""); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,61 @@
+package test_helpers_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/test_helpers"
+)
+
+var server = "127.0.0.1:3013"
+var opts = Opts{
+	Timeout: 500 * time.Millisecond,
+	User:    "test",
+	Pass:    "test",
+}
+
+func TestLoadFixtures(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	const path = "testdata/fixtures.json"
+	if err = test_helpers.LoadFixtures(conn, path); err != nil {
+		t.Errorf("Failed to load fixtures: %s", err.Error())
+		return
+	}
+	// Fixtures could be loaded several times.
+	if err = test_helpers.LoadFixtures(conn, path); err != nil {
+		t.Errorf("Failed to load fixtures again: %s", err.Error())
+		return
+	}
+
+	resp, err := conn.Eval("return box.space.fixtures_test:select()", []interface{}{})
+	if err != nil {
+		t.Errorf("Failed to select: %s", err.Error())
+		return
+	}
+	tuples := resp.Data[0].([]interface{})
+	if len(tuples) != 2 {
+		t.Errorf("Unexpected records: %v", tuples)
+		return
+	}
+	if tuple := tuples[0].([]interface{}); tuple[0] != uint64(1) || tuple[1] != "Alice" || tuple[2] != 2.5 {
+		t.Errorf("Unexpected record: %v", tuple)
+	}
+
+	if err = test_helpers.CleanupFixtures(conn, path); err != nil {
+		t.Errorf("Failed to cleanup fixtures: %s", err.Error())
+		return
+	}
+	resp, err = conn.Eval("return box.space.fixtures_test:len()", []interface{}{})
+	if err != nil {
+		t.Errorf("Failed to get space len: %s", err.Error())
+	} else if len(resp.Data) != 1 || resp.Data[0] != uint64(0) {
+		t.Errorf("Records are not deleted: %v", resp.Data)
+	}
+}
@@ -0,0 +1,93 @@
+package test_helpers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+)
+
+const (
+	minPollInterval = 10 * time.Millisecond
+	maxPollInterval = 500 * time.Millisecond
+)
+
+// poll calls check with exponential backoff until it returns true,
+// fails or timeout expires. The last check error is reported on timeout.
+func poll(timeout time.Duration, check func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	interval := minPollInterval
+	for {
+		done, err := check()
+		if done {
+			return nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("timeout %s expired", timeout)
+		}
+		time.Sleep(interval)
+		if interval *= 2; interval > maxPollInterval {
+			interval = maxPollInterval
+		}
+	}
+}
+
+// waitReadOnly waits until box.info.ro of instance is equal to ro.
+func waitReadOnly(conn tarantool.Connector, ro bool, timeout time.Duration) error {
+	err := poll(timeout, func() (bool, error) {
+		var res []bool
+		if err := conn.EvalTyped("return box.info.ro", []interface{}{}, &res); err != nil {
+			return false, err
+		}
+		return len(res) == 1 && res[0] == ro, nil
+	})
+	if err != nil {
+		return fmt.Errorf("instance is not switched to ro=%t: %s", ro, err)
+	}
+	return nil
+}
+
+// WaitUntilRW waits until instance becomes writable (box.info.ro is false).
+// Note: it uses Eval, so user needs 'execute universe' privilege.
+func WaitUntilRW(conn tarantool.Connector, timeout time.Duration) error {
+	return waitReadOnly(conn, false, timeout)
+}
+
+// WaitUntilRO waits until instance becomes read-only (box.info.ro is true).
+// Note: it uses Eval, so user needs 'execute universe' privilege.
+func WaitUntilRO(conn tarantool.Connector, timeout time.Duration) error {
+	return waitReadOnly(conn, true, timeout)
+}
+
+// WaitUntilReplicated waits until replica applies all changes made on
+// master at the moment of the call, i.e. until replica's vclock component
+// of master reaches master's own one.
+// Note: it uses Eval, so user needs 'execute universe' privilege.
+func WaitUntilReplicated(master, replica tarantool.Connector, timeout time.Duration) error {
+	var lsn []uint64
+	err := master.EvalTyped("local id = box.info.id return id, box.info.vclock[id] or 0",
+		[]interface{}{}, &lsn)
+	if err != nil {
+		return fmt.Errorf("failed to get master vclock: %s", err)
+	}
+	if len(lsn) != 2 {
+		return fmt.Errorf("unexpected master vclock: %v", lsn)
+	}
+	id, masterLsn := lsn[0], lsn[1]
+
+	err = poll(timeout, func() (bool, error) {
+		var res []uint64
+		err := replica.EvalTyped("return box.info.vclock[...] or 0", []interface{}{id}, &res)
+		if err != nil {
+			return false, err
+		}
+		return len(res) == 1 && res[0] >= masterLsn, nil
+	})
+	if err != nil {
+		return fmt.Errorf("replica has not reached lsn %d of master %d: %s", masterLsn, id, err)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package test_helpers_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/test_helpers"
+)
+
+func TestWaitUntilRW(t *testing.T) {
+	conn, err := Connect(server, opts)
+	if err != nil {
+		t.Errorf("Failed to connect: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	if err = test_helpers.WaitUntilRW(conn, time.Second); err != nil {
+		t.Errorf("Instance is not writable: %s", err.Error())
+	}
+	if err = test_helpers.WaitUntilRO(conn, 100*time.Millisecond); err == nil {
+		t.Errorf("Writable instance is reported as read-only")
+	}
+	// Instance is replicated to itself.
+	if err = test_helpers.WaitUntilReplicated(conn, conn, time.Second); err != nil {
+		t.Errorf("Failed to wait for replication: %s", err.Error())
+	}
+}
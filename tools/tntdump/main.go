@@ -0,0 +1,66 @@
+// Command tntdump prints traffic dump written by Opts.TrafficDump.
+//
+// Usage:
+//
+//	tntdump [file]
+//
+// Dump is read from stdin if file is not specified.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/tarantool/go-tarantool"
+	"github.com/tarantool/go-tarantool/dump"
+)
+
+func printPacket(w io.Writer, rec *tarantool.TrafficRecord, packet []byte) {
+	s, err := dump.Packet(packet)
+	if err != nil {
+		s += " malformed packet: " + err.Error()
+	}
+	fmt.Fprintf(w, "%s %c %s\n", rec.Time.Format(time.RFC3339Nano), rec.Direction, s)
+}
+
+func run(r io.Reader, w io.Writer) error {
+	for {
+		rec, err := tarantool.ReadTrafficRecord(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		packets, err := rec.Packets()
+		for _, packet := range packets {
+			printPacket(w, rec, packet)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func main() {
+	var r io.Reader = os.Stdin
+	if len(os.Args) > 1 {
+		f, err := os.Open(os.Args[1])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		r = f
+	}
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	if err := run(bufio.NewReader(r), w); err != nil {
+		w.Flush()
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
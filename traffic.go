@@ -0,0 +1,99 @@
+package tarantool
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// TrafficDirection is a direction of dumped traffic.
+type TrafficDirection byte
+
+const (
+	// TrafficOut marks requests sent to Tarantool.
+	TrafficOut TrafficDirection = '>'
+	// TrafficIn marks responses received from Tarantool.
+	TrafficIn TrafficDirection = '<'
+)
+
+// TrafficRecord is a record of traffic dump, see Opts.TrafficDump.
+//
+// Record is written as direction byte, 8 bytes of unix time in nanoseconds,
+// 4 bytes of payload length (big endian) and payload. Payload contains one
+// or more complete iproto packets including their length prefix.
+type TrafficRecord struct {
+	Direction TrafficDirection
+	Time      time.Time
+	Payload   []byte
+}
+
+const trafficHeaderLen = 1 + 8 + 4
+
+// Packets splits payload into iproto packets without length prefix.
+func (rec *TrafficRecord) Packets() ([][]byte, error) {
+	var packets [][]byte
+	b := rec.Payload
+	for len(b) > 0 {
+		if len(b) < 5 || b[0] != 0xce {
+			return packets, errors.New("wrong packet header")
+		}
+		l := int(binary.BigEndian.Uint32(b[1:5]))
+		if len(b) < 5+l {
+			return packets, errors.New("truncated packet")
+		}
+		packets = append(packets, b[5:5+l])
+		b = b[5+l:]
+	}
+	return packets, nil
+}
+
+// ReadTrafficRecord reads record of traffic dump. It returns io.EOF at the
+// end of dump.
+func ReadTrafficRecord(r io.Reader) (*TrafficRecord, error) {
+	var header [trafficHeaderLen]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	rec := &TrafficRecord{
+		Direction: TrafficDirection(header[0]),
+		Time:      time.Unix(0, int64(binary.BigEndian.Uint64(header[1:9]))),
+		Payload:   make([]byte, binary.BigEndian.Uint32(header[9:13])),
+	}
+	if rec.Direction != TrafficOut && rec.Direction != TrafficIn {
+		return nil, fmt.Errorf("wrong traffic direction %q", header[0])
+	}
+	if _, err := io.ReadFull(r, rec.Payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return rec, nil
+}
+
+// trafficDumper writes records of traffic dump from writer and reader
+// goroutines. Write errors are ignored, so dump doesn't affect requests.
+type trafficDumper struct {
+	mutex sync.Mutex
+	w     io.Writer
+	buf   []byte
+}
+
+// dump writes record with payload = prefix + packets.
+func (d *trafficDumper) dump(dir TrafficDirection, now time.Time, prefix, packets []byte) {
+	if d == nil {
+		return
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.buf = append(d.buf[:0], byte(dir))
+	d.buf = d.buf[:trafficHeaderLen]
+	binary.BigEndian.PutUint64(d.buf[1:9], uint64(now.UnixNano()))
+	binary.BigEndian.PutUint32(d.buf[9:13], uint32(len(prefix)+len(packets)))
+	d.buf = append(d.buf, prefix...)
+	d.buf = append(d.buf, packets...)
+	d.w.Write(d.buf)
+}
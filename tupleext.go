@@ -0,0 +1,186 @@
+package tarantool
+
+import (
+	"fmt"
+	"sync"
+
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// tupleExtId is an id of MP_TUPLE extension type.
+const tupleExtId = 7
+
+// TupleFormat is a format of tuples of MP_TUPLE extension. Formats are
+// sent by Tarantool in responses with IPROTO_TUPLE_FORMATS and are cached
+// by connection until reconnect, since format ids are valid within a
+// session only.
+type TupleFormat struct {
+	Id     uint64
+	Fields []*Field
+}
+
+// FieldNo returns 0-based number of field with name.
+func (format *TupleFormat) FieldNo(name string) (int, bool) {
+	for i, field := range format.Fields {
+		if field.Name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// decodeTupleFormat decodes format as array of field definitions like
+// space:format().
+func decodeTupleFormat(id uint64, d *msgpack.Decoder) (*TupleFormat, error) {
+	v, err := d.DecodeInterface()
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("tuple format %d is not an array: %v", id, v)
+	}
+	format := &TupleFormat{Id: id}
+	for i, f := range fields {
+		field := &Field{Id: uint32(i)}
+		if f, ok := f.(map[interface{}]interface{}); ok {
+			field.Name, _ = f["name"].(string)
+			field.Type, _ = f["type"].(string)
+			field.IsNullable, _ = f["is_nullable"].(bool)
+		}
+		format.Fields = append(format.Fields, field)
+	}
+	return format, nil
+}
+
+// decodeTupleFormats decodes IPROTO_TUPLE_FORMATS: map of format ids to
+// formats.
+func decodeTupleFormats(d *msgpack.Decoder) (map[uint64]*TupleFormat, error) {
+	l, err := d.DecodeMapLen()
+	if err != nil {
+		return nil, err
+	}
+	formats := make(map[uint64]*TupleFormat, l)
+	for ; l > 0; l-- {
+		id, err := d.DecodeUint64()
+		if err != nil {
+			return nil, err
+		}
+		if formats[id], err = decodeTupleFormat(id, d); err != nil {
+			return nil, err
+		}
+	}
+	return formats, nil
+}
+
+// ExtTuple is a tuple decoded from MP_TUPLE extension, which is sent by
+// Tarantool instead of arrays if Opts.TupleExtension is set. Tuples in
+// Response.Data have Format set; tuples decoded with GetTyped have only
+// FormatId, their formats are returned by Connection.TupleFormat.
+type ExtTuple struct {
+	FormatId uint64
+	Format   *TupleFormat
+	Fields   []interface{}
+}
+
+// Get returns value of field with name, it returns false if format is
+// unknown, there is no such field in format or tuple is shorter.
+func (t ExtTuple) Get(name string) (interface{}, bool) {
+	if t.Format == nil {
+		return nil, false
+	}
+	no, ok := t.Format.FieldNo(name)
+	if !ok || no >= len(t.Fields) {
+		return nil, false
+	}
+	return t.Fields[no], true
+}
+
+// EncodeMsgpack encodes tuple as MP_TUPLE payload: format id and array of
+// fields.
+func (t ExtTuple) EncodeMsgpack(e *msgpack.Encoder) error {
+	if err := e.EncodeUint64(t.FormatId); err != nil {
+		return err
+	}
+	return e.Encode(t.Fields)
+}
+
+// DecodeMsgpack decodes MP_TUPLE payload.
+func (t *ExtTuple) DecodeMsgpack(d *msgpack.Decoder) error {
+	var err error
+	if t.FormatId, err = d.DecodeUint64(); err != nil {
+		return err
+	}
+	v, err := d.DecodeInterface()
+	if err != nil {
+		return err
+	}
+	var ok bool
+	if t.Fields, ok = v.([]interface{}); !ok {
+		return fmt.Errorf("tuple fields are not an array: %v", v)
+	}
+	return nil
+}
+
+func init() {
+	msgpack.RegisterExt(tupleExtId, (*ExtTuple)(nil))
+}
+
+// tupleFormats is a cache of tuple formats of connection.
+type tupleFormats struct {
+	mutex   sync.RWMutex
+	formats map[uint64]*TupleFormat
+}
+
+func (c *tupleFormats) add(formats map[uint64]*TupleFormat) {
+	if len(formats) == 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.formats == nil {
+		c.formats = make(map[uint64]*TupleFormat)
+	}
+	for id, format := range formats {
+		c.formats[id] = format
+	}
+}
+
+func (c *tupleFormats) get(id uint64) (*TupleFormat, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	format, ok := c.formats[id]
+	return format, ok
+}
+
+func (c *tupleFormats) reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.formats = nil
+}
+
+// bind sets formats of tuples in v, which is decoded with
+// DecodeInterface, and returns v with updated tuples.
+func (c *tupleFormats) bind(v interface{}) interface{} {
+	switch v := v.(type) {
+	case ExtTuple:
+		if v.Format == nil {
+			v.Format, _ = c.get(v.FormatId)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = c.bind(v[i])
+		}
+	case map[interface{}]interface{}:
+		for k := range v {
+			v[k] = c.bind(v[k])
+		}
+	}
+	return v
+}
+
+// TupleFormat returns cached format of MP_TUPLE tuples by id.
+func (conn *Connection) TupleFormat(id uint64) (*TupleFormat, bool) {
+	return conn.tupleFormats.get(id)
+}
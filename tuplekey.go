@@ -0,0 +1,276 @@
+package tarantool
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+)
+
+// ExtractKey returns key parts of tuple in order of index parts.
+func (index *Index) ExtractKey(tuple []interface{}) ([]interface{}, error) {
+	key := make([]interface{}, len(index.Fields))
+	for i, part := range index.Fields {
+		if int(part.Id) >= len(tuple) {
+			return nil, fmt.Errorf("tuple has no field %d of index %s", part.Id+1, index.Name)
+		}
+		key[i] = tuple[part.Id]
+	}
+	return key, nil
+}
+
+// Compare compares tuples by index key: it returns -1 if a is less than b,
+// 0 if keys are equal, and 1 otherwise.
+//
+// Parts are compared by their types, strings are compared as bytes since
+// collations are not loaded with schema.
+func (index *Index) Compare(a, b []interface{}) (int, error) {
+	ka, err := index.ExtractKey(a)
+	if err != nil {
+		return 0, err
+	}
+	kb, err := index.ExtractKey(b)
+	if err != nil {
+		return 0, err
+	}
+	return index.CompareKeys(ka, kb)
+}
+
+// CompareKeys compares keys of index. Keys may be partial, then only
+// common parts are compared, like server does for partial keys.
+func (index *Index) CompareKeys(a, b []interface{}) (int, error) {
+	for i := 0; i < len(a) && i < len(b) && i < len(index.Fields); i++ {
+		c, err := compareValues(a[i], b[i])
+		if err != nil {
+			return 0, fmt.Errorf("part %d of index %s: %s", i+1, index.Name, err)
+		}
+		if c != 0 {
+			return c, nil
+		}
+	}
+	return 0, nil
+}
+
+// Value classes in order of scalar comparison: nil is less than anything.
+const (
+	classNil = iota
+	classBool
+	classNumber
+	classString
+	classBinary
+)
+
+func valueClass(v interface{}) (int, error) {
+	switch v.(type) {
+	case nil:
+		return classNil, nil
+	case bool:
+		return classBool, nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return classNumber, nil
+	case string:
+		return classString, nil
+	case []byte:
+		return classBinary, nil
+	}
+	return 0, fmt.Errorf("value of type %T is not comparable", v)
+}
+
+func compareValues(a, b interface{}) (int, error) {
+	ca, err := valueClass(a)
+	if err != nil {
+		return 0, err
+	}
+	cb, err := valueClass(b)
+	if err != nil {
+		return 0, err
+	}
+	if ca != cb {
+		return compareInts(int64(ca), int64(cb)), nil
+	}
+	switch ca {
+	case classBool:
+		return compareInts(boolInt(a.(bool)), boolInt(b.(bool))), nil
+	case classNumber:
+		return compareNumbers(a, b), nil
+	case classString:
+		return compareStrings(a.(string), b.(string)), nil
+	case classBinary:
+		return bytes.Compare(a.([]byte), b.([]byte)), nil
+	}
+	return 0, nil
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareStrings(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// number is a numeric value: unsigned values that don't fit int64 are kept
+// as is to be compared exactly.
+type number struct {
+	isUint bool
+	u      uint64
+	i      int64
+	f      float64
+	isF    bool
+}
+
+func toNumber(v interface{}) number {
+	switch v := v.(type) {
+	case int:
+		return number{i: int64(v)}
+	case int8:
+		return number{i: int64(v)}
+	case int16:
+		return number{i: int64(v)}
+	case int32:
+		return number{i: int64(v)}
+	case int64:
+		return number{i: v}
+	case uint:
+		return fromUint(uint64(v))
+	case uint8:
+		return number{i: int64(v)}
+	case uint16:
+		return number{i: int64(v)}
+	case uint32:
+		return number{i: int64(v)}
+	case uint64:
+		return fromUint(v)
+	case float32:
+		return number{f: float64(v), isF: true}
+	case float64:
+		return number{f: v, isF: true}
+	}
+	return number{}
+}
+
+func fromUint(u uint64) number {
+	if u > math.MaxInt64 {
+		return number{isUint: true, u: u}
+	}
+	return number{i: int64(u)}
+}
+
+func (n number) float() float64 {
+	switch {
+	case n.isF:
+		return n.f
+	case n.isUint:
+		return float64(n.u)
+	}
+	return float64(n.i)
+}
+
+func compareNumbers(a, b interface{}) int {
+	na, nb := toNumber(a), toNumber(b)
+	switch {
+	case na.isF || nb.isF:
+		fa, fb := na.float(), nb.float()
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	case na.isUint && nb.isUint:
+		switch {
+		case na.u < nb.u:
+			return -1
+		case na.u > nb.u:
+			return 1
+		}
+		return 0
+	case na.isUint:
+		return 1
+	case nb.isUint:
+		return -1
+	}
+	return compareInts(na.i, nb.i)
+}
+
+// ValidateTuple checks tuple against space format: number of fields,
+// types and nullability of fields which are present in format. Values of
+// double fields should be floats, as integers are rejected by tarantool.
+// Errors are of type TupleValidationError.
+func (space *Space) ValidateTuple(tuple []interface{}) error {
+	if space.FieldsCount > 0 && uint32(len(tuple)) != space.FieldsCount {
+		return TupleValidationError{
+			Space: space.Name,
+			Msg:   fmt.Sprintf("tuple has %d fields, expected %d", len(tuple), space.FieldsCount),
+		}
+	}
+	for id, field := range space.FieldsById {
+		if int(id) >= len(tuple) {
+			if !field.IsNullable {
+				return space.fieldError(field, "field is missing")
+			}
+			continue
+		}
+		if err := space.validateValue(field, tuple[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matchesType(v interface{}, fieldType string) bool {
+	class, err := valueClass(v)
+	switch fieldType {
+	case "unsigned", "uint", "num":
+		if err != nil || class != classNumber {
+			return false
+		}
+		n := toNumber(v)
+		return !n.isF && (n.isUint || n.i >= 0)
+	case "integer", "int":
+		return err == nil && class == classNumber && !toNumber(v).isF
+	case "number":
+		return err == nil && class == classNumber
+	case "double":
+		// tarantool rejects integers in double fields
+		return err == nil && class == classNumber && toNumber(v).isF
+	case "string", "str":
+		return err == nil && class == classString
+	case "boolean":
+		return err == nil && class == classBool
+	case "varbinary":
+		return err == nil && class == classBinary
+	case "scalar":
+		return err == nil
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "map":
+		_, ok := v.(map[interface{}]interface{})
+		if !ok {
+			_, ok = v.(map[string]interface{})
+		}
+		return ok
+	}
+	// any and extension types are not checked
+	return true
+}
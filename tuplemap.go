@@ -0,0 +1,72 @@
+package tarantool
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// TupleMap converts tuple to a map from names of space format fields to
+// values. Fields which are not named in space format are keyed by their
+// 1-based number, e.g. "3".
+func (space *Space) TupleMap(tuple []interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(tuple))
+	for i, value := range tuple {
+		if field, ok := space.FieldsById[uint32(i)]; ok && field.Name != "" {
+			m[field.Name] = value
+		} else {
+			m[strconv.Itoa(i+1)] = value
+		}
+	}
+	return m
+}
+
+// spaceOf returns loaded space description for space name or number.
+func (conn *Connection) spaceOf(s interface{}) (*Space, error) {
+	if conn.Schema == nil {
+		return nil, fmt.Errorf("Schema is not loaded")
+	}
+	spaceNo, _, err := conn.resolveSpaceIndex(s, nil)
+	if err != nil {
+		return nil, err
+	}
+	space, ok := conn.Schema.SpacesById[spaceNo]
+	if !ok {
+		return nil, fmt.Errorf("there is no space with id %d", spaceNo)
+	}
+	return space, nil
+}
+
+// SelectMaps performs select to box space and returns tuples as maps keyed
+// by field names of space format, see Space.TupleMap.
+// It is intended for dynamic consumers which don't know tuple structure in
+// advance. Space should be present in loaded schema.
+func (conn *Connection) SelectMaps(space, index interface{}, offset, limit, iterator uint32, key interface{}) ([]map[string]interface{}, error) {
+	spaceDesc, err := conn.spaceOf(space)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := conn.Select(spaceDesc.Id, index, offset, limit, iterator, key)
+	if err != nil {
+		return nil, err
+	}
+	maps := make([]map[string]interface{}, 0, len(resp.Data))
+	for _, row := range resp.Data {
+		tuple, ok := row.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected tuple type %T", row)
+		}
+		maps = append(maps, spaceDesc.TupleMap(tuple))
+	}
+	return maps, nil
+}
+
+// GetMap performs select (with limit = 1 and offset = 0) to box space and
+// returns tuple as map keyed by field names of space format, see
+// Space.TupleMap. It returns nil map if tuple is not found.
+func (conn *Connection) GetMap(space, index interface{}, key interface{}) (map[string]interface{}, error) {
+	maps, err := conn.SelectMaps(space, index, 0, 1, IterEq, key)
+	if err != nil || len(maps) == 0 {
+		return nil, err
+	}
+	return maps[0], nil
+}
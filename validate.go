@@ -0,0 +1,157 @@
+package tarantool
+
+import (
+	"fmt"
+)
+
+// TupleValidationError is returned when tuple or update operations don't
+// match space format.
+type TupleValidationError struct {
+	Space string
+	// FieldNo is a 1-based number of field, it is zero for errors of the
+	// whole tuple.
+	FieldNo uint32
+	// Field is a name of field in space format.
+	Field string
+	Msg   string
+}
+
+func (err TupleValidationError) Error() string {
+	if err.FieldNo == 0 {
+		return fmt.Sprintf("space %s: %s", err.Space, err.Msg)
+	}
+	if err.Field == "" {
+		return fmt.Sprintf("space %s: field %d: %s", err.Space, err.FieldNo, err.Msg)
+	}
+	return fmt.Sprintf("space %s: field %d (%s): %s", err.Space, err.FieldNo, err.Field, err.Msg)
+}
+
+func (space *Space) fieldError(field *Field, format string, args ...interface{}) error {
+	return TupleValidationError{
+		Space:   space.Name,
+		FieldNo: field.Id + 1,
+		Field:   field.Name,
+		Msg:     fmt.Sprintf(format, args...),
+	}
+}
+
+func (space *Space) validateValue(field *Field, v interface{}) error {
+	if v == nil {
+		if !field.IsNullable {
+			return space.fieldError(field, "field is not nullable")
+		}
+		return nil
+	}
+	if !matchesType(v, field.Type) {
+		return space.fieldError(field, "value of type %T does not match %s", v, field.Type)
+	}
+	return nil
+}
+
+// ValidateOps checks update operations against space format: values of
+// assignments and types of fields of arithmetic, bitwise and splice
+// operations. Operations should be arrays {op, field, args...}, where
+// field is a 0-based number or a name; negative numbers and JSON paths
+// are not checked. Errors are of type TupleValidationError.
+func (space *Space) ValidateOps(ops []interface{}) error {
+	for _, op := range ops {
+		op, ok := op.([]interface{})
+		if !ok || len(op) < 3 {
+			continue
+		}
+		name, _ := op[0].(string)
+		field := space.opField(op[1])
+		if field == nil {
+			continue
+		}
+		switch name {
+		case "=", "!":
+			if err := space.validateValue(field, op[2]); err != nil {
+				return err
+			}
+		case "+", "-":
+			if !matchesType(op[2], "number") {
+				return space.fieldError(field, "argument of %s of type %T is not a number", name, op[2])
+			}
+			if !allowsType(field.Type, "number", "double", "integer", "int", "unsigned", "uint", "num", "scalar") {
+				return space.fieldError(field, "operation %s on field of type %s", name, field.Type)
+			}
+		case "&", "|", "^":
+			if !allowsType(field.Type, "unsigned", "uint", "num", "scalar") {
+				return space.fieldError(field, "operation %s on field of type %s", name, field.Type)
+			}
+		case ":":
+			if !allowsType(field.Type, "string", "str", "scalar") {
+				return space.fieldError(field, "operation %s on field of type %s", name, field.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// allowsType reports whether field type is one of types or is not checked
+// by client, e.g. any.
+func allowsType(fieldType string, types ...string) bool {
+	for _, t := range types {
+		if fieldType == t {
+			return true
+		}
+	}
+	switch fieldType {
+	case "unsigned", "uint", "num", "integer", "int", "number", "double",
+		"string", "str", "boolean", "varbinary", "scalar", "array", "map":
+		return false
+	}
+	return true
+}
+
+func (space *Space) opField(f interface{}) *Field {
+	switch f := f.(type) {
+	case string:
+		if field, ok := space.Fields[f]; ok {
+			return field
+		}
+		for _, field := range space.FieldsById {
+			if field.Name == f {
+				return field
+			}
+		}
+	case int:
+		if f >= 0 {
+			return space.FieldsById[uint32(f)]
+		}
+	case int64:
+		if f >= 0 {
+			return space.FieldsById[uint32(f)]
+		}
+	case uint:
+		return space.FieldsById[uint32(f)]
+	case uint32:
+		return space.FieldsById[f]
+	case uint64:
+		return space.FieldsById[uint32(f)]
+	}
+	return nil
+}
+
+// validateWrite checks tuple and update operations if Opts.ValidateTuples
+// is set and schema of the space is loaded. Only tuples and operations of
+// type []interface{} are checked.
+func (conn *Connection) validateWrite(spaceNo uint32, tuple, ops interface{}) error {
+	if !conn.opts.ValidateTuples || conn.Schema == nil {
+		return nil
+	}
+	space, ok := conn.Schema.SpacesById[spaceNo]
+	if !ok {
+		return nil
+	}
+	if tuple, ok := tuple.([]interface{}); ok {
+		if err := space.ValidateTuple(tuple); err != nil {
+			return err
+		}
+	}
+	if ops, ok := ops.([]interface{}); ok {
+		return space.ValidateOps(ops)
+	}
+	return nil
+}